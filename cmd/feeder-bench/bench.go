@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"feeder-service/pkg/client"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of the generated messages.
+const (
+	kindValid     = "valid"
+	kindDuplicate = "duplicate"
+	kindInvalid   = "invalid"
+)
+
+// mix holds the weight of each kind of message, e.g. 80 valid, 15 duplicate and 5 invalid messages of every 100.
+type mix map[string]int
+
+var errInvalidMix = errors.New("invalid mix")
+
+// parseMix parses a mix like "valid=80,duplicate=15,invalid=5", the missing kinds have no weight.
+func parseMix(value string) (mix, error) {
+	parsed := mix{}
+	total := 0
+	for _, part := range strings.Split(value, ",") {
+		kind, weightValue := part, ""
+		if index := strings.Index(part, "="); index >= 0 {
+			kind, weightValue = strings.TrimSpace(part[:index]), strings.TrimSpace(part[index+1:])
+		}
+		if kind != kindValid && kind != kindDuplicate && kind != kindInvalid {
+			return nil, fmt.Errorf("%w: unknown kind %q", errInvalidMix, kind)
+		}
+		weight, err := strconv.Atoi(weightValue)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("%w: the weight of %s must be a non negative integer", errInvalidMix, kind)
+		}
+		parsed[kind] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%w: every weight is zero", errInvalidMix)
+	}
+
+	return parsed, nil
+}
+
+func (m mix) String() string {
+	return fmt.Sprintf("%s=%d,%s=%d,%s=%d", kindValid, m[kindValid], kindDuplicate, m[kindDuplicate], kindInvalid, m[kindInvalid])
+}
+
+// generator returns the messages of the mix: the valid ones are skus never generated before, the duplicates
+// repeat a valid one already generated and the invalid ones don't have the sku format. The valid skus are numbered,
+// so they're not kept: once every sku of the run is generated the next valid ones are duplicates.
+type generator struct {
+	mix    mix
+	total  int
+	random *rand.Rand
+	// run makes the skus of every bench different, so a bench against a server with skus doesn't duplicate them.
+	run int
+	// generated is the number of valid skus generated, up to maxValid.
+	generated int
+	maxValid  int
+}
+
+func newGenerator(messagesMix mix, seed int64) *generator {
+	total := 0
+	for _, weight := range messagesMix {
+		total += weight
+	}
+	random := rand.New(rand.NewSource(seed))
+
+	return &generator{mix: messagesMix, total: total, random: random, run: random.Intn(26 * 26), maxValid: maxValidSkus}
+}
+
+// maxValidSkus is the number of different skus with the default format of a run: two letters for the run, two
+// more letters and four digits.
+const maxValidSkus = 26 * 26 * 10000
+
+func (g *generator) next() (string, string) {
+	kind := g.kind()
+	if kind == kindDuplicate && g.generated == 0 {
+		kind = kindValid
+	}
+	if kind == kindValid && g.generated == g.maxValid {
+		kind = kindDuplicate
+	}
+	switch kind {
+	case kindDuplicate:
+		return kind, g.sku(g.random.Intn(g.generated))
+	case kindInvalid:
+		return kind, fmt.Sprintf("invalid-%d", g.random.Int63())
+	default:
+		g.generated++
+		return kind, g.sku(g.generated - 1)
+	}
+}
+
+// sku returns the valid sku of the index, lower than maxValidSkus.
+func (g *generator) sku(index int) string {
+	return fmt.Sprintf("%s%s-%04d", letters(g.run), letters(index/10000), index%10000)
+}
+
+func (g *generator) kind() string {
+	pick := g.random.Intn(g.total)
+	for _, kind := range []string{kindValid, kindDuplicate, kindInvalid} {
+		if pick < g.mix[kind] {
+			return kind
+		}
+		pick -= g.mix[kind]
+	}
+
+	return kindValid
+}
+
+// letters returns two uppercase letters for a number lower than 26*26.
+func letters(number int) string {
+	return string([]byte{byte('A' + number/26%26), byte('A' + number%26)})
+}
+
+// latencies summarises the time to get the ack of the messages, in milliseconds.
+type latencies struct {
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+func newLatencies(durations []time.Duration) latencies {
+	if len(durations) == 0 {
+		return latencies{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, duration := range sorted {
+		total += duration
+	}
+
+	return latencies{
+		P50:  milliseconds(percentile(sorted, 50)),
+		P90:  milliseconds(percentile(sorted, 90)),
+		P95:  milliseconds(percentile(sorted, 95)),
+		P99:  milliseconds(percentile(sorted, 99)),
+		Max:  milliseconds(sorted[len(sorted)-1]),
+		Mean: milliseconds(total / time.Duration(len(sorted))),
+	}
+}
+
+// percentile returns the nearest rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+// benchConfig is what a bench sends: the messages of the mix at the rate per second (as fast as possible when
+// it's zero) until it sends the number of messages or the duration ends, whatever happens first.
+type benchConfig struct {
+	mix         mix
+	rate        float64
+	messages    int
+	duration    time.Duration
+	connections int
+	seed        int64
+}
+
+// result is the outcome of a bench, Outcomes holds the count of each ack outcome and Kinds the count of each
+// kind of message sent.
+type result struct {
+	Target          string         `json:"target"`
+	Slots           int            `json:"slots,omitempty"`
+	Connections     int            `json:"connections"`
+	Mix             string         `json:"mix"`
+	TargetRate      float64        `json:"target_rate"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Sent            int            `json:"sent"`
+	Acked           int            `json:"acked"`
+	Failed          int            `json:"failed"`
+	Throughput      float64        `json:"throughput"`
+	LatencyMs       latencies      `json:"latency_ms"`
+	Kinds           map[string]int `json:"kinds"`
+	Outcomes        map[string]int `json:"outcomes"`
+	Errors          map[string]int `json:"errors"`
+	// ServerReport holds the counters of the run report of the in-process server.
+	ServerReport map[string]int `json:"server_report,omitempty"`
+}
+
+// sender sends a message and returns its ack, it's implemented by client.Client.
+type sender interface {
+	Send(ctx context.Context, message string) (client.Ack, error)
+}
+
+type generatedMessage struct {
+	kind  string
+	value string
+}
+
+// bench sends the generated messages through as many goroutines as connections, paced by the rate. The
+// messages in progress when the duration ends wait for their ack.
+func bench(ctx context.Context, messageSender sender, cfg benchConfig) result {
+	benchResult := result{
+		Connections: cfg.connections,
+		Mix:         cfg.mix.String(),
+		TargetRate:  cfg.rate,
+		Kinds:       map[string]int{},
+		Outcomes:    map[string]int{},
+		Errors:      map[string]int{},
+	}
+	var durations []time.Duration
+	var mutex sync.Mutex
+	messages := make(chan generatedMessage)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.connections; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for message := range messages {
+				sentAt := time.Now()
+				ack, err := messageSender.Send(ctx, message.value)
+				duration := time.Since(sentAt)
+				mutex.Lock()
+				benchResult.Sent++
+				benchResult.Kinds[message.kind]++
+				if err != nil {
+					benchResult.Failed++
+					benchResult.Errors[err.Error()]++
+				} else {
+					benchResult.Acked++
+					benchResult.Outcomes[ack.Outcome]++
+					durations = append(durations, duration)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	startedAt := time.Now()
+	dispatch(ctx, messages, cfg, startedAt)
+	close(messages)
+	wg.Wait()
+
+	elapsed := time.Since(startedAt).Seconds()
+	benchResult.DurationSeconds = elapsed
+	if elapsed > 0 {
+		benchResult.Throughput = float64(benchResult.Acked) / elapsed
+	}
+	benchResult.LatencyMs = newLatencies(durations)
+
+	return benchResult
+}
+
+// dispatch generates the messages of the bench until it's done, the i-th message is not dispatched before
+// i/rate seconds since the start.
+func dispatch(ctx context.Context, messages chan<- generatedMessage, cfg benchConfig, startedAt time.Time) {
+	if cfg.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, startedAt.Add(cfg.duration))
+		defer cancel()
+	}
+	messageGenerator := newGenerator(cfg.mix, cfg.seed)
+	for i := 0; cfg.messages <= 0 || i < cfg.messages; i++ {
+		if cfg.rate > 0 && !sleepUntil(ctx, startedAt.Add(time.Duration(float64(i)/cfg.rate*float64(time.Second)))) {
+			return
+		}
+		kind, value := messageGenerator.next()
+		select {
+		case messages <- generatedMessage{kind: kind, value: value}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sleepUntil returns false when the context is done before the time.
+func sleepUntil(ctx context.Context, at time.Time) bool {
+	wait := time.Until(at)
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
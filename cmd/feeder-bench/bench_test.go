@@ -0,0 +1,132 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"feeder-service/pkg/client"
+	"github.com/stretchr/testify/suite"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+type BenchUnitSuite struct {
+	suite.Suite
+}
+
+func TestBenchUnitSuite(t *testing.T) {
+	suite.Run(t, new(BenchUnitSuite))
+}
+
+func (s *BenchUnitSuite) TestParseTheWeightOfEachKind() {
+	parsed, err := parseMix("valid=80, duplicate=15,invalid=5")
+	s.Require().NoError(err)
+	s.Require().Equal(mix{kindValid: 80, kindDuplicate: 15, kindInvalid: 5}, parsed)
+
+	for _, value := range []string{"", "valid", "valid=-1", "valid=0", "archived=5"} {
+		_, err = parseMix(value)
+		s.Require().ErrorIs(err, errInvalidMix, value)
+	}
+}
+
+func (s *BenchUnitSuite) TestTheValidSkusAreUniqueAndTheDuplicatesRepeatThem() {
+	messageGenerator := newGenerator(mix{kindValid: 2, kindDuplicate: 1, kindInvalid: 1}, 1)
+	skuFormat := regexp.MustCompile("^[A-Z]{4}-[0-9]{4}$")
+	valid := map[string]bool{}
+	kinds := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		kind, value := messageGenerator.next()
+		kinds[kind]++
+		switch kind {
+		case kindValid:
+			s.Require().Regexp(skuFormat, value)
+			s.Require().False(valid[value], value)
+			valid[value] = true
+		case kindDuplicate:
+			s.Require().True(valid[value], value)
+		default:
+			s.Require().NotRegexp(skuFormat, value)
+		}
+	}
+	s.Require().InDelta(500, kinds[kindValid], 60)
+	s.Require().InDelta(250, kinds[kindDuplicate], 60)
+	s.Require().InDelta(250, kinds[kindInvalid], 60)
+}
+
+func (s *BenchUnitSuite) TestTheValidSkusAreDuplicatesOnceEverySkuOfTheRunIsGenerated() {
+	messageGenerator := newGenerator(mix{kindValid: 1}, 1)
+	messageGenerator.maxValid = 3
+	valid := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		kind, value := messageGenerator.next()
+		s.Require().Equal(kindValid, kind)
+		valid[value] = true
+	}
+
+	for i := 0; i < 10; i++ {
+		kind, value := messageGenerator.next()
+		s.Require().Equal(kindDuplicate, kind)
+		s.Require().True(valid[value], value)
+	}
+	s.Require().Equal(3, messageGenerator.generated)
+}
+
+func (s *BenchUnitSuite) TestTheLatenciesAreTheNearestRankPercentiles() {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	s.Require().Equal(latencies{P50: 50, P90: 90, P95: 95, P99: 99, Max: 100, Mean: 50.5}, newLatencies(durations))
+	s.Require().Equal(latencies{}, newLatencies(nil))
+}
+
+// fakeSender acks every message as created, but the ones starting with "invalid".
+type fakeSender struct {
+	mutex sync.Mutex
+	sent  []string
+}
+
+func (f *fakeSender) Send(_ context.Context, message string) (client.Ack, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.sent = append(f.sent, message)
+	if regexp.MustCompile("^invalid").MatchString(message) {
+		return client.Ack{Outcome: client.OutcomeInvalid, Reason: "invalid_format"}, nil
+	}
+
+	return client.Ack{Outcome: client.OutcomeCreated}, nil
+}
+
+func (s *BenchUnitSuite) TestTheBenchSendsTheMessagesAndCountsTheirOutcomes() {
+	messageSender := &fakeSender{}
+
+	benchResult := bench(context.Background(), messageSender, benchConfig{
+		mix:         mix{kindValid: 1, kindInvalid: 1},
+		messages:    100,
+		duration:    time.Minute,
+		connections: 4,
+		seed:        1,
+	})
+	s.Require().Len(messageSender.sent, 100)
+	s.Require().Equal(100, benchResult.Sent)
+	s.Require().Equal(100, benchResult.Acked)
+	s.Require().Equal(benchResult.Kinds[kindValid], benchResult.Outcomes[client.OutcomeCreated])
+	s.Require().Equal(benchResult.Kinds[kindInvalid], benchResult.Outcomes[client.OutcomeInvalid])
+	s.Require().Greater(benchResult.Throughput, 0.0)
+}
+
+func (s *BenchUnitSuite) TestTheRatePacesTheMessages() {
+	startedAt := time.Now()
+	benchResult := bench(context.Background(), &fakeSender{}, benchConfig{
+		mix:         mix{kindValid: 1},
+		rate:        100,
+		messages:    11,
+		duration:    time.Minute,
+		connections: 1,
+	})
+	s.Require().Equal(11, benchResult.Sent)
+	s.Require().GreaterOrEqual(time.Since(startedAt), 100*time.Millisecond)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"feeder-service/internal/sku/infrastructure/io/socket/tcp/server"
+	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+// inProcessServer is a socket server with an in-memory repository listening on a random local port, so the
+// bench measures the server without mongodb.
+type inProcessServer struct {
+	addr   string
+	cancel context.CancelFunc
+	report chan reporting.Report
+}
+
+// maxInProcessRun is the deadline of the reads of the in-process server, it's stopped long before.
+const maxInProcessRun = 24 * time.Hour
+
+func startInProcessServer(ctx context.Context, slots int) (*inProcessServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	skuReader, err := sku_reader.New(listener)
+	if err != nil {
+		return nil, err
+	}
+
+	commandBus := bus.New()
+	err = commandBus.Register(create_sku.CommandName, create_sku.BusHandler(create_sku.NewCommandHandler(memory.NewSkuRepository(domain.NewHydrator()))))
+	if err != nil {
+		return nil, err
+	}
+	serverTCP := server.New(skuReader, commandBus, log.New(io.Discard, "", 0))
+
+	ctx, cancel := context.WithCancel(ctx)
+	inProcess := &inProcessServer{addr: listener.Addr().String(), cancel: cancel, report: make(chan reporting.Report, 1)}
+	go func() {
+		inProcess.report <- serverTCP.Run(ctx, slots, time.Now().Add(maxInProcessRun))
+	}()
+
+	return inProcess, nil
+}
+
+// stop shuts the server down and returns its run report.
+func (s *inProcessServer) stop() reporting.Report {
+	s.cancel()
+
+	return <-s.report
+}
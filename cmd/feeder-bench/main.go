@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"feeder-service/pkg/client"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const usage = `usage:
+  feeder-bench [flags]   send a mix of generated messages to the socket server and print the results as json
+  (the messages are sent to an in-process server with an in-memory repository when there is no -addr)`
+
+var errUsage = errors.New(usage)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("feeder-bench", flag.ContinueOnError)
+	addr := flags.String("addr", "", "address of a remote socket server, empty for an in-process one")
+	slots := flags.Int("slots", 5, "max concurrent connections of the in-process server")
+	connections := flags.Int("connections", 20, "number of sessions sending messages at once")
+	rate := flags.Float64("rate", 0, "target messages per second, 0 sends them as fast as possible")
+	messages := flags.Int("messages", 0, "number of messages to send, 0 sends them until the duration ends")
+	duration := flags.Duration("duration", 10*time.Second, "maximum duration of the bench")
+	mixValue := flags.String("mix", "valid=80,duplicate=15,invalid=5", "weight of each kind of message")
+	timeout := flags.Duration("timeout", client.DefaultTimeout, "time limit to send a message and get its ack")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the generated messages")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *connections <= 0 || *slots <= 0 || *rate < 0 || (*messages <= 0 && *duration <= 0) {
+		return errUsage
+	}
+	messagesMix, err := parseMix(*mixValue)
+	if err != nil {
+		return err
+	}
+
+	var inProcess *inProcessServer
+	target := *addr
+	if target == "" {
+		inProcess, err = startInProcessServer(ctx, *slots)
+		if err != nil {
+			return err
+		}
+		target = inProcess.addr
+	}
+
+	// the retries would hide the failures and add their backoff to the latencies
+	feederClient := client.New(target,
+		client.WithConnections(*connections),
+		client.WithRetries(0, 0),
+		client.WithTimeout(*timeout),
+	)
+	benchResult := bench(ctx, feederClient, benchConfig{
+		mix:         messagesMix,
+		rate:        *rate,
+		messages:    *messages,
+		duration:    *duration,
+		connections: *connections,
+		seed:        *seed,
+	})
+	err = feederClient.Close()
+	if err != nil {
+		return err
+	}
+
+	benchResult.Target = *addr
+	if inProcess != nil {
+		benchResult.Target = "in-process"
+		benchResult.Slots = *slots
+		benchResult.ServerReport = inProcess.stop().Counters()
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(benchResult)
+}
@@ -0,0 +1,42 @@
+//+build integration
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+type BenchIntegrationSuite struct {
+	suite.Suite
+}
+
+func TestBenchIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(BenchIntegrationSuite))
+}
+
+func (s *BenchIntegrationSuite) TestBenchTheInProcessServer() {
+	output := &strings.Builder{}
+
+	err := run(context.Background(), []string{"-messages", "300", "-connections", "4", "-slots", "2", "-mix", "valid=2,duplicate=1,invalid=1"}, output)
+	s.Require().NoError(err)
+
+	var benchResult result
+	s.Require().NoError(json.Unmarshal([]byte(output.String()), &benchResult))
+	s.Require().Equal("in-process", benchResult.Target)
+	s.Require().Equal(300, benchResult.Sent)
+	s.Require().Equal(300, benchResult.Acked)
+	s.Require().Equal(benchResult.Outcomes["created"], benchResult.ServerReport["created_skus"])
+	s.Require().Equal(benchResult.Outcomes["duplicated"], benchResult.ServerReport["duplicated_skus"])
+	s.Require().Equal(benchResult.Kinds[kindInvalid], benchResult.ServerReport["invalid_skus_invalid_format"])
+	s.Require().Equal(benchResult.Kinds[kindValid], benchResult.ServerReport["created_skus"])
+	s.Require().Greater(benchResult.LatencyMs.P99, 0.0)
+}
+
+func (s *BenchIntegrationSuite) TestReturnErrUsageWithoutMessagesNorDuration() {
+	err := run(context.Background(), []string{"-messages", "0", "-duration", "0"}, &strings.Builder{})
+	s.Require().ErrorIs(err, errUsage)
+}
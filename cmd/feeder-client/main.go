@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"feeder-service/pkg/client"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+)
+
+const usage = `usage:
+  feeder-client [flags] [file ...]   send every line of the files (stdin when there is none or it's -) as a message`
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+var (
+	errUsage          = errors.New(usage)
+	errFailedMessages = errors.New("some messages couldn't be sent")
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, output io.Writer) error {
+	flags := flag.NewFlagSet("feeder-client", flag.ContinueOnError)
+	addr := flags.String("addr", fetchEnvVar("SOCKET_ADDR", "localhost:4000"), "address of the socket server")
+	connections := flags.Int("connections", client.DefaultConnections, "number of sessions sending messages at once")
+	retries := flags.Int("retries", client.DefaultRetries, "times a failed message is sent again")
+	retryBackoff := flags.Duration("retry-backoff", client.DefaultRetryBackoff, "wait before the first retry, it grows with every retry")
+	timeout := flags.Duration("timeout", client.DefaultTimeout, "time limit to send a message and get its ack")
+	format := flags.String("format", formatText, "format of the summary: text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *format != formatText && *format != formatJSON {
+		return errUsage
+	}
+
+	input, closeInput, err := openInputs(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	feederClient := client.New(*addr,
+		client.WithConnections(*connections),
+		client.WithRetries(*retries, *retryBackoff),
+		client.WithTimeout(*timeout),
+	)
+	defer feederClient.Close()
+	summary, err := feederClient.Feed(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	err = printSummary(output, summary, *format)
+	if err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%w: %d failed", errFailedMessages, summary.Failed)
+	}
+
+	return nil
+}
+
+// openInputs returns the files concatenated one after another, or stdin when there is no file.
+func openInputs(names []string, stdin io.Reader) (io.Reader, func(), error) {
+	if len(names) == 0 {
+		return stdin, func() {}, nil
+	}
+
+	var readers []io.Reader
+	var files []*os.File
+	closeFiles := func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}
+	for _, name := range names {
+		var reader io.Reader = stdin
+		if name != "-" {
+			file, err := os.Open(name)
+			if err != nil {
+				closeFiles()
+				return nil, nil, err
+			}
+			files = append(files, file)
+			reader = file
+		}
+		// an input without a final line break would join its last line with the first one of the next input
+		readers = append(readers, reader, newLine{})
+	}
+
+	return io.MultiReader(readers...), closeFiles, nil
+}
+
+// newLine is a reader of a single line break.
+type newLine struct{}
+
+func (newLine) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = '\n'
+
+	return 1, io.EOF
+}
+
+func printSummary(output io.Writer, summary client.Summary, format string) error {
+	if format == formatJSON {
+		return json.NewEncoder(output).Encode(summary)
+	}
+
+	fmt.Fprintf(output, "Sent %d messages, %d failed, %d retries\n", summary.Sent, summary.Failed, summary.Retries)
+	fmt.Fprintf(output, "Received %d unique product skus, %d duplicates, %d discard values\n",
+		summary.CreatedSkus, summary.DuplicatedSkus, summary.InvalidSkus)
+	fmt.Fprintf(output, "Updated %d skus, %d unchanged, %d not found\n",
+		summary.UpdatedSkus, summary.UnchangedSkus, summary.NotFoundSkus)
+	fmt.Fprintf(output, "Deactivated %d skus, %d reactivated, %d deleted, %d rejected by their tombstone\n",
+		summary.DeactivatedSkus, summary.ReactivatedSkus, summary.DeletedSkus, summary.TombstonedSkus)
+	for _, reason := range sortedKeys(summary.InvalidReasons) {
+		fmt.Fprintf(output, "Discarded %d values by %s\n", summary.InvalidReasons[reason], reason)
+	}
+	for _, message := range sortedKeys(summary.Errors) {
+		fmt.Fprintf(output, "Failed %d messages by %s\n", summary.Errors[message], message)
+	}
+	if summary.Terminated {
+		fmt.Fprintln(output, "Terminated the server")
+	}
+	duration := time.Duration(summary.DurationSeconds * float64(time.Second))
+	var throughput float64
+	if summary.DurationSeconds > 0 {
+		throughput = float64(summary.Sent) / summary.DurationSeconds
+	}
+	fmt.Fprintf(output, "Ran for %s at %.2f messages per second\n", duration.Round(time.Millisecond), throughput)
+
+	return nil
+}
+
+func sortedKeys(values map[string]int) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func fetchEnvVar(name, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
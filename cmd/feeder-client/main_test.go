@@ -0,0 +1,77 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"feeder-service/pkg/client"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type FeederClientUnitSuite struct {
+	suite.Suite
+	output *strings.Builder
+}
+
+func (s *FeederClientUnitSuite) SetupTest() {
+	s.output = &strings.Builder{}
+}
+
+func TestFeederClientUnitSuite(t *testing.T) {
+	suite.Run(t, new(FeederClientUnitSuite))
+}
+
+func (s *FeederClientUnitSuite) TestTheFilesAreReadOneAfterAnotherAndStdinIsReadWithoutFiles() {
+	dir := s.T().TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	s.Require().NoError(os.WriteFile(first, []byte("KASL-3423\nKASL-3424"), 0600))
+	s.Require().NoError(os.WriteFile(second, []byte("KASL-3425\n"), 0600))
+
+	input, closeInput, err := openInputs([]string{first, "-", second}, strings.NewReader("KASL-3426"))
+	s.Require().NoError(err)
+	defer closeInput()
+	content, err := io.ReadAll(input)
+	s.Require().NoError(err)
+	s.Require().Equal("KASL-3423\nKASL-3424\nKASL-3426\nKASL-3425\n\n", string(content))
+
+	input, _, err = openInputs(nil, strings.NewReader("KASL-3426"))
+	s.Require().NoError(err)
+	content, err = io.ReadAll(input)
+	s.Require().NoError(err)
+	s.Require().Equal("KASL-3426", string(content))
+}
+
+func (s *FeederClientUnitSuite) TestReturnAnErrorWhenAFileDoesNotExist() {
+	_, _, err := openInputs([]string{filepath.Join(s.T().TempDir(), "missing.txt")}, nil)
+	s.Require().ErrorIs(err, os.ErrNotExist)
+}
+
+func (s *FeederClientUnitSuite) TestTheSummaryIsPrintedLikeTheServerReport() {
+	summary := client.Summary{
+		Sent:           5,
+		CreatedSkus:    2,
+		DuplicatedSkus: 1,
+		InvalidSkus:    1,
+		InvalidReasons: map[string]int{"invalid_format": 1},
+		Failed:         1,
+		Errors:         map[string]int{"connection refused": 1},
+		Retries:        3,
+	}
+
+	s.Require().NoError(printSummary(s.output, summary, formatText))
+	s.Require().Contains(s.output.String(), "Sent 5 messages, 1 failed, 3 retries\n")
+	s.Require().Contains(s.output.String(), "Received 2 unique product skus, 1 duplicates, 1 discard values\n")
+	s.Require().Contains(s.output.String(), "Discarded 1 values by invalid_format\n")
+	s.Require().Contains(s.output.String(), "Failed 1 messages by connection refused\n")
+}
+
+func (s *FeederClientUnitSuite) TestReturnErrUsageWithAnUnknownFormat() {
+	err := run(context.Background(), []string{"-format", "xml"}, strings.NewReader(""), s.output)
+	s.Require().ErrorIs(err, errUsage)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	mongoSku "feeder-service/internal/sku/infrastructure/persistence/mongo"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage:
+  migrate up       apply the pending migrations of the sku collections
+  migrate status   list the applied and the pending migrations`
+
+var errUsage = errors.New(usage)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mongoClient, err := connectMongo(ctx)
+	if err != nil {
+		log.Fatalf("error connecting mongodb: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	runner, err := newMigrationRunner(mongoClient.Database(fetchEnvVar("MONGO_DATABASE", "sku")))
+	if err != nil {
+		log.Fatalf("error creating migration runner: %v", err)
+	}
+
+	err = run(ctx, runner, os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newMigrationRunner(db *mongo.Database) (*migration.Runner, error) {
+	store, err := mongoSku.NewMigrationStore(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := mongoSku.Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return migration.NewRunner(store, migrations)
+}
+
+func run(ctx context.Context, runner *migration.Runner, args []string, output io.Writer) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "up":
+		return up(ctx, runner, output)
+	case "status":
+		return status(ctx, runner, output)
+	default:
+		return errUsage
+	}
+}
+
+// up prints every migration once it's applied, the ones applied before the failed one stay applied.
+func up(ctx context.Context, runner *migration.Runner, output io.Writer) error {
+	records, err := runner.Run(ctx)
+	for _, record := range records {
+		fmt.Fprintf(output, "applied %d %s\n", record.Version, record.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(output, "no pending migrations")
+	}
+
+	return nil
+}
+
+// status prints the migrations ordered by version with the time they were applied, or pending.
+func status(ctx context.Context, runner *migration.Runner, output io.Writer) error {
+	records, err := runner.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	pending, err := runner.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, pendingMigration := range pending {
+		records = append(records, migration.Record{Version: pendingMigration.Version, Description: pendingMigration.Description})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "version\tdescription\tapplied at")
+	for _, record := range records {
+		appliedAt := "pending"
+		if !record.AppliedAt.IsZero() {
+			appliedAt = record.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\n", record.Version, record.Description, appliedAt)
+	}
+
+	return writer.Flush()
+}
+
+func connectMongo(ctx context.Context) (*mongo.Client, error) {
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI(fetchEnvVar("MONGO_URI", "mongodb://localhost:27017")))
+	if err != nil {
+		return nil, err
+	}
+
+	return mongoClient, mongoClient.Connect(ctx)
+}
+
+func fetchEnvVar(name, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,52 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+type MigrateUnitSuite struct {
+	suite.Suite
+	ctx    context.Context
+	runner *migration.Runner
+}
+
+func (s *MigrateUnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	var err error
+	s.runner, err = migration.NewRunner(memory.NewMigrationStore(), []migration.Migration{
+		{Version: 1, Description: "first index", Up: func(context.Context) error { return nil }},
+		{Version: 2, Description: "broken backfill", Up: func(context.Context) error { return errors.New("timeout") }},
+	})
+	s.Require().NoError(err)
+}
+
+func TestMigrateUnitSuite(t *testing.T) {
+	suite.Run(t, new(MigrateUnitSuite))
+}
+
+func (s *MigrateUnitSuite) TestUpPrintsTheAppliedMigrationsAndStatusTheRestAsPending() {
+	output := &strings.Builder{}
+	err := run(s.ctx, s.runner, []string{"up"}, output)
+	s.Require().ErrorIs(err, migration.ErrMigrationFailed)
+	s.Require().Equal("applied 1 first index\n", output.String())
+
+	output.Reset()
+	s.Require().NoError(run(s.ctx, s.runner, []string{"status"}, output))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	s.Require().Len(lines, 3)
+	s.Require().NotContains(lines[1], "pending")
+	s.Require().Equal("2        broken backfill  pending", lines[2])
+}
+
+func (s *MigrateUnitSuite) TestReturnErrUsageWithAnUnknownSubcommand() {
+	s.Require().ErrorIs(run(s.ctx, s.runner, []string{"down"}, &strings.Builder{}), errUsage)
+	s.Require().ErrorIs(run(s.ctx, s.runner, nil, &strings.Builder{}), errUsage)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/run/application/query/compare_runs"
+	"feeder-service/internal/run/application/query/list_runs"
+	"feeder-service/internal/run/domain"
+	mongoRun "feeder-service/internal/run/infrastructure/persistence/mongo"
+	"flag"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage:
+  run-history list [-limit N]       list the latest runs
+  run-history compare <id> <id>     compare the counters of two runs`
+
+const timeout = 10 * time.Second
+
+var errUsage = errors.New(usage)
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	mongoClient, err := connectMongo(ctx)
+	if err != nil {
+		log.Fatalf("error connecting mongodb: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	runRepository, err := mongoRun.NewRunRepository(mongoClient.Database(fetchEnvVar("MONGO_DATABASE", "sku")), domain.NewHydrator())
+	if err != nil {
+		log.Fatalf("error creating run repository: %v", err)
+	}
+
+	err = run(ctx, runRepository, os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, runRepository domain.RunRepository, args []string, output io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("list", flag.ContinueOnError)
+		limit := flags.Int("limit", list_runs.DefaultLimit, "max number of runs to list")
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+		runs, err := list_runs.NewQueryHandler(runRepository).Handle(ctx, list_runs.Query{Limit: *limit})
+		if err != nil {
+			return err
+		}
+		printRuns(output, runs)
+	case "compare":
+		if len(args) != 3 {
+			return errUsage
+		}
+		comparison, err := compare_runs.NewQueryHandler(runRepository).Handle(ctx, compare_runs.Query{BaseRunId: args[1], OtherRunId: args[2]})
+		if err != nil {
+			return err
+		}
+		printComparison(output, comparison)
+	default:
+		return errUsage
+	}
+
+	return nil
+}
+
+func printRuns(output io.Writer, runs []*domain.Run) {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tSTARTED AT\tDURATION\tHOST\tCONFIG\tSNAPSHOT OF\tCREATED\tDUPLICATED\tINVALID")
+	for _, run := range runs {
+		counters := run.Counters()
+		snapshotOf := "-"
+		if run.Snapshot() {
+			snapshotOf = string(run.SnapshotOf())
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%.8s\t%s\t%d\t%d\t%d\n",
+			run.Id(), run.StartedAt().Format(time.RFC3339), run.Duration().Round(time.Second), run.Host(),
+			run.ConfigHash(), snapshotOf, counters["created_skus"], counters["duplicated_skus"], counters["invalid_skus"])
+	}
+	writer.Flush()
+}
+
+func printComparison(output io.Writer, comparison domain.Comparison) {
+	if !comparison.SameConfig {
+		fmt.Fprintln(output, "The runs were executed with a different config")
+	}
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "COUNTER\t%s\t%s\tDELTA\n", comparison.Base.Id(), comparison.Other.Id())
+	for _, diff := range comparison.CounterDiffs {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%+d\n", diff.Counter, diff.Base, diff.Other, diff.Delta())
+	}
+	writer.Flush()
+}
+
+func connectMongo(ctx context.Context) (*mongo.Client, error) {
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI(fetchEnvVar("MONGO_URI", "mongodb://localhost:27017")))
+	if err != nil {
+		return nil, err
+	}
+
+	return mongoClient, mongoClient.Connect(ctx)
+}
+
+func fetchEnvVar(name, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,64 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"feeder-service/internal/run/domain"
+	"feeder-service/internal/run/domain/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+	"time"
+)
+
+type RunHistoryUnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	mockCtrl       *gomock.Controller
+	repositoryMock *mock.MockRunRepository
+	output         *strings.Builder
+}
+
+func (s *RunHistoryUnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockRunRepository(s.mockCtrl)
+	s.output = &strings.Builder{}
+}
+
+func (s *RunHistoryUnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestRunHistoryUnitSuite(t *testing.T) {
+	suite.Run(t, new(RunHistoryUnitSuite))
+}
+
+func (s *RunHistoryUnitSuite) TestListTheLatestRuns() {
+	s.repositoryMock.EXPECT().List(s.ctx, 2).Return([]*domain.Run{s.run("first", map[string]int{"created_skus": 7})}, nil)
+
+	s.Require().NoError(run(s.ctx, s.repositoryMock, []string{"list", "-limit", "2"}, s.output))
+	s.Require().Contains(s.output.String(), "first")
+	s.Require().Contains(s.output.String(), "feeder-1")
+}
+
+func (s *RunHistoryUnitSuite) TestCompareTwoRuns() {
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId("first")).Return(s.run("first", map[string]int{"invalid_skus": 2}), nil)
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId("second")).Return(s.run("second", map[string]int{"invalid_skus": 5}), nil)
+
+	s.Require().NoError(run(s.ctx, s.repositoryMock, []string{"compare", "first", "second"}, s.output))
+	s.Require().Regexp(`invalid_skus\s+2\s+5\s+\+3`, s.output.String())
+}
+
+func (s *RunHistoryUnitSuite) TestUnknownCommandsReturnTheUsage() {
+	s.Require().ErrorIs(run(s.ctx, s.repositoryMock, []string{"delete"}, s.output), errUsage)
+	s.Require().ErrorIs(run(s.ctx, s.repositoryMock, []string{"compare", "first"}, s.output), errUsage)
+	s.Require().ErrorIs(run(s.ctx, s.repositoryMock, nil, s.output), errUsage)
+}
+
+func (s *RunHistoryUnitSuite) run(id string, counters map[string]int) *domain.Run {
+	startedAt := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	return domain.NewRun(domain.RunId(id), startedAt, startedAt.Add(time.Minute), "feeder-1", "hash", "", counters)
+}
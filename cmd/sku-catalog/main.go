@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/query/export_skus"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/sku_export"
+	mongoSku "feeder-service/internal/sku/infrastructure/persistence/mongo"
+	"flag"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `usage:
+  sku-catalog export [-format newline|csv|jsonl] [-tenant T] [-prefix P,...] [-status S,...] [-output FILE]
+                                         export the skus, the deleted ones only with -status
+  sku-catalog snapshot [-tenant T] [-prefix P,...] [-output FILE]
+                                         write the complete state of the skus, deleted ones included
+  sku-catalog restore [-input FILE]      store the skus of a snapshot as they were, replacing the stored ones`
+
+var errUsage = errors.New(usage)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mongoClient, err := connectMongo(ctx)
+	if err != nil {
+		log.Fatalf("error connecting mongodb: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	skuRepository, err := mongoSku.NewSkuRepository(mongoClient.Database(fetchEnvVar("MONGO_DATABASE", "sku")), domain.NewHydrator())
+	if err != nil {
+		log.Fatalf("error creating sku repository: %v", err)
+	}
+
+	err = run(ctx, skuRepository, os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, skuRepository domain.SkuRepository, args []string, input io.Reader, output io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	tenant := flags.String("tenant", "", "tenant of the skus, every tenant when it's empty")
+	prefixes := flags.String("prefix", "", "comma separated prefixes of the sku values, every sku when it's empty")
+	outputFileName := flags.String("output", "", "file written, stdout when it's empty")
+	switch args[0] {
+	case "export":
+		format := flags.String("format", sku_export.FormatNewline, "newline, csv or jsonl")
+		statuses := flags.String("status", "", "comma separated statuses of the skus, the ones not deleted when it's empty")
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+		// the format is checked before the output file is created
+		_, err = sku_export.NewWriter(*format, io.Discard)
+		if err != nil {
+			return err
+		}
+		query := export_skus.Query{Tenant: *tenant, Prefixes: splitList(*prefixes), Statuses: splitList(*statuses)}
+
+		return writeOutput(*outputFileName, output, func(output io.Writer) error {
+			writer, err := sku_export.NewWriter(*format, output)
+			if err != nil {
+				return err
+			}
+			err = export_skus.NewQueryHandler(skuRepository).Handle(ctx, query, writer.Write)
+			if err != nil {
+				return err
+			}
+			return writer.Flush()
+		})
+	case "snapshot":
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+		filter := domain.SkuFilter{Prefixes: splitList(*prefixes)}
+		if *tenant != "" {
+			filter.Tenant, err = domain.NewTenant(*tenant)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writeOutput(*outputFileName, output, func(output io.Writer) error {
+			_, err := sku_export.WriteSnapshot(ctx, skuRepository, filter, output, time.Now())
+			return err
+		})
+	case "restore":
+		inputFileName := flags.String("input", "", "snapshot file, stdin when it's empty")
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+		if *inputFileName != "" {
+			file, err := os.Open(*inputFileName)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+		count, err := sku_export.RestoreSnapshot(ctx, input, skuRepository)
+		fmt.Fprintf(output, "%d skus restored\n", count)
+
+		return err
+	default:
+		return errUsage
+	}
+}
+
+// writeOutput calls write with the output file, or with the output when there's no file name. The file is written
+// with a temporary name and renamed once it's complete, so a failed export doesn't leave a partial file behind.
+func writeOutput(fileName string, output io.Writer, write func(io.Writer) error) error {
+	if fileName == "" {
+		return write(output)
+	}
+
+	temporaryFileName := fileName + ".tmp"
+	file, err := os.Create(temporaryFileName)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(temporaryFileName)
+		return err
+	}
+
+	return os.Rename(temporaryFileName, fileName)
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
+
+func connectMongo(ctx context.Context) (*mongo.Client, error) {
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI(fetchEnvVar("MONGO_URI", "mongodb://localhost:27017")))
+	if err != nil {
+		return nil, err
+	}
+
+	return mongoClient, mongoClient.Connect(ctx)
+}
+
+func fetchEnvVar(name, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,79 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SkuCatalogUnitSuite struct {
+	suite.Suite
+	ctx        context.Context
+	repository *memory.SkuRepository
+	output     *strings.Builder
+}
+
+func (s *SkuCatalogUnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repository = memory.NewSkuRepository(domain.NewHydrator())
+	s.output = &strings.Builder{}
+	for _, value := range []string{"ABCD-0001", "ABCD-0002", "EFGH-0001"} {
+		skuId, err := domain.NewSkuId(value)
+		s.Require().NoError(err)
+		s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	}
+}
+
+func TestSkuCatalogUnitSuite(t *testing.T) {
+	suite.Run(t, new(SkuCatalogUnitSuite))
+}
+
+func (s *SkuCatalogUnitSuite) TestExportTheSkusWithThePrefixes() {
+	err := run(s.ctx, s.repository, []string{"export", "-prefix", "ABCD"}, nil, s.output)
+	s.Require().NoError(err)
+	s.Require().Equal("ABCD-0001\nABCD-0002\n", s.output.String())
+}
+
+func (s *SkuCatalogUnitSuite) TestExportToAFile() {
+	fileName := filepath.Join(s.T().TempDir(), "skus.jsonl")
+
+	err := run(s.ctx, s.repository, []string{"export", "-format", "jsonl", "-output", fileName}, nil, s.output)
+	s.Require().NoError(err)
+	content, err := os.ReadFile(fileName)
+	s.Require().NoError(err)
+	s.Require().Len(strings.Split(strings.TrimSpace(string(content)), "\n"), 3)
+	s.Require().NoFileExists(fileName + ".tmp")
+
+	err = run(s.ctx, s.repository, []string{"export", "-format", "xml", "-output", fileName + ".xml"}, nil, s.output)
+	s.Require().Error(err)
+	s.Require().NoFileExists(fileName + ".xml")
+}
+
+func (s *SkuCatalogUnitSuite) TestRestoreASnapshotInAnotherRepository() {
+	err := run(s.ctx, s.repository, []string{"snapshot", "-prefix", "ABCD"}, nil, s.output)
+	s.Require().NoError(err)
+
+	target := memory.NewSkuRepository(domain.NewHydrator())
+	restoreOutput := &strings.Builder{}
+	err = run(s.ctx, target, []string{"restore"}, strings.NewReader(s.output.String()), restoreOutput)
+	s.Require().NoError(err)
+	s.Require().Equal("2 skus restored\n", restoreOutput.String())
+
+	exportOutput := &strings.Builder{}
+	err = run(s.ctx, target, []string{"export"}, nil, exportOutput)
+	s.Require().NoError(err)
+	s.Require().Equal("ABCD-0001\nABCD-0002\n", exportOutput.String())
+}
+
+func (s *SkuCatalogUnitSuite) TestReturnErrUsageWithAnUnknownCommand() {
+	s.Require().ErrorIs(run(s.ctx, s.repository, nil, nil, s.output), errUsage)
+	s.Require().ErrorIs(run(s.ctx, s.repository, []string{"import"}, nil, s.output), errUsage)
+}
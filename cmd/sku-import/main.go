@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"feeder-service/internal/sku/infrastructure/io/sku_import"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	mongoSku "feeder-service/internal/sku/infrastructure/persistence/mongo"
+	"flag"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `usage:
+  sku-import [flags] <file>   create the skus of a plain or csv file, optionally gzipped, and print the report
+  (an interrupted import resumes from its checkpoint when it's run again with the same file)`
+
+var errUsage = errors.New(usage)
+
+// importConfig holds the flags of the command.
+type importConfig struct {
+	fileName       string
+	format         string
+	column         string
+	tenant         domain.Tenant
+	skuIdFormats   *domain.SkuIdFormats
+	workers        int
+	batchSize      int
+	checkpoint     string
+	restart        bool
+	reportFormat   string
+	commandTimeout time.Duration
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	mongoClient, err := connectMongo(ctx)
+	if err != nil {
+		log.Fatalf("error connecting mongodb: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	commandBus, err := newCommandBus(ctx, cfg, mongoClient.Database(fetchEnvVar("MONGO_DATABASE", "sku")))
+	if err != nil {
+		log.Fatalf("error creating the command bus: %v", err)
+	}
+
+	err = run(ctx, commandBus, cfg, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+var errInvalidTenantFormat = errors.New("invalid tenant format, it's tenant=pattern")
+
+// tenantFormats holds the sku format of each tenant given with the repeated -tenant-format flag, like the
+// sku_format of the tenants of the server config.
+type tenantFormats map[string]string
+
+func (f tenantFormats) String() string {
+	values := make([]string, 0, len(f))
+	for tenant, pattern := range f {
+		values = append(values, tenant+"="+pattern)
+	}
+	sort.Strings(values)
+
+	return strings.Join(values, ",")
+}
+
+func (f tenantFormats) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%w: %s", errInvalidTenantFormat, value)
+	}
+	f[parts[0]] = parts[1]
+
+	return nil
+}
+
+// skuIdFormats returns the sku format of each tenant, the ones without their own format use the default one.
+func (f tenantFormats) skuIdFormats(skuIdPattern string) (*domain.SkuIdFormats, error) {
+	defaultFormat, err := domain.NewSkuIdFormat(skuIdPattern)
+	if err != nil {
+		return nil, err
+	}
+	skuIdFormats := map[domain.Tenant]*domain.SkuIdFormat{}
+	for tenantValue, pattern := range f {
+		skuTenant, err := domain.NewTenant(tenantValue)
+		if err != nil {
+			return nil, err
+		}
+		skuIdFormats[skuTenant], err = domain.NewSkuIdFormat(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tenantValue, err)
+		}
+	}
+
+	return domain.NewSkuIdFormats(defaultFormat, skuIdFormats), nil
+}
+
+func parseArgs(args []string) (importConfig, error) {
+	cfg := importConfig{}
+	formats := tenantFormats{}
+	flags := flag.NewFlagSet("sku-import", flag.ContinueOnError)
+	flags.StringVar(&cfg.format, "format", "", "plain or csv, by default csv for the .csv files and plain for the rest")
+	flags.StringVar(&cfg.column, "column", sku_import.DefaultColumn, "header of the sku column of a csv file, or its position starting from 1")
+	tenant := flags.String("tenant", "", "tenant of the skus, the default one when it's empty")
+	skuIdPattern := flags.String("sku-format", domain.DefaultSkuIdPattern, "regular expression of the valid skus of the tenants without their own format")
+	flags.Var(formats, "tenant-format", "tenant=pattern, the regular expression of the valid skus of the tenant, it can be repeated")
+	flags.IntVar(&cfg.workers, "workers", sku_import.DefaultWorkers, "max number of skus created at once")
+	flags.IntVar(&cfg.batchSize, "checkpoint-every", sku_import.DefaultBatchSize, "number of skus imported between two checkpoints")
+	flags.StringVar(&cfg.checkpoint, "checkpoint", "", "checkpoint file name, by default the file name with the .checkpoint extension")
+	flags.BoolVar(&cfg.restart, "restart", false, "ignore the checkpoint and import the file from its beginning")
+	flags.StringVar(&cfg.reportFormat, "report-format", "text", "text, json or csv")
+	flags.DurationVar(&cfg.commandTimeout, "command-timeout", 10*time.Second, "time limit to create an sku, zero is no limit")
+	err := flags.Parse(args)
+	if err != nil {
+		return cfg, err
+	}
+	if flags.NArg() != 1 || cfg.workers <= 0 || cfg.batchSize <= 0 {
+		return cfg, errUsage
+	}
+
+	cfg.fileName = flags.Arg(0)
+	if cfg.format == "" {
+		cfg.format = sku_import.FormatOf(cfg.fileName)
+	}
+	if cfg.checkpoint == "" {
+		cfg.checkpoint = cfg.fileName + ".checkpoint"
+	}
+	cfg.tenant, err = domain.NewTenant(*tenant)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.skuIdFormats, err = formats.skuIdFormats(*skuIdPattern)
+	if err != nil {
+		return cfg, err
+	}
+	_, err = reporting.NewFormatter(cfg.reportFormat)
+
+	return cfg, err
+}
+
+// newCommandBus applies the pending migrations of the collections before the import, like the server on startup.
+func newCommandBus(ctx context.Context, cfg importConfig, db *mongo.Database) (*bus.Bus, error) {
+	err := migrate(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	skuRepository, err := mongoSku.NewSkuRepository(db, domain.NewHydrator())
+	if err != nil {
+		return nil, err
+	}
+
+	return newBus(cfg, skuRepository)
+}
+
+func migrate(ctx context.Context, db *mongo.Database) error {
+	migrationStore, err := mongoSku.NewMigrationStore(db)
+	if err != nil {
+		return err
+	}
+	migrations, err := mongoSku.Migrations(db)
+	if err != nil {
+		return err
+	}
+	runner, err := migration.NewRunner(migrationStore, migrations)
+	if err != nil {
+		return err
+	}
+	_, err = runner.Run(ctx)
+
+	return err
+}
+
+// newBus returns a bus with the create_sku handler, the only command of the imports.
+func newBus(cfg importConfig, skuRepository domain.SkuRepository) (*bus.Bus, error) {
+	createSkuCommandHandler := create_sku.NewCommandHandler(skuRepository)
+	createSkuCommandHandler.SetSkuIdFormats(cfg.skuIdFormats)
+	commandBus := bus.New(bus.Timeout(cfg.commandTimeout))
+
+	return commandBus, commandBus.Register(create_sku.CommandName, create_sku.BusHandler(createSkuCommandHandler))
+}
+
+// run imports the file from its checkpoint and prints the report of the whole import, including the skus imported
+// before an interruption. The checkpoint and its journal are removed once the file is imported.
+func run(ctx context.Context, commandBus bus.Dispatcher, cfg importConfig, output io.Writer) error {
+	checkpoint, err := sku_import.NewCheckpoint(cfg.fileName, time.Now())
+	if err != nil {
+		return err
+	}
+	journalFileName := cfg.checkpoint + ".journal"
+	if cfg.restart {
+		err = os.Remove(journalFileName)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	} else {
+		saved, err := sku_import.LoadCheckpoint(cfg.checkpoint)
+		if err != nil {
+			return err
+		}
+		if saved != nil {
+			err = checkpoint.Resumes(saved)
+			if err != nil {
+				return fmt.Errorf("%w, run it with -restart to import it from its beginning", err)
+			}
+			checkpoint = saved
+		}
+	}
+
+	source, err := sku_import.Open(cfg.fileName, cfg.format, cfg.column)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	importer := sku_import.New(commandBus,
+		sku_import.WithTenant(cfg.tenant),
+		sku_import.WithWorkers(cfg.workers),
+		sku_import.WithBatchSize(cfg.batchSize),
+		sku_import.WithJournal(journalFileName),
+	)
+	importErr := importer.Import(ctx, source, checkpoint, func(c *sku_import.Checkpoint) error {
+		return c.Save(cfg.checkpoint)
+	})
+	if importErr == nil {
+		err = os.Remove(cfg.checkpoint)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	formatter, err := reporting.NewFormatter(cfg.reportFormat)
+	if err != nil {
+		return err
+	}
+	content, err := formatter.Format(reporting.NewSummary(checkpoint.Report, checkpoint.StartedAt, time.Now()))
+	if err != nil {
+		return err
+	}
+	_, err = output.Write(content)
+	if err != nil {
+		return err
+	}
+	if errors.Is(importErr, sku_import.ErrInterrupted) {
+		return fmt.Errorf("%w, run it again to resume it from %s", importErr, cfg.checkpoint)
+	}
+
+	return importErr
+}
+
+func connectMongo(ctx context.Context) (*mongo.Client, error) {
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI(fetchEnvVar("MONGO_URI", "mongodb://localhost:27017")))
+	if err != nil {
+		return nil, err
+	}
+
+	return mongoClient, mongoClient.Connect(ctx)
+}
+
+func fetchEnvVar(name, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,124 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/sku_import"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type ImportUnitSuite struct {
+	suite.Suite
+	dir string
+}
+
+func TestImportUnitSuite(t *testing.T) {
+	suite.Run(t, new(ImportUnitSuite))
+}
+
+func (s *ImportUnitSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+}
+
+func (s *ImportUnitSuite) TestTheFlagsDefaultToTheFileOfTheArgument() {
+	cfg, err := parseArgs([]string{"-tenant", "acme", "skus.csv.gz"})
+	s.Require().NoError(err)
+	s.Require().Equal("skus.csv.gz", cfg.fileName)
+	s.Require().Equal(sku_import.FormatCSV, cfg.format)
+	s.Require().Equal("skus.csv.gz.checkpoint", cfg.checkpoint)
+	s.Require().Equal(domain.Tenant("acme"), cfg.tenant)
+
+	_, err = parseArgs(nil)
+	s.Require().ErrorIs(err, errUsage)
+	_, err = parseArgs([]string{"-workers", "0", "skus.txt"})
+	s.Require().ErrorIs(err, errUsage)
+	_, err = parseArgs([]string{"-tenant", "ACME", "skus.txt"})
+	s.Require().ErrorIs(err, domain.ErrInvalidTenant)
+}
+
+func (s *ImportUnitSuite) TestTheTenantsWithoutTheirOwnFormatUseTheSkuFormat() {
+	cfg, err := parseArgs([]string{"-sku-format", "^[A-Z]{4}$", "-tenant-format", "acme=^ACME-[0-9]{6}$", "skus.txt"})
+	s.Require().NoError(err)
+	_, err = cfg.skuIdFormats.NewSkuId("acme", "ACME-123456")
+	s.Require().NoError(err)
+	_, err = cfg.skuIdFormats.NewSkuId("acme", "ABCD")
+	s.Require().ErrorIs(err, domain.ErrInvalidSku)
+	_, err = cfg.skuIdFormats.NewSkuId("", "ABCD")
+	s.Require().NoError(err)
+
+	_, err = parseArgs([]string{"-tenant-format", "acme", "skus.txt"})
+	s.Require().Contains(err.Error(), errInvalidTenantFormat.Error())
+	_, err = parseArgs([]string{"-tenant-format", "Acme Inc=^.+$", "skus.txt"})
+	s.Require().ErrorIs(err, domain.ErrInvalidTenant)
+	_, err = parseArgs([]string{"-tenant-format", "acme=^[A-Z", "skus.txt"})
+	s.Require().ErrorIs(err, domain.ErrInvalidSkuIdFormat)
+}
+
+func (s *ImportUnitSuite) TestTheImportPrintsTheReportAndRemovesItsCheckpoint() {
+	fileName := filepath.Join(s.dir, "skus.csv")
+	s.Require().NoError(os.WriteFile(fileName, []byte("sku,name\nABCD-1234,shoe\nABCD-1234,shoe\nabc,hat\n"), 0644))
+	cfg, err := parseArgs([]string{"-report-format", "json", fileName})
+	s.Require().NoError(err)
+	commandBus, err := newBus(cfg, memory.NewSkuRepository(domain.NewHydrator()))
+	s.Require().NoError(err)
+	output := &strings.Builder{}
+
+	err = run(context.Background(), commandBus, cfg, output)
+	s.Require().NoError(err)
+	var summary map[string]interface{}
+	s.Require().NoError(json.Unmarshal([]byte(output.String()), &summary))
+	s.Require().Equal(1.0, summary["created_skus"])
+	s.Require().Equal(1.0, summary["duplicated_skus"])
+	s.Require().Equal(1.0, summary["invalid_skus"])
+	s.Require().NoFileExists(cfg.checkpoint)
+}
+
+func (s *ImportUnitSuite) TestAnInterruptedImportKeepsItsCheckpointUntilItIsResumed() {
+	fileName := filepath.Join(s.dir, "skus.txt")
+	s.Require().NoError(os.WriteFile(fileName, []byte("ABCD-1234\nEFGH-5678\nIJKL-9012\n"), 0644))
+	cfg, err := parseArgs([]string{"-checkpoint-every", "2", "-report-format", "json", fileName})
+	s.Require().NoError(err)
+	commandBus, err := newBus(cfg, memory.NewSkuRepository(domain.NewHydrator()))
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = run(ctx, commandBus, cfg, &strings.Builder{})
+	s.Require().ErrorIs(err, sku_import.ErrInterrupted)
+	s.Require().FileExists(cfg.checkpoint)
+
+	output := &strings.Builder{}
+	err = run(context.Background(), commandBus, cfg, output)
+	s.Require().NoError(err)
+	var summary map[string]interface{}
+	s.Require().NoError(json.Unmarshal([]byte(output.String()), &summary))
+	s.Require().Equal(3.0, summary["created_skus"])
+	s.Require().Equal(0.0, summary["duplicated_skus"])
+	s.Require().NoFileExists(cfg.checkpoint)
+	s.Require().NoFileExists(cfg.checkpoint + ".journal")
+}
+
+func (s *ImportUnitSuite) TestTheCheckpointOfAModifiedFileIsRejectedUnlessItRestarts() {
+	fileName := filepath.Join(s.dir, "skus.txt")
+	s.Require().NoError(os.WriteFile(fileName, []byte("ABCD-1234\n"), 0644))
+	cfg, err := parseArgs([]string{fileName})
+	s.Require().NoError(err)
+	s.Require().NoError(os.WriteFile(cfg.checkpoint, []byte(`{"path": "/another/skus.txt", "position": 1}`), 0644))
+	commandBus, err := newBus(cfg, memory.NewSkuRepository(domain.NewHydrator()))
+	s.Require().NoError(err)
+
+	err = run(context.Background(), commandBus, cfg, &strings.Builder{})
+	s.Require().ErrorIs(err, sku_import.ErrCheckpointMismatch)
+
+	cfg.restart = true
+	err = run(context.Background(), commandBus, cfg, &strings.Builder{})
+	s.Require().NoError(err)
+}
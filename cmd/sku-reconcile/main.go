@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/sku_import"
+	"feeder-service/internal/sku/infrastructure/io/sku_reconcile"
+	mongoSku "feeder-service/internal/sku/infrastructure/persistence/mongo"
+	"flag"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+)
+
+const usage = `usage:
+  sku-reconcile [flags] <file>   compare the skus of a supplier file, plain or csv and optionally gzipped, with the
+                                 stored ones and write the missing, extra and invalid skus as csv`
+
+var errUsage = errors.New(usage)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mongoClient, err := connectMongo(ctx)
+	if err != nil {
+		log.Fatalf("error connecting mongodb: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	skuRepository, err := mongoSku.NewSkuRepository(mongoClient.Database(fetchEnvVar("MONGO_DATABASE", "sku")), domain.NewHydrator())
+	if err != nil {
+		log.Fatalf("error creating sku repository: %v", err)
+	}
+
+	err = run(ctx, skuRepository, os.Args[1:], os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run writes the differences to the output as they're found and the summary once they're all written.
+func run(ctx context.Context, skuRepository domain.SkuRepository, args []string, output, summaryOutput io.Writer) error {
+	flags := flag.NewFlagSet("sku-reconcile", flag.ContinueOnError)
+	format := flags.String("format", "", "plain or csv, by default csv for the .csv files and plain for the rest")
+	column := flags.String("column", sku_import.DefaultColumn, "header of the sku column of a csv file, or its position starting from 1")
+	tenant := flags.String("tenant", "", "tenant of the stored skus, the default one when it's empty")
+	skuIdPattern := flags.String("sku-format", domain.DefaultSkuIdPattern, "regular expression of the valid skus of the tenant, the sku_format of the server")
+	chunkSize := flags.Int("chunk-size", sku_reconcile.DefaultChunkSize, "number of skus of the file sorted in memory at once")
+	tempDir := flags.String("temp-dir", "", "dir of the sorted chunks of the file, the system one when it's empty")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *chunkSize <= 0 {
+		return errUsage
+	}
+	fileName := flags.Arg(0)
+	if *format == "" {
+		*format = sku_import.FormatOf(fileName)
+	}
+	skuTenant, err := domain.NewTenant(*tenant)
+	if err != nil {
+		return err
+	}
+	skuIdFormat, err := domain.NewSkuIdFormat(*skuIdPattern)
+	if err != nil {
+		return err
+	}
+
+	source, err := sku_import.Open(fileName, *format, *column)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	writer := csv.NewWriter(output)
+	err = writer.Write([]string{"kind", "sku", "line", "reason"})
+	if err != nil {
+		return err
+	}
+	reconciler := sku_reconcile.New(skuRepository,
+		sku_reconcile.WithTenant(skuTenant),
+		sku_reconcile.WithSkuIdFormat(skuIdFormat),
+		sku_reconcile.WithChunkSize(*chunkSize),
+		sku_reconcile.WithTempDir(*tempDir),
+	)
+	summary, err := reconciler.Reconcile(ctx, source, func(difference sku_reconcile.Difference) error {
+		var line string
+		if difference.Line > 0 {
+			line = strconv.Itoa(difference.Line)
+		}
+		return writer.Write([]string{difference.Kind, difference.Sku, line, difference.Reason})
+	})
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	if writer.Error() != nil {
+		return writer.Error()
+	}
+
+	printSummary(summaryOutput, summary)
+
+	return nil
+}
+
+func printSummary(output io.Writer, summary sku_reconcile.Summary) {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "file skus\t%d\n", summary.FileSkus)
+	fmt.Fprintf(writer, "duplicated in the file\t%d\n", summary.DuplicatedSkus)
+	fmt.Fprintf(writer, "invalid in the file\t%d\n", summary.InvalidSkus)
+	fmt.Fprintf(writer, "stored skus\t%d\n", summary.StoreSkus)
+	fmt.Fprintf(writer, "matched\t%d\n", summary.MatchedSkus)
+	fmt.Fprintf(writer, "missing in the store\t%d\n", summary.MissingSkus)
+	fmt.Fprintf(writer, "extra in the store\t%d\n", summary.ExtraSkus)
+	writer.Flush()
+}
+
+func connectMongo(ctx context.Context) (*mongo.Client, error) {
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI(fetchEnvVar("MONGO_URI", "mongodb://localhost:27017")))
+	if err != nil {
+		return nil, err
+	}
+
+	return mongoClient, mongoClient.Connect(ctx)
+}
+
+func fetchEnvVar(name, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
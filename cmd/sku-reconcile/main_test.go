@@ -0,0 +1,71 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SkuReconcileUnitSuite struct {
+	suite.Suite
+	ctx        context.Context
+	dir        string
+	repository *memory.SkuRepository
+}
+
+func (s *SkuReconcileUnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.dir = s.T().TempDir()
+	s.repository = memory.NewSkuRepository(domain.NewHydrator())
+	for _, value := range []string{"ABCD-0001", "ABCD-0003"} {
+		skuId, err := domain.NewSkuId(value)
+		s.Require().NoError(err)
+		s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	}
+}
+
+func TestSkuReconcileUnitSuite(t *testing.T) {
+	suite.Run(t, new(SkuReconcileUnitSuite))
+}
+
+func (s *SkuReconcileUnitSuite) TestWriteTheDifferencesAsCsvAndTheSummary() {
+	fileName := filepath.Join(s.dir, "supplier.csv")
+	s.Require().NoError(os.WriteFile(fileName, []byte("name,code\nshoe,ABCD-0001\nhat,ABCD-0002\nboot,ABCD\n"), 0644))
+	output := &strings.Builder{}
+	summaryOutput := &strings.Builder{}
+
+	err := run(s.ctx, s.repository, []string{"-column", "code", fileName}, output, summaryOutput)
+	s.Require().NoError(err)
+	s.Require().Equal("kind,sku,line,reason\n"+
+		"invalid,ABCD,3,invalid Sku provided: ABCD\n"+
+		"missing,ABCD-0002,2,\n"+
+		"extra,ABCD-0003,,\n", output.String())
+	s.Require().Contains(summaryOutput.String(), "missing in the store    1\n")
+	s.Require().Contains(summaryOutput.String(), "matched                 1\n")
+}
+
+func (s *SkuReconcileUnitSuite) TestValidateTheSkusWithTheGivenFormat() {
+	fileName := filepath.Join(s.dir, "supplier.txt")
+	s.Require().NoError(os.WriteFile(fileName, []byte("ABCD-0001\nABCD-0003\n"), 0644))
+	output := &strings.Builder{}
+
+	err := run(s.ctx, s.repository, []string{"-sku-format", "^ABCD-000[13]$", fileName}, output, &strings.Builder{})
+	s.Require().NoError(err)
+	s.Require().Equal("kind,sku,line,reason\n", output.String())
+
+	err = run(s.ctx, s.repository, []string{"-sku-format", "[", fileName}, output, &strings.Builder{})
+	s.Require().ErrorIs(err, domain.ErrInvalidSkuIdFormat)
+}
+
+func (s *SkuReconcileUnitSuite) TestReturnErrUsageWithoutAFile() {
+	err := run(s.ctx, s.repository, nil, &strings.Builder{}, &strings.Builder{})
+	s.Require().ErrorIs(err, errUsage)
+}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
+	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
+	"feeder-service/internal/sku/infrastructure/tracing"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type config struct {
+	socketAddr  string
+	healthAddr string
+	mongoUri string
+	mongoDatabase string
+	logFileName string
+	maxConcurrentConnections int
+	timeout time.Duration
+	gracePeriod time.Duration
+	// commandTimeout limits how long the command of a message can take, zero is no limit.
+	commandTimeout time.Duration
+	// idempotencyStore is where the results of the idempotency keys are kept, see the idempotencyStore constants.
+	idempotencyStore string
+	idempotencyTTL   time.Duration
+	// tracingExporter is where the spans are exported, see the tracing.Exporter constants.
+	tracingExporter string
+	rateLimit ratelimit.Config
+	ipFilterFileName string
+	readLimits sku_reader.ReadLimits
+	skuIdPattern string
+	configFileName string
+	// runSnapshotInterval is how often the report so far is stored in the run history, zero disables the snapshots.
+	runSnapshotInterval time.Duration
+	reportFormat        string
+	reportDestination   string
+	// tenants holds the settings of the tenants with their own sku format or quota, the rest use the defaults.
+	tenants map[string]tenantConfig
+	// dryRun validates the messages without storing anything, see dryrun.SkuRepository.
+	dryRun bool
+	// migrateOnStartup applies the pending migrations of the collections once the server is listening.
+	migrateOnStartup bool
+}
+
+type tenantConfig struct {
+	// skuIdPattern is empty when the tenant uses the default one.
+	skuIdPattern string
+	// quota is the maximum number of messages of the tenant handled in a run, zero is no limit.
+	quota int
+}
+
+func newConfigDefault() *config {
+	return &config{
+		socketAddr:               "localhost:4000",
+		healthAddr:               "localhost:8080",
+		mongoUri:                 "mongodb://localhost:27017",
+		mongoDatabase:            "sku",
+		logFileName:              "server_report_file.txt",
+		maxConcurrentConnections: 5,
+		timeout:                  60 * time.Second,
+		gracePeriod:              10 * time.Second,
+		rateLimit:                ratelimit.Config{Default: ratelimit.Limit{Policy: ratelimit.PolicyReject}},
+		skuIdPattern:             domain.DefaultSkuIdPattern,
+		reportFormat:             reporting.FormatText,
+		reportDestination:        reporting.DestinationStdout,
+		idempotencyStore:         idempotencyStoreMemory,
+		idempotencyTTL:           24 * time.Hour,
+		tracingExporter:          tracing.ExporterNone,
+		migrateOnStartup:         true,
+		readLimits: sku_reader.ReadLimits{
+			IdleTimeout:      10 * time.Second,
+			ReadTimeout:      30 * time.Second,
+			MaxMessageLength: 4096,
+		},
+	}
+}
+
+func fetchConfigFromEnvVars() (*config, error) {
+	cfg := newConfigDefault()
+	fetchSocketAddrEnvVar(cfg)
+	fetchHealthAddrEnvVar(cfg)
+	fetchLogFileNameEnvVar(cfg)
+	fetchIPFilterFileNameEnvVar(cfg)
+	fetchSkuIdPatternEnvVar(cfg)
+	fetchConfigFileNameEnvVar(cfg)
+	fetchReportEnvVars(cfg)
+	fetchIdempotencyStoreEnvVar(cfg)
+	fetchTracingExporterEnvVar(cfg)
+	err := fetchMaxConcurrentConnectionsEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchDryRunEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchMigrateOnStartupEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchTimeoutEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchGracePeriodEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchCommandTimeoutEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchIdempotencyTTLEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchRateLimitEnvVars(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchReadLimitsEnvVars(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fetchRunSnapshotIntervalEnvVar(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func fetchSocketAddrEnvVar(cfg *config) {
+	socketAddr, ok := os.LookupEnv("SOCKET_ADDR")
+	if ok {
+		cfg.socketAddr = socketAddr
+	}
+}
+
+func fetchHealthAddrEnvVar(cfg *config) {
+	healthAddr, ok := os.LookupEnv("HEALTH_ADDR")
+	if ok {
+		cfg.healthAddr = healthAddr
+	}
+}
+
+func fetchLogFileNameEnvVar(cfg *config) {
+	logFileName, ok := os.LookupEnv("LOG_FILE_NAME")
+	if ok {
+		cfg.logFileName = logFileName
+	}
+}
+
+func fetchIPFilterFileNameEnvVar(cfg *config) {
+	ipFilterFileName, ok := os.LookupEnv("IP_FILTER_FILE")
+	if ok {
+		cfg.ipFilterFileName = ipFilterFileName
+	}
+}
+
+func fetchSkuIdPatternEnvVar(cfg *config) {
+	skuIdPattern, ok := os.LookupEnv("SKU_FORMAT")
+	if ok {
+		cfg.skuIdPattern = skuIdPattern
+	}
+}
+
+func fetchConfigFileNameEnvVar(cfg *config) {
+	configFileName, ok := os.LookupEnv("CONFIG_FILE")
+	if ok {
+		cfg.configFileName = configFileName
+	}
+}
+
+func fetchReportEnvVars(cfg *config) {
+	reportFormat, ok := os.LookupEnv("REPORT_FORMAT")
+	if ok {
+		cfg.reportFormat = reportFormat
+	}
+	reportDestination, ok := os.LookupEnv("REPORT_DESTINATION")
+	if ok {
+		cfg.reportDestination = reportDestination
+	}
+}
+
+func fetchIdempotencyStoreEnvVar(cfg *config) {
+	idempotencyStore, ok := os.LookupEnv("IDEMPOTENCY_STORE")
+	if ok {
+		cfg.idempotencyStore = idempotencyStore
+	}
+}
+
+func fetchTracingExporterEnvVar(cfg *config) {
+	tracingExporter, ok := os.LookupEnv("TRACING_EXPORTER")
+	if ok {
+		cfg.tracingExporter = tracingExporter
+	}
+}
+
+func fetchDryRunEnvVar(cfg *config) error {
+	dryRunAsString, ok := os.LookupEnv("DRY_RUN")
+	if ok {
+		dryRun, err := strconv.ParseBool(dryRunAsString)
+		if err != nil {
+			return err
+		}
+		cfg.dryRun = dryRun
+	}
+
+	return nil
+}
+
+func fetchMigrateOnStartupEnvVar(cfg *config) error {
+	migrateOnStartupAsString, ok := os.LookupEnv("MIGRATE_ON_STARTUP")
+	if ok {
+		migrateOnStartup, err := strconv.ParseBool(migrateOnStartupAsString)
+		if err != nil {
+			return err
+		}
+		cfg.migrateOnStartup = migrateOnStartup
+	}
+
+	return nil
+}
+
+func fetchIdempotencyTTLEnvVar(cfg *config) error {
+	ttlAsString, ok := os.LookupEnv("IDEMPOTENCY_TTL_IN_SECS")
+	if ok {
+		ttl, err := strconv.Atoi(ttlAsString)
+		if err != nil {
+			return err
+		}
+		cfg.idempotencyTTL = time.Duration(ttl) * time.Second
+	}
+
+	return nil
+}
+
+func fetchMaxConcurrentConnectionsEnvVar(cfg *config) error{
+	maxConcurrentConnsAsString, ok := os.LookupEnv("MAX_CONCURRENT_CONNECTIONS")
+	if ok {
+		maxConcurrentConns, err := strconv.Atoi(maxConcurrentConnsAsString)
+		if err != nil {
+			return err
+		}
+		cfg.maxConcurrentConnections = maxConcurrentConns
+	}
+
+	return nil
+}
+
+func fetchTimeoutEnvVar(cfg *config) error{
+	timeoutAsString, ok := os.LookupEnv("TIMEOUT_IN_SECS")
+	if ok {
+		timeout, err := strconv.Atoi(timeoutAsString)
+		if err != nil {
+			return err
+		}
+		cfg.timeout = time.Duration(timeout) * time.Second
+	}
+
+	return nil
+}
+
+func fetchGracePeriodEnvVar(cfg *config) error {
+	gracePeriodAsString, ok := os.LookupEnv("GRACE_PERIOD_IN_SECS")
+	if ok {
+		gracePeriod, err := strconv.Atoi(gracePeriodAsString)
+		if err != nil {
+			return err
+		}
+		cfg.gracePeriod = time.Duration(gracePeriod) * time.Second
+	}
+
+	return nil
+}
+
+func fetchCommandTimeoutEnvVar(cfg *config) error {
+	commandTimeoutAsString, ok := os.LookupEnv("COMMAND_TIMEOUT_IN_SECS")
+	if ok {
+		commandTimeout, err := strconv.Atoi(commandTimeoutAsString)
+		if err != nil {
+			return err
+		}
+		cfg.commandTimeout = time.Duration(commandTimeout) * time.Second
+	}
+
+	return nil
+}
+
+func fetchRunSnapshotIntervalEnvVar(cfg *config) error {
+	intervalAsString, ok := os.LookupEnv("RUN_SNAPSHOT_INTERVAL_IN_SECS")
+	if ok {
+		interval, err := strconv.Atoi(intervalAsString)
+		if err != nil {
+			return err
+		}
+		cfg.runSnapshotInterval = time.Duration(interval) * time.Second
+	}
+
+	return nil
+}
+
+func fetchReadLimitsEnvVars(cfg *config) error {
+	idleTimeoutAsString, ok := os.LookupEnv("IDLE_TIMEOUT_IN_SECS")
+	if ok {
+		idleTimeout, err := strconv.Atoi(idleTimeoutAsString)
+		if err != nil {
+			return err
+		}
+		cfg.readLimits.IdleTimeout = time.Duration(idleTimeout) * time.Second
+	}
+
+	readTimeoutAsString, ok := os.LookupEnv("READ_TIMEOUT_IN_SECS")
+	if ok {
+		readTimeout, err := strconv.Atoi(readTimeoutAsString)
+		if err != nil {
+			return err
+		}
+		cfg.readLimits.ReadTimeout = time.Duration(readTimeout) * time.Second
+	}
+
+	maxMessageLengthAsString, ok := os.LookupEnv("MAX_MESSAGE_LENGTH")
+	if ok {
+		maxMessageLength, err := strconv.Atoi(maxMessageLengthAsString)
+		if err != nil {
+			return err
+		}
+		cfg.readLimits.MaxMessageLength = maxMessageLength
+	}
+
+	return nil
+}
+
+func fetchRateLimitEnvVars(cfg *config) error {
+	rateAsString, ok := os.LookupEnv("RATE_LIMIT_PER_SEC")
+	if ok {
+		rate, err := strconv.ParseFloat(rateAsString, 64)
+		if err != nil {
+			return err
+		}
+		cfg.rateLimit.Default.RatePerSecond = rate
+	}
+
+	burstAsString, ok := os.LookupEnv("RATE_LIMIT_BURST")
+	if ok {
+		burst, err := strconv.Atoi(burstAsString)
+		if err != nil {
+			return err
+		}
+		cfg.rateLimit.Default.Burst = burst
+	}
+
+	policyAsString, ok := os.LookupEnv("RATE_LIMIT_POLICY")
+	if ok {
+		policy, err := ratelimit.ParsePolicy(policyAsString)
+		if err != nil {
+			return err
+		}
+		cfg.rateLimit.Default.Policy = policy
+	}
+
+	clientsAsString, ok := os.LookupEnv("RATE_LIMIT_CLIENTS")
+	if ok {
+		clients, err := parseClientRateLimits(clientsAsString)
+		if err != nil {
+			return err
+		}
+		cfg.rateLimit.Clients = clients
+	}
+
+	return nil
+}
+
+var errInvalidClientRateLimit = errors.New("invalid client rate limit, expected client=rate:burst:policy")
+
+// parseClientRateLimits parses a comma separated list of client=rate:burst:policy entries.
+func parseClientRateLimits(value string) (map[string]ratelimit.Limit, error) {
+	clients := map[string]ratelimit.Limit{}
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		client, limitAsString := splitInTwo(strings.TrimSpace(entry), "=")
+		fields := strings.Split(limitAsString, ":")
+		if client == "" || len(fields) != 3 {
+			return nil, fmt.Errorf("%w: %s", errInvalidClientRateLimit, entry)
+		}
+		rate, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidClientRateLimit, entry)
+		}
+		burst, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidClientRateLimit, entry)
+		}
+		policy, err := ratelimit.ParsePolicy(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		clients[client] = ratelimit.Limit{RatePerSecond: rate, Burst: burst, Policy: policy}
+	}
+
+	return clients, nil
+}
+
+func splitInTwo(value, separator string) (string, string) {
+	parts := strings.SplitN(value, separator, 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// loadConfig fetches the config from the env vars and overrides it with the values of the config file, if any.
+// It's called again on every reload, so the config file is the place for the settings that change while running.
+func loadConfig() (*config, error) {
+	cfg, err := fetchConfigFromEnvVars()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.configFileName != "" {
+		err = applyConfigFile(cfg, cfg.configFileName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = cfg.skuIdFormats()
+	if err != nil {
+		return nil, err
+	}
+	_, err = reporting.NewFormatter(cfg.reportFormat)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.validateIdempotency()
+	if err != nil {
+		return nil, err
+	}
+	err = tracing.CheckExporter(cfg.tracingExporter)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+const (
+	idempotencyStoreMemory = "memory"
+	idempotencyStoreMongo  = "mongo"
+)
+
+var errInvalidIdempotency = errors.New("invalid idempotency config")
+
+func (c *config) validateIdempotency() error {
+	if c.idempotencyStore != idempotencyStoreMemory && c.idempotencyStore != idempotencyStoreMongo {
+		return fmt.Errorf("%w: unknown store %q", errInvalidIdempotency, c.idempotencyStore)
+	}
+	if c.idempotencyTTL <= 0 {
+		return fmt.Errorf("%w: the ttl has to be greater than zero", errInvalidIdempotency)
+	}
+
+	return nil
+}
+
+type configFile struct {
+	SocketAddr                *string                     `json:"socket_addr"`
+	HealthAddr                *string                     `json:"health_addr"`
+	MongoUri                  *string                     `json:"mongo_uri"`
+	MongoDatabase             *string                     `json:"mongo_database"`
+	LogFileName               *string                     `json:"log_file_name"`
+	MaxConcurrentConnections  *int                        `json:"max_concurrent_connections"`
+	TimeoutInSecs             *int                        `json:"timeout_in_secs"`
+	GracePeriodInSecs         *int                        `json:"grace_period_in_secs"`
+	CommandTimeoutInSecs      *int                        `json:"command_timeout_in_secs"`
+	SkuFormat                 *string                     `json:"sku_format"`
+	IPFilterFile              *string                     `json:"ip_filter_file"`
+	RateLimit                 *configFileRateLimit        `json:"rate_limit"`
+	ReadLimits                *configFileReadLimits       `json:"read_limits"`
+	RunSnapshotIntervalInSecs *int                        `json:"run_snapshot_interval_in_secs"`
+	ReportFormat              *string                     `json:"report_format"`
+	ReportDestination         *string                     `json:"report_destination"`
+	Idempotency               *configFileIdempotency      `json:"idempotency"`
+	TracingExporter           *string                     `json:"tracing_exporter"`
+	Tenants                   map[string]configFileTenant `json:"tenants"`
+	DryRun                    *bool                       `json:"dry_run"`
+	MigrateOnStartup          *bool                       `json:"migrate_on_startup"`
+}
+
+type configFileTenant struct {
+	SkuFormat string `json:"sku_format"`
+	Quota     int    `json:"quota"`
+}
+
+type configFileIdempotency struct {
+	Store     *string `json:"store"`
+	TTLInSecs *int    `json:"ttl_in_secs"`
+}
+
+type configFileLimit struct {
+	RatePerSec float64 `json:"rate_per_sec"`
+	Burst      int     `json:"burst"`
+	Policy     string  `json:"policy"`
+}
+
+type configFileRateLimit struct {
+	configFileLimit
+	Clients map[string]configFileLimit `json:"clients"`
+}
+
+type configFileReadLimits struct {
+	IdleTimeoutInSecs *int `json:"idle_timeout_in_secs"`
+	ReadTimeoutInSecs *int `json:"read_timeout_in_secs"`
+	MaxMessageLength  *int `json:"max_message_length"`
+}
+
+var errInvalidConfigFile = errors.New("invalid config file")
+
+func applyConfigFile(cfg *config, fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var values configFile
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&values)
+	if err != nil {
+		return fmt.Errorf("%w %s: %s", errInvalidConfigFile, fileName, err.Error())
+	}
+
+	return values.applyTo(cfg)
+}
+
+func (f configFile) applyTo(cfg *config) error {
+	setString(&cfg.socketAddr, f.SocketAddr)
+	setString(&cfg.healthAddr, f.HealthAddr)
+	setString(&cfg.mongoUri, f.MongoUri)
+	setString(&cfg.mongoDatabase, f.MongoDatabase)
+	setString(&cfg.logFileName, f.LogFileName)
+	setString(&cfg.skuIdPattern, f.SkuFormat)
+	setString(&cfg.ipFilterFileName, f.IPFilterFile)
+	setString(&cfg.reportFormat, f.ReportFormat)
+	setString(&cfg.reportDestination, f.ReportDestination)
+	setString(&cfg.tracingExporter, f.TracingExporter)
+	if f.MaxConcurrentConnections != nil {
+		cfg.maxConcurrentConnections = *f.MaxConcurrentConnections
+	}
+	setSeconds(&cfg.timeout, f.TimeoutInSecs)
+	setSeconds(&cfg.gracePeriod, f.GracePeriodInSecs)
+	setSeconds(&cfg.commandTimeout, f.CommandTimeoutInSecs)
+	if f.Idempotency != nil {
+		setString(&cfg.idempotencyStore, f.Idempotency.Store)
+		setSeconds(&cfg.idempotencyTTL, f.Idempotency.TTLInSecs)
+	}
+	setSeconds(&cfg.runSnapshotInterval, f.RunSnapshotIntervalInSecs)
+	if f.DryRun != nil {
+		cfg.dryRun = *f.DryRun
+	}
+	if f.MigrateOnStartup != nil {
+		cfg.migrateOnStartup = *f.MigrateOnStartup
+	}
+
+	if f.ReadLimits != nil {
+		setSeconds(&cfg.readLimits.IdleTimeout, f.ReadLimits.IdleTimeoutInSecs)
+		setSeconds(&cfg.readLimits.ReadTimeout, f.ReadLimits.ReadTimeoutInSecs)
+		if f.ReadLimits.MaxMessageLength != nil {
+			cfg.readLimits.MaxMessageLength = *f.ReadLimits.MaxMessageLength
+		}
+	}
+
+	if f.Tenants != nil {
+		cfg.tenants = map[string]tenantConfig{}
+		for tenant, tenantValues := range f.Tenants {
+			cfg.tenants[tenant] = tenantConfig{skuIdPattern: tenantValues.SkuFormat, quota: tenantValues.Quota}
+		}
+	}
+
+	if f.RateLimit != nil {
+		rateLimit, err := f.RateLimit.toConfig()
+		if err != nil {
+			return err
+		}
+		cfg.rateLimit = rateLimit
+	}
+
+	return nil
+}
+
+func (r configFileRateLimit) toConfig() (ratelimit.Config, error) {
+	defaultLimit, err := r.configFileLimit.toLimit()
+	if err != nil {
+		return ratelimit.Config{}, err
+	}
+	rateLimit := ratelimit.Config{Default: defaultLimit, Clients: map[string]ratelimit.Limit{}}
+	for client, clientLimit := range r.Clients {
+		rateLimit.Clients[client], err = clientLimit.toLimit()
+		if err != nil {
+			return ratelimit.Config{}, err
+		}
+	}
+
+	return rateLimit, nil
+}
+
+func (l configFileLimit) toLimit() (ratelimit.Limit, error) {
+	policy := ratelimit.PolicyReject
+	if l.Policy != "" {
+		var err error
+		policy, err = ratelimit.ParsePolicy(l.Policy)
+		if err != nil {
+			return ratelimit.Limit{}, err
+		}
+	}
+
+	return ratelimit.Limit{RatePerSecond: l.RatePerSec, Burst: l.Burst, Policy: policy}, nil
+}
+
+func setString(target *string, value *string) {
+	if value != nil {
+		*target = *value
+	}
+}
+
+func setSeconds(target *time.Duration, seconds *int) {
+	if seconds != nil {
+		*target = time.Duration(*seconds) * time.Second
+	}
+}
+
+var errInvalidTenantQuota = errors.New("invalid tenant quota, it can't be negative")
+
+// skuIdFormats returns the sku format of each tenant, the ones without their own format use the sku_format.
+func (c *config) skuIdFormats() (*domain.SkuIdFormats, error) {
+	defaultFormat, err := domain.NewSkuIdFormat(c.skuIdPattern)
+	if err != nil {
+		return nil, err
+	}
+	tenantFormats := map[domain.Tenant]*domain.SkuIdFormat{}
+	for tenantValue, tenant := range c.tenants {
+		skuTenant, err := domain.NewTenant(tenantValue)
+		if err != nil {
+			return nil, err
+		}
+		if tenant.quota < 0 {
+			return nil, fmt.Errorf("%w: %s", errInvalidTenantQuota, tenantValue)
+		}
+		if tenant.skuIdPattern == "" {
+			continue
+		}
+		tenantFormats[skuTenant], err = domain.NewSkuIdFormat(tenant.skuIdPattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tenantValue, err)
+		}
+	}
+
+	return domain.NewSkuIdFormats(defaultFormat, tenantFormats), nil
+}
+
+func (c *config) tenantQuotas() map[string]int {
+	quotas := map[string]int{}
+	for tenant, tenantValues := range c.tenants {
+		quotas[tenant] = tenantValues.quota
+	}
+
+	return quotas
+}
+
+// hash identifies the settings of a run, so runs with the same config can be told apart from the others
+// in the run history. The config file name is left out as only its content matters.
+func (c config) hash() string {
+	c.configFileName = ""
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v", c)))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,185 @@
+//+build unit
+
+package main
+
+import (
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type ConfigUnitSuite struct {
+	suite.Suite
+	configFileName string
+}
+
+func (s *ConfigUnitSuite) SetupTest() {
+	s.configFileName = filepath.Join(s.T().TempDir(), "config.json")
+	s.Require().NoError(os.Setenv("CONFIG_FILE", s.configFileName))
+	s.Require().NoError(os.Setenv("MAX_CONCURRENT_CONNECTIONS", "5"))
+}
+
+func (s *ConfigUnitSuite) TearDownTest() {
+	s.Require().NoError(os.Unsetenv("CONFIG_FILE"))
+	s.Require().NoError(os.Unsetenv("MAX_CONCURRENT_CONNECTIONS"))
+}
+
+func TestConfigUnitSuite(t *testing.T) {
+	suite.Run(t, new(ConfigUnitSuite))
+}
+
+func (s *ConfigUnitSuite) TestConfigFileOverridesEnvVarsAndDefaults() {
+	s.writeConfigFile(`{
+		"max_concurrent_connections": 10,
+		"sku_format": "^[A-Z]{3}-[0-9]{5}$",
+		"read_limits": {"idle_timeout_in_secs": 3},
+		"rate_limit": {"rate_per_sec": 2, "burst": 4, "policy": "delay", "clients": {"10.0.0.1": {"rate_per_sec": 1, "burst": 1}}}
+	}`)
+
+	cfg, err := loadConfig()
+	s.Require().NoError(err)
+	s.Require().Equal(10, cfg.maxConcurrentConnections)
+	s.Require().Equal("^[A-Z]{3}-[0-9]{5}$", cfg.skuIdPattern)
+	s.Require().Equal(3*time.Second, cfg.readLimits.IdleTimeout)
+	s.Require().Equal(30*time.Second, cfg.readLimits.ReadTimeout)
+	s.Require().Equal(ratelimit.Config{
+		Default: ratelimit.Limit{RatePerSecond: 2, Burst: 4, Policy: ratelimit.PolicyDelay},
+		Clients: map[string]ratelimit.Limit{"10.0.0.1": {RatePerSecond: 1, Burst: 1, Policy: ratelimit.PolicyReject}},
+	}, cfg.rateLimit)
+	s.Require().Equal("localhost:4000", cfg.socketAddr)
+}
+
+func (s *ConfigUnitSuite) TestConfigFileWithUnknownSettingsIsRejected() {
+	s.writeConfigFile(`{"max_connections": 10}`)
+
+	_, err := loadConfig()
+	s.Require().ErrorIs(err, errInvalidConfigFile)
+}
+
+func (s *ConfigUnitSuite) TestConfigWithAnInvalidSkuFormatIsRejected() {
+	s.writeConfigFile(`{"sku_format": "^[A-Z"}`)
+
+	_, err := loadConfig()
+	s.Require().Error(err)
+}
+
+func (s *ConfigUnitSuite) TestTenantsHaveTheirOwnSkuFormatAndQuota() {
+	s.writeConfigFile(`{"tenants": {"acme": {"sku_format": "^ACME-[0-9]{6}$", "quota": 100}, "globex": {"quota": 5}}}`)
+
+	cfg, err := loadConfig()
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]int{"acme": 100, "globex": 5}, cfg.tenantQuotas())
+	skuIdFormats, err := cfg.skuIdFormats()
+	s.Require().NoError(err)
+	_, err = skuIdFormats.NewSkuId("acme", "ACME-123456")
+	s.Require().NoError(err)
+	_, err = skuIdFormats.NewSkuId("globex", "ABCD-1234")
+	s.Require().NoError(err)
+}
+
+func (s *ConfigUnitSuite) TestConfigWithAnInvalidTenantIsRejected() {
+	for _, content := range []string{
+		`{"tenants": {"Acme Inc": {"quota": 1}}}`,
+		`{"tenants": {"acme": {"sku_format": "^[A-Z"}}}`,
+		`{"tenants": {"acme": {"quota": -1}}}`,
+	} {
+		s.writeConfigFile(content)
+
+		_, err := loadConfig()
+		s.Require().Error(err, content)
+	}
+}
+
+func (s *ConfigUnitSuite) TestConfigWithAnUnknownReportFormatIsRejected() {
+	s.writeConfigFile(`{"report_format": "xml"}`)
+
+	_, err := loadConfig()
+	s.Require().ErrorIs(err, reporting.ErrUnknownFormat)
+}
+
+func (s *ConfigUnitSuite) TestIdempotencyStoreAndTTLCanBeConfigured() {
+	s.writeConfigFile(`{"idempotency": {"store": "mongo", "ttl_in_secs": 3600}}`)
+
+	cfg, err := loadConfig()
+	s.Require().NoError(err)
+	s.Require().Equal(idempotencyStoreMongo, cfg.idempotencyStore)
+	s.Require().Equal(time.Hour, cfg.idempotencyTTL)
+
+	s.writeConfigFile(`{"idempotency": {"store": "redis"}}`)
+	_, err = loadConfig()
+	s.Require().ErrorIs(err, errInvalidIdempotency)
+}
+
+func (s *ConfigUnitSuite) TestDryRunCanBeEnabledByEnvVarOrConfigFile() {
+	s.Require().NoError(os.Setenv("DRY_RUN", "true"))
+	defer os.Unsetenv("DRY_RUN")
+	s.writeConfigFile(`{}`)
+
+	cfg, err := loadConfig()
+	s.Require().NoError(err)
+	s.Require().True(cfg.dryRun)
+
+	s.writeConfigFile(`{"dry_run": false}`)
+	cfg, err = loadConfig()
+	s.Require().NoError(err)
+	s.Require().False(cfg.dryRun)
+
+	s.Require().NoError(os.Setenv("DRY_RUN", "maybe"))
+	_, err = loadConfig()
+	s.Require().Error(err)
+}
+
+func (s *ConfigUnitSuite) TestMigrationsOnStartupCanBeDisabled() {
+	s.writeConfigFile(`{}`)
+	cfg, err := loadConfig()
+	s.Require().NoError(err)
+	s.Require().True(cfg.migrateOnStartup)
+
+	s.writeConfigFile(`{"migrate_on_startup": false}`)
+	cfg, err = loadConfig()
+	s.Require().NoError(err)
+	s.Require().False(cfg.migrateOnStartup)
+}
+
+func (s *ConfigUnitSuite) TestRestartRequiredSettings() {
+	current := newConfigDefault()
+	reloaded := newConfigDefault()
+	reloaded.socketAddr = "localhost:4001"
+	reloaded.readLimits.MaxMessageLength = 10
+	reloaded.maxConcurrentConnections = 50
+	reloaded.skuIdPattern = "^[0-9]+$"
+
+	s.Require().Equal([]string{"socket_addr", "read_limits"}, restartRequiredSettings(current, reloaded))
+}
+
+func (s *ConfigUnitSuite) TestConfigHashChangesOnlyWhenTheSettingsChange() {
+	cfg := newConfigDefault()
+	sameSettings := newConfigDefault()
+	sameSettings.configFileName = "config.json"
+	otherSettings := newConfigDefault()
+	otherSettings.maxConcurrentConnections = 50
+
+	s.Require().Equal(cfg.hash(), sameSettings.hash())
+	s.Require().NotEqual(cfg.hash(), otherSettings.hash())
+}
+
+func (s *ConfigUnitSuite) TestOnlyTheAppliedSettingsAreKeptAfterAReload() {
+	current := newConfigDefault()
+	reloaded := newConfigDefault()
+	reloaded.socketAddr = "localhost:4001"
+	reloaded.maxConcurrentConnections = 50
+	reloaded.logFileName = "another_file.txt"
+
+	updated := current.withLiveSettingsFrom(reloaded, []string{"log_file_name"})
+	s.Require().Equal("localhost:4000", updated.socketAddr)
+	s.Require().Equal(50, updated.maxConcurrentConnections)
+	s.Require().Equal(current.logFileName, updated.logFileName)
+}
+
+func (s *ConfigUnitSuite) writeConfigFile(content string) {
+	s.Require().NoError(os.WriteFile(s.configFileName, []byte(content), 0600))
+}
@@ -2,123 +2,246 @@ package main
 
 import (
 	"context"
+	"errors"
+	"feeder-service/internal/run/application/command/record_run"
+	runDomain "feeder-service/internal/run/domain"
+	mongoRun "feeder-service/internal/run/infrastructure/persistence/mongo"
+	"feeder-service/internal/sku/application/bus"
 	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/application/command/deactivate_sku"
+	"feeder-service/internal/sku/application/command/delete_sku"
+	"feeder-service/internal/sku/application/command/reactivate_sku"
+	"feeder-service/internal/sku/application/command/update_sku"
+	"feeder-service/internal/sku/application/command/upsert_sku"
 	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/http/health"
+	"feeder-service/internal/sku/infrastructure/io/ipfilter"
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
 	"feeder-service/internal/sku/infrastructure/io/socket/tcp/server"
 	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
+	"feeder-service/internal/sku/infrastructure/persistence/dryrun"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
 	mongoSku "feeder-service/internal/sku/infrastructure/persistence/mongo"
+	"feeder-service/internal/sku/infrastructure/tracing"
 	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"log"
 	"net"
+	"net/http"
 	"os"
-	"strconv"
+	"sync"
 	"time"
 )
 
-type config struct {
-	socketAddr  string
-	mongoUri string
-	mongoDatabase string
-	logFileName string
-	maxConcurrentConnections int
-	timeout time.Duration
-}
-
-func newConfigDefault() *config {
-	return &config{
-		socketAddr:               "localhost:4000",
-		mongoUri:                 "mongodb://localhost:27017",
-		mongoDatabase:            "sku",
-		logFileName:              "server_report_file.txt",
-		maxConcurrentConnections: 5,
-		timeout:                  60 * time.Second,
-	}
-}
-
 func main() {
-	cfg, err := fetchConfigFromEnvVars()
+	cfg, err := loadConfig()
 	if err != nil {
-		log.Fatalf("error fetching application config from env vars: %v", err)
+		log.Fatalf("error fetching application config: %v", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
 	defer cancel()
 
-	serverTCP, err := bootstrapApplication(ctx, cfg)
+	app, err := bootstrapApplication(ctx, cfg)
 	if err != nil {
 		log.Fatalf("error bootstraping application: %v", err)
 	}
+	go func() {
+		fmt.Println("Starting health endpoints in "+cfg.healthAddr)
+		err := app.healthServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("error serving health endpoints: %v", err)
+		}
+	}()
+	reloadCtx, stopReloading := context.WithCancel(ctx)
+	reloadFinished := make(chan struct{})
+	go func() {
+		app.reloadOnHangup(reloadCtx)
+		close(reloadFinished)
+	}()
+	snapshotsFinished := make(chan struct{})
+	go func() {
+		app.snapshotRuns(reloadCtx)
+		close(snapshotsFinished)
+	}()
+	migrationsFinished := make(chan struct{})
+	go func() {
+		app.migrate(reloadCtx)
+		close(migrationsFinished)
+	}()
+	if cfg.dryRun {
+		fmt.Println("Dry run: the skus are validated but not stored")
+	}
 	fmt.Println("Starting listening tcp connections in "+cfg.socketAddr)
-	report := serverTCP.Run(ctx, cfg.maxConcurrentConnections, time.Now().Add(cfg.timeout))
+	report := app.serverTCP.Run(ctx, cfg.maxConcurrentConnections, time.Now().Add(cfg.timeout))
+	stopReloading()
+	<-reloadFinished
+	<-snapshotsFinished
+	<-migrationsFinished
+	endedAt := time.Now()
+	app.recordRun(report.Counters(), false)
+	shutdownErr := app.shutdown()
+	publishErr := app.publishReport(report, endedAt)
+	if publishErr != nil {
+		log.Printf("error publishing report: %v", publishErr)
+		shutdownErr = publishErr
+	}
+
+	exitCode := shutdownExitCode(report, shutdownErr)
+	if exitCode != exitCodeCleanShutdown {
+		os.Exit(exitCode)
+	}
+}
+
+const (
+	exitCodeCleanShutdown = 0
+	// exitCodeGracePeriodExceeded means that some connections were force closed and their messages were lost
+	exitCodeGracePeriodExceeded = 2
+	// exitCodeShutdownFailed means that the created skus log couldn't be flushed, mongodb couldn't be disconnected
+	// or the report couldn't be published
+	exitCodeShutdownFailed = 3
+)
+
+func shutdownExitCode(report reporting.Report, shutdownErr error) int {
+	if shutdownErr != nil {
+		return exitCodeShutdownFailed
+	}
+	if report.GracePeriodExceeded {
+		return exitCodeGracePeriodExceeded
+	}
 
-	fmt.Println("Received "+strconv.Itoa(report.CreatedSkus)+" unique product skus, "+strconv.Itoa(report.DuplicatedSkus)+" duplicates, "+strconv.Itoa(report.InvalidSkus)+" discard values")
+	return exitCodeCleanShutdown
 }
 
-func fetchConfigFromEnvVars() (*config, error) {
-	cfg := newConfigDefault()
-	fetchSocketAddrEnvVar(cfg)
-	fetchLogFileNameEnvVar(cfg)
-	err := fetchMaxConcurrentConnectionsEnvVar(cfg)
+type application struct {
+	cfg                         *config
+	serverTCP                   *server.Server
+	skuReader                   *sku_reader.SkuReaderImpl
+	ipFilter                    *ipfilter.Filter
+	rateLimiter                 *ratelimit.Limiter
+	createSkuCommandHandler     *create_sku.CommandHandler
+	updateSkuCommandHandler     *update_sku.CommandHandler
+	upsertSkuCommandHandler     *upsert_sku.CommandHandler
+	deactivateSkuCommandHandler *deactivate_sku.CommandHandler
+	reactivateSkuCommandHandler *reactivate_sku.CommandHandler
+	deleteSkuCommandHandler     *delete_sku.CommandHandler
+	logger                      *log.Logger
+	logFile                     *os.File
+	recordRunCommandHandler     *record_run.CommandHandler
+	runId                       string
+	startedAt                   time.Time
+	host                        string
+	configHash                  string
+	mongoClient                 *mongo.Client
+	migrationRunner             *migration.Runner
+	// mutex guards cfg and logFile, which reload replaces while the other goroutines read them.
+	mutex sync.RWMutex
+	// tracerProvider is nil when the tracing is disabled.
+	tracerProvider *sdktrace.TracerProvider
+	healthServer   *http.Server
+}
+
+const shutdownTimeout = 5 * time.Second
+
+// shutdown runs after the server has drained its connections: it stops the health endpoints, flushes the created
+// skus log and disconnects mongodb. It returns an error when the log or mongodb could not be closed cleanly.
+func (a *application) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := a.healthServer.Shutdown(ctx)
 	if err != nil {
-		return nil, err
+		log.Printf("error stopping health endpoints: %v", err)
 	}
 
-	err = fetchTimeoutEnvVar(cfg)
+	var shutdownErr error
+	err = a.closeLogFile()
 	if err != nil {
-		return nil, err
+		log.Printf("error flushing log file: %v", err)
+		shutdownErr = err
 	}
 
-	return cfg, nil
-}
+	err = a.mongoClient.Disconnect(ctx)
+	if err != nil {
+		log.Printf("error disconnecting mongodb: %v", err)
+		shutdownErr = err
+	}
 
-func fetchSocketAddrEnvVar(cfg *config) {
-	socketAddr, ok := os.LookupEnv("SOCKET_ADDR")
-	if ok {
-		cfg.socketAddr = socketAddr
+	if a.tracerProvider != nil {
+		err = a.tracerProvider.Shutdown(ctx)
+		if err != nil {
+			log.Printf("error exporting the last spans: %v", err)
+		}
 	}
+
+	return shutdownErr
 }
 
-func fetchLogFileNameEnvVar(cfg *config) {
-	logFileName, ok := os.LookupEnv("LOG_FILE_NAME")
-	if ok {
-		cfg.logFileName = logFileName
+// publishReport writes the report in the configured format to the configured destination.
+func (a *application) publishReport(report reporting.Report, endedAt time.Time) error {
+	cfg := a.currentConfig()
+	formatter, err := reporting.NewFormatter(cfg.reportFormat)
+	if err != nil {
+		return err
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	destination := reporting.NewDestination(cfg.reportDestination, &http.Client{Timeout: shutdownTimeout})
+
+	return reporting.Publish(ctx, reporting.NewSummary(report, a.startedAt, endedAt), formatter, destination)
 }
 
-func fetchMaxConcurrentConnectionsEnvVar(cfg *config) error{
-	maxConcurrentConnsAsString, ok := os.LookupEnv("MAX_CONCURRENT_CONNECTIONS")
-	if ok {
-		maxConcurrentConns, err := strconv.Atoi(maxConcurrentConnsAsString)
-		if err != nil {
-			return err
-		}
-		cfg.maxConcurrentConnections = maxConcurrentConns
-	}
+// currentConfig returns the config with the settings applied by the last reload.
+func (a *application) currentConfig() *config {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
 
-	return nil
+	return a.cfg
 }
 
-func fetchTimeoutEnvVar(cfg *config) error{
-	timeoutAsString, ok := os.LookupEnv("TIMEOUT_IN_SECS")
-	if ok {
-		timeout, err := strconv.Atoi(timeoutAsString)
-		if err != nil {
-			return err
-		}
-		cfg.timeout = time.Duration(timeout) * time.Second
+func (a *application) closeLogFile() error {
+	a.mutex.RLock()
+	logFile := a.logFile
+	a.mutex.RUnlock()
+	err := logFile.Sync()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return logFile.Close()
 }
 
-func bootstrapApplication(ctx context.Context, cfg *config) (*server.Server, error) {
+var errConnectionSlotsExhausted = errors.New("connection slots exhausted")
+
+func bootstrapApplication(ctx context.Context, cfg *config) (*application, error) {
+	tracerProvider, err := installTracerProvider(ctx, cfg.tracingExporter)
+	if err != nil {
+		return nil, err
+	}
 	listener, err := net.Listen("tcp", cfg.socketAddr)
 	if err != nil {
 		return nil, err
 	}
-	skuReader, err := sku_reader.New(listener)
+	ipFilter, err := ipfilter.New(ipfilter.Rules{})
+	if err != nil {
+		return nil, err
+	}
+	err = reloadIPFilter(ipFilter, cfg.ipFilterFileName)
+	if err != nil {
+		return nil, err
+	}
+	rateLimiter := ratelimit.New(cfg.rateLimit)
+	skuReader, err := sku_reader.New(
+		listener,
+		sku_reader.WithIPFilter(ipFilter),
+		sku_reader.WithRateLimiter(rateLimiter),
+		sku_reader.WithReadLimits(cfg.readLimits),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -133,18 +256,201 @@ func bootstrapApplication(ctx context.Context, cfg *config) (*server.Server, err
 	}
 
 	db := mongoClient.Database(cfg.mongoDatabase)
-	skuRepository, err := mongoSku.NewSkuRepository(db, domain.NewHydrator())
+	migrationRunner, err := newMigrationRunner(db)
+	if err != nil {
+		return nil, err
+	}
+	skuRepository, err := newSkuRepository(cfg, db)
 	if err != nil {
 		return nil, err
 	}
 
+	skuIdFormats, err := cfg.skuIdFormats()
+	if err != nil {
+		return nil, err
+	}
 	createSkuCommandHandler := create_sku.NewCommandHandler(skuRepository)
+	createSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	updateSkuCommandHandler := update_sku.NewCommandHandler(skuRepository)
+	updateSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	upsertSkuCommandHandler := upsert_sku.NewCommandHandler(skuRepository)
+	upsertSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	deactivateSkuCommandHandler := deactivate_sku.NewCommandHandler(skuRepository)
+	deactivateSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	reactivateSkuCommandHandler := reactivate_sku.NewCommandHandler(skuRepository)
+	reactivateSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	deleteSkuCommandHandler := delete_sku.NewCommandHandler(skuRepository)
+	deleteSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	idempotencyStore, err := newIdempotencyStore(ctx, cfg, db)
+	if err != nil {
+		return nil, err
+	}
+	commandBus := bus.New(bus.Tracing(), bus.Idempotency(idempotencyStore, cfg.idempotencyTTL, domain.OutcomeErrors...), bus.Timeout(cfg.commandTimeout))
+	err = registerSkuCommandHandlers(commandBus, map[string]bus.Handler{
+		create_sku.CommandName:     create_sku.BusHandler(createSkuCommandHandler),
+		update_sku.CommandName:     update_sku.BusHandler(updateSkuCommandHandler),
+		upsert_sku.CommandName:     upsert_sku.BusHandler(upsertSkuCommandHandler),
+		deactivate_sku.CommandName: deactivate_sku.BusHandler(deactivateSkuCommandHandler),
+		reactivate_sku.CommandName: reactivate_sku.BusHandler(reactivateSkuCommandHandler),
+		delete_sku.CommandName:     delete_sku.BusHandler(deleteSkuCommandHandler),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runRepository, err := mongoRun.NewRunRepository(db, runDomain.NewHydrator())
+	if err != nil {
+		return nil, err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
 
-	logFile, err := os.OpenFile(cfg.logFileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	logFile, err := openLogFile(cfg.logFileName)
 	if err != nil {
 		return nil, err
 	}
 	logger := log.New(logFile, "", log.Lmsgprefix)
 
-	return server.New(skuReader, createSkuCommandHandler, logger), nil
+	serverOptions := []server.Option{
+		server.WithGracePeriod(cfg.gracePeriod),
+		server.WithTenantQuotas(cfg.tenantQuotas()),
+	}
+	if cfg.dryRun {
+		serverOptions = append(serverOptions, server.WithDryRun())
+	}
+	serverTCP := server.New(skuReader, commandBus, logger, serverOptions...)
+
+	healthHandler := health.NewHandler(time.Second)
+	healthHandler.AddReadinessCheck("listener", skuReader.CheckListening)
+	healthHandler.AddReadinessCheck("mongo", func(ctx context.Context) error {
+		return mongoClient.Ping(ctx, nil)
+	})
+	healthHandler.AddReadinessCheck("connection_slots", func(_ context.Context) error {
+		if skuReader.OpenConnections() >= serverTCP.MaxConnections() {
+			return errConnectionSlotsExhausted
+		}
+		return nil
+	})
+	healthHandler.AddReadinessCheck("server", serverTCP.CheckAcceptingConnections)
+
+	return &application{
+		cfg:                         cfg,
+		serverTCP:                   serverTCP,
+		skuReader:                   skuReader,
+		ipFilter:                    ipFilter,
+		rateLimiter:                 rateLimiter,
+		createSkuCommandHandler:     createSkuCommandHandler,
+		updateSkuCommandHandler:     updateSkuCommandHandler,
+		upsertSkuCommandHandler:     upsertSkuCommandHandler,
+		deactivateSkuCommandHandler: deactivateSkuCommandHandler,
+		reactivateSkuCommandHandler: reactivateSkuCommandHandler,
+		deleteSkuCommandHandler:     deleteSkuCommandHandler,
+		recordRunCommandHandler:     record_run.NewCommandHandler(runRepository),
+		runId:                       string(runDomain.NewRunId()),
+		startedAt:                   time.Now(),
+		host:                        host,
+		configHash:                  cfg.hash(),
+		logger:                      logger,
+		logFile:                     logFile,
+		mongoClient:                 mongoClient,
+		migrationRunner:             migrationRunner,
+		tracerProvider:              tracerProvider,
+		healthServer:                &http.Server{Addr: cfg.healthAddr, Handler: healthHandler.ServeMux()},
+	}, nil
+}
+
+// installTracerProvider makes the tracer provider of the exporter the global one, so every package records its spans
+// with it, and propagates the W3C trace context. It returns nil when the tracing is disabled.
+func installTracerProvider(ctx context.Context, exporter string) (*sdktrace.TracerProvider, error) {
+	if exporter == tracing.ExporterNone {
+		return nil, nil
+	}
+	tracerProvider, err := tracing.NewTracerProvider(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tracerProvider, nil
+}
+
+// newSkuRepository returns the mongo sku repository, only read from in a dry run.
+func newSkuRepository(cfg *config, db *mongo.Database) (domain.SkuRepository, error) {
+	skuRepository, err := mongoSku.NewSkuRepository(db, domain.NewHydrator())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.dryRun {
+		return dryrun.NewSkuRepository(skuRepository, domain.NewHydrator()), nil
+	}
+
+	return skuRepository, nil
+}
+
+// newIdempotencyStore returns the configured idempotency store, a dry run keeps the results in memory whatever the
+// store as they're the results of skus that were not stored.
+func newIdempotencyStore(ctx context.Context, cfg *config, db *mongo.Database) (bus.IdempotencyStore, error) {
+	if cfg.idempotencyStore != idempotencyStoreMongo || cfg.dryRun {
+		return memory.NewIdempotencyStore(), nil
+	}
+
+	return mongoSku.NewIdempotencyStore(ctx, db)
+}
+
+func newMigrationRunner(db *mongo.Database) (*migration.Runner, error) {
+	migrationStore, err := mongoSku.NewMigrationStore(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := mongoSku.Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return migration.NewRunner(migrationStore, migrations)
+}
+
+// migrate applies the pending migrations of the collections while the server is listening, as a backfill can
+// last longer than the startup is allowed to. The server works with the collections as they are until then, and
+// a migration that fails is applied again on the next startup. When the migrations on startup are disabled, or in
+// a dry run, the pending ones are only logged, so they're applied by the migrate command.
+func (a *application) migrate(ctx context.Context) {
+	cfg := a.currentConfig()
+	if !cfg.migrateOnStartup || cfg.dryRun {
+		pending, err := a.migrationRunner.Pending(ctx)
+		if err != nil {
+			log.Printf("error listing the pending migrations: %v", err)
+			return
+		}
+		if len(pending) > 0 {
+			log.Printf("%d pending migrations, the migrate command applies them", len(pending))
+		}
+		return
+	}
+
+	records, err := a.migrationRunner.Run(ctx)
+	for _, record := range records {
+		log.Printf("applied migration %d %s", record.Version, record.Description)
+	}
+	if err != nil {
+		log.Printf("error applying the migrations: %v", err)
+	}
+}
+
+func registerSkuCommandHandlers(commandBus *bus.Bus, handlers map[string]bus.Handler) error {
+	for commandName, handler := range handlers {
+		err := commandBus.Register(commandName, handler)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func openLogFile(fileName string) (*os.File, error) {
+	return os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 }
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"feeder-service/internal/sku/infrastructure/io/ipfilter"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+)
+
+// reloadOnHangup reloads the config every time a SIGHUP is received until the context is done.
+func (a *application) reloadOnHangup(ctx context.Context) {
+	sigChannel := make(chan os.Signal, 1)
+	signal.Notify(sigChannel, syscall.SIGHUP)
+	defer signal.Stop(sigChannel)
+	for {
+		select {
+		case <-sigChannel:
+			a.reload()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reload applies the settings that can change without dropping the connections in progress and logs the ones
+// that need a restart. When the new config is not valid nothing is applied.
+func (a *application) reload() {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Printf("error reloading config, keeping the current one: %v", err)
+		return
+	}
+
+	// reload is the only writer of the config, so it reads it once and replaces it at the end
+	current := a.currentConfig()
+	var applied, failed []string
+	apply := func(setting string, err error) {
+		if err != nil {
+			log.Printf("error applying %s: %v", setting, err)
+			failed = append(failed, setting)
+			return
+		}
+		applied = append(applied, setting)
+	}
+
+	if cfg.maxConcurrentConnections != current.maxConcurrentConnections {
+		apply("max_concurrent_connections", a.serverTCP.SetMaxConnections(cfg.maxConcurrentConnections))
+	}
+	if cfg.skuIdPattern != current.skuIdPattern || !reflect.DeepEqual(cfg.tenants, current.tenants) {
+		apply("sku_format", a.applySkuIdFormats(cfg))
+	}
+	if !reflect.DeepEqual(cfg.tenantQuotas(), current.tenantQuotas()) {
+		a.serverTCP.SetTenantQuotas(cfg.tenantQuotas())
+		apply("tenant_quotas", nil)
+	}
+	if cfg.ipFilterFileName != "" || current.ipFilterFileName != "" {
+		apply("ip_filter", a.applyIPFilterFile(cfg.ipFilterFileName))
+	}
+	if !reflect.DeepEqual(cfg.rateLimit, current.rateLimit) {
+		a.rateLimiter.Update(cfg.rateLimit)
+		apply("rate_limit", nil)
+	}
+	if cfg.logFileName != current.logFileName {
+		apply("log_file_name", a.applyLogFileName(cfg.logFileName))
+	}
+	if cfg.reportFormat != current.reportFormat || cfg.reportDestination != current.reportDestination {
+		// the report is only written when the run finishes, so the new values just have to be kept
+		apply("report", nil)
+	}
+
+	requireRestart := restartRequiredSettings(current, cfg)
+	a.mutex.Lock()
+	a.cfg = current.withLiveSettingsFrom(cfg, failed)
+	a.mutex.Unlock()
+
+	log.Printf("config reloaded, applied: [%s], failed: [%s], require a restart: [%s]",
+		strings.Join(applied, ", "), strings.Join(failed, ", "), strings.Join(requireRestart, ", "))
+}
+
+// applySkuIdFormats replaces the default sku format and the ones of the tenants.
+func (a *application) applySkuIdFormats(cfg *config) error {
+	skuIdFormats, err := cfg.skuIdFormats()
+	if err != nil {
+		return err
+	}
+	a.createSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	a.updateSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	a.upsertSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	a.deactivateSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	a.reactivateSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+	a.deleteSkuCommandHandler.SetSkuIdFormats(skuIdFormats)
+
+	return nil
+}
+
+func (a *application) applyIPFilterFile(fileName string) error {
+	if fileName == "" {
+		return a.ipFilter.Reload(ipfilter.Rules{})
+	}
+
+	return reloadIPFilter(a.ipFilter, fileName)
+}
+
+// applyLogFileName moves the created skus log to another file, the previous one is closed once replaced.
+func (a *application) applyLogFileName(fileName string) error {
+	logFile, err := openLogFile(fileName)
+	if err != nil {
+		return err
+	}
+	a.logger.SetOutput(logFile)
+	a.mutex.Lock()
+	previousLogFile := a.logFile
+	a.logFile = logFile
+	a.mutex.Unlock()
+
+	return previousLogFile.Close()
+}
+
+func reloadIPFilter(ipFilter *ipfilter.Filter, fileName string) error {
+	if fileName == "" {
+		return nil
+	}
+	rules, err := ipfilter.ParseRulesFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	return ipFilter.Reload(rules)
+}
+
+// restartRequiredSettings returns the settings that changed but can't be applied while running.
+func restartRequiredSettings(current, reloaded *config) []string {
+	var settings []string
+	if current.socketAddr != reloaded.socketAddr {
+		settings = append(settings, "socket_addr")
+	}
+	if current.healthAddr != reloaded.healthAddr {
+		settings = append(settings, "health_addr")
+	}
+	if current.mongoUri != reloaded.mongoUri {
+		settings = append(settings, "mongo_uri")
+	}
+	if current.mongoDatabase != reloaded.mongoDatabase {
+		settings = append(settings, "mongo_database")
+	}
+	if current.timeout != reloaded.timeout {
+		settings = append(settings, "timeout_in_secs")
+	}
+	if current.gracePeriod != reloaded.gracePeriod {
+		settings = append(settings, "grace_period_in_secs")
+	}
+	if current.commandTimeout != reloaded.commandTimeout {
+		settings = append(settings, "command_timeout_in_secs")
+	}
+	if current.idempotencyStore != reloaded.idempotencyStore || current.idempotencyTTL != reloaded.idempotencyTTL {
+		settings = append(settings, "idempotency")
+	}
+	if current.tracingExporter != reloaded.tracingExporter {
+		settings = append(settings, "tracing_exporter")
+	}
+	if current.readLimits != reloaded.readLimits {
+		settings = append(settings, "read_limits")
+	}
+	if current.runSnapshotInterval != reloaded.runSnapshotInterval {
+		settings = append(settings, "run_snapshot_interval_in_secs")
+	}
+	if current.dryRun != reloaded.dryRun {
+		settings = append(settings, "dry_run")
+	}
+	if current.migrateOnStartup != reloaded.migrateOnStartup {
+		settings = append(settings, "migrate_on_startup")
+	}
+
+	return settings
+}
+
+// withLiveSettingsFrom returns a copy of the config with the settings that were applied live from the reloaded one,
+// so the ones that failed or require a restart are compared again against the running values in the next reload.
+func (c *config) withLiveSettingsFrom(reloaded *config, failed []string) *config {
+	updated := *c
+	isFailed := func(setting string) bool {
+		for _, failedSetting := range failed {
+			if failedSetting == setting {
+				return true
+			}
+		}
+		return false
+	}
+	if !isFailed("max_concurrent_connections") {
+		updated.maxConcurrentConnections = reloaded.maxConcurrentConnections
+	}
+	if !isFailed("sku_format") {
+		updated.skuIdPattern = reloaded.skuIdPattern
+		updated.tenants = reloaded.tenants
+	}
+	if !isFailed("ip_filter") {
+		updated.ipFilterFileName = reloaded.ipFilterFileName
+	}
+	if !isFailed("log_file_name") {
+		updated.logFileName = reloaded.logFileName
+	}
+	updated.rateLimit = reloaded.rateLimit
+	updated.reportFormat = reloaded.reportFormat
+	updated.reportDestination = reloaded.reportDestination
+
+	return &updated
+}
@@ -0,0 +1,99 @@
+//+build unit
+
+package main
+
+import (
+	"context"
+	"feeder-service/internal/run/application/command/record_run"
+	"feeder-service/internal/run/domain/mock"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type ReloadUnitSuite struct {
+	suite.Suite
+	dir            string
+	configFileName string
+	mockCtrl       *gomock.Controller
+}
+
+func (s *ReloadUnitSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.configFileName = filepath.Join(s.dir, "config.json")
+	s.Require().NoError(os.Setenv("CONFIG_FILE", s.configFileName))
+	s.mockCtrl = gomock.NewController(s.T())
+}
+
+func (s *ReloadUnitSuite) TearDownTest() {
+	s.Require().NoError(os.Unsetenv("CONFIG_FILE"))
+	s.mockCtrl.Finish()
+}
+
+func TestReloadUnitSuite(t *testing.T) {
+	suite.Run(t, new(ReloadUnitSuite))
+}
+
+// TestTheConfigIsReloadedOnSIGHUPWhileTheRunsAreRecorded is meant to be run with -race too.
+func (s *ReloadUnitSuite) TestTheConfigIsReloadedOnSIGHUPWhileTheRunsAreRecorded() {
+	// the signals are caught before the application listens to them, so they never kill the test
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	s.writeConfigFile(0)
+	cfg, err := loadConfig()
+	s.Require().NoError(err)
+	logFile, err := openLogFile(cfg.logFileName)
+	s.Require().NoError(err)
+	runRepositoryMock := mock.NewMockRunRepository(s.mockCtrl)
+	runRepositoryMock.EXPECT().Save(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	app := &application{
+		cfg:                     cfg,
+		logger:                  log.New(logFile, "", 0),
+		logFile:                 logFile,
+		recordRunCommandHandler: record_run.NewCommandHandler(runRepositoryMock),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		app.reloadOnHangup(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			app.recordRun(map[string]int{"created_skus": 1}, true)
+		}
+	}()
+
+	for i := 1; i <= 3; i++ {
+		s.writeConfigFile(i)
+		expected := filepath.Join(s.dir, fmt.Sprintf("log_%d.txt", i))
+		s.Require().Eventually(func() bool {
+			s.Require().NoError(syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+			return app.currentConfig().logFileName == expected
+		}, 5*time.Second, 20*time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+	s.Require().NoError(app.closeLogFile())
+}
+
+// writeConfigFile writes a config whose log file and report format change with the version.
+func (s *ReloadUnitSuite) writeConfigFile(version int) {
+	reportFormats := []string{"text", "json"}
+	content := fmt.Sprintf(`{"log_file_name": %q, "report_format": %q}`,
+		filepath.Join(s.dir, fmt.Sprintf("log_%d.txt", version)), reportFormats[version%2])
+	s.Require().NoError(os.WriteFile(s.configFileName, []byte(content), 0600))
+}
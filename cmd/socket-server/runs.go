@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"feeder-service/internal/run/application/command/record_run"
+	"log"
+	"time"
+)
+
+// snapshotRuns stores the report so far in the run history every run snapshot interval, until the context is done.
+func (a *application) snapshotRuns(ctx context.Context) {
+	interval := a.currentConfig().runSnapshotInterval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.recordRun(a.serverTCP.Snapshot().Counters(), true)
+		}
+	}
+}
+
+// recordRun stores a run in the run history, a failure is only logged as it must not stop the server. Every
+// record of the run has its id, so the snapshots are linked to the final one. The config hash is the one of the
+// config the run started with. A dry run is not recorded as it stores nothing.
+func (a *application) recordRun(counters map[string]int, snapshot bool) {
+	if a.currentConfig().dryRun {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := a.recordRunCommandHandler.Handle(ctx, record_run.Command{
+		RunId:      a.runId,
+		StartedAt:  a.startedAt,
+		EndedAt:    time.Now(),
+		Host:       a.host,
+		ConfigHash: a.configHash,
+		Snapshot:   snapshot,
+		Counters:   counters,
+	})
+	if err != nil {
+		log.Printf("error recording run: %v", err)
+	}
+}
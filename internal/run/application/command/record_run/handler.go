@@ -0,0 +1,57 @@
+package record_run
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/run/domain"
+	"fmt"
+	"time"
+)
+
+// Command records a run, or a snapshot of it when Snapshot is set. RunId is the id of the run, it's generated
+// when empty, and the snapshots are stored with their own id linked to it.
+type Command struct {
+	RunId      string
+	StartedAt  time.Time
+	EndedAt    time.Time
+	Host       string
+	ConfigHash string
+	Snapshot   bool
+	Counters   map[string]int
+}
+
+type CommandHandler struct {
+	repository domain.RunRepository
+}
+
+func NewCommandHandler(repository domain.RunRepository) *CommandHandler {
+	return &CommandHandler{repository: repository}
+}
+
+var ErrRecordingRun = errors.New("error recording run")
+
+func (h *CommandHandler) Handle(ctx context.Context, command Command) error {
+	id, snapshotOf := domain.RunId(command.RunId), domain.RunId("")
+	if id == "" {
+		id = domain.NewRunId()
+	}
+	if command.Snapshot {
+		id, snapshotOf = domain.NewRunId(), id
+	}
+	run := domain.NewRun(
+		id,
+		command.StartedAt,
+		command.EndedAt,
+		command.Host,
+		command.ConfigHash,
+		snapshotOf,
+		command.Counters,
+	)
+
+	err := h.repository.Save(ctx, run)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRecordingRun, err.Error())
+	}
+
+	return nil
+}
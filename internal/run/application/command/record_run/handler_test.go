@@ -0,0 +1,91 @@
+//+build unit
+
+package record_run_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/run/application/command/record_run"
+	"feeder-service/internal/run/domain"
+	"feeder-service/internal/run/domain/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	repositoryMock *mock.MockRunRepository
+	mockCtrl       *gomock.Controller
+	handler        *record_run.CommandHandler
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockRunRepository(s.mockCtrl)
+	s.handler = record_run.NewCommandHandler(s.repositoryMock)
+}
+
+func (s *UnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestSaveRun() {
+	startedAt := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	command := record_run.Command{
+		RunId:      "run-1",
+		StartedAt:  startedAt,
+		EndedAt:    startedAt.Add(time.Minute),
+		Host:       "feeder-1",
+		ConfigHash: "abc",
+		Counters:   map[string]int{"created_skus": 3},
+	}
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Run{})).Times(1).DoAndReturn(func(_ context.Context, run *domain.Run) error {
+		s.Require().Equal(domain.RunId("run-1"), run.Id())
+		s.Require().Equal(time.Minute, run.Duration())
+		s.Require().Equal("feeder-1", run.Host())
+		s.Require().Equal("abc", run.ConfigHash())
+		s.Require().False(run.Snapshot())
+		s.Require().Equal(map[string]int{"created_skus": 3}, run.Counters())
+		return nil
+	})
+
+	s.Require().NoError(s.handler.Handle(s.ctx, command))
+}
+
+func (s *UnitSuite) TestSaveASnapshotLinkedToItsRun() {
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Run{})).Times(2).DoAndReturn(func(_ context.Context, run *domain.Run) error {
+		s.Require().NotEqual(domain.RunId("run-1"), run.Id())
+		s.Require().True(run.Snapshot())
+		s.Require().Equal(domain.RunId("run-1"), run.SnapshotOf())
+		return nil
+	})
+
+	s.Require().NoError(s.handler.Handle(s.ctx, record_run.Command{RunId: "run-1", Snapshot: true}))
+	s.Require().NoError(s.handler.Handle(s.ctx, record_run.Command{RunId: "run-1", Snapshot: true}))
+}
+
+func (s *UnitSuite) TestSaveARunWithANewIdWhenItHasNone() {
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Run{})).Times(1).DoAndReturn(func(_ context.Context, run *domain.Run) error {
+		s.Require().NotEmpty(run.Id())
+		s.Require().False(run.Snapshot())
+		return nil
+	})
+
+	s.Require().NoError(s.handler.Handle(s.ctx, record_run.Command{}))
+}
+
+func (s *UnitSuite) TestReturnErrRecordingRunWhenCallToRepositorySaveReturnError() {
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.Any()).Times(1).Return(errors.New("repository error"))
+
+	err := s.handler.Handle(s.ctx, record_run.Command{})
+	s.Require().ErrorIs(err, record_run.ErrRecordingRun)
+	s.Require().Equal("error recording run: repository error", err.Error())
+}
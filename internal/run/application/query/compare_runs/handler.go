@@ -0,0 +1,45 @@
+package compare_runs
+
+import (
+	"context"
+	"feeder-service/internal/run/domain"
+	"fmt"
+)
+
+type Query struct {
+	BaseRunId  string
+	OtherRunId string
+}
+
+type QueryHandler struct {
+	repository domain.RunRepository
+}
+
+func NewQueryHandler(repository domain.RunRepository) *QueryHandler {
+	return &QueryHandler{repository: repository}
+}
+
+func (h *QueryHandler) Handle(ctx context.Context, query Query) (domain.Comparison, error) {
+	base, err := h.find(ctx, query.BaseRunId)
+	if err != nil {
+		return domain.Comparison{}, err
+	}
+	other, err := h.find(ctx, query.OtherRunId)
+	if err != nil {
+		return domain.Comparison{}, err
+	}
+
+	return domain.Compare(base, other), nil
+}
+
+func (h *QueryHandler) find(ctx context.Context, runId string) (*domain.Run, error) {
+	run, err := h.repository.Find(ctx, domain.RunId(runId))
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrRunNotFound, runId)
+	}
+
+	return run, nil
+}
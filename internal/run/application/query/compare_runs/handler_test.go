@@ -0,0 +1,81 @@
+//+build unit
+
+package compare_runs_test
+
+import (
+	"context"
+	"feeder-service/internal/run/application/query/compare_runs"
+	"feeder-service/internal/run/domain"
+	"feeder-service/internal/run/domain/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+const (
+	baseRunId  = "base"
+	otherRunId = "other"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	repositoryMock *mock.MockRunRepository
+	mockCtrl       *gomock.Controller
+	handler        *compare_runs.QueryHandler
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockRunRepository(s.mockCtrl)
+	s.handler = compare_runs.NewQueryHandler(s.repositoryMock)
+}
+
+func (s *UnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestCompareEveryCounterOfBothRuns() {
+	base := s.run(baseRunId, "hash", map[string]int{"created_skus": 10, "invalid_skus": 2})
+	other := s.run(otherRunId, "hash", map[string]int{"created_skus": 7, "denied_connections": 1})
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId(baseRunId)).Return(base, nil)
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId(otherRunId)).Return(other, nil)
+
+	comparison, err := s.handler.Handle(s.ctx, compare_runs.Query{BaseRunId: baseRunId, OtherRunId: otherRunId})
+	s.Require().NoError(err)
+	s.Require().True(comparison.SameConfig)
+	s.Require().Equal([]domain.CounterDiff{
+		{Counter: "created_skus", Base: 10, Other: 7},
+		{Counter: "denied_connections", Base: 0, Other: 1},
+		{Counter: "invalid_skus", Base: 2, Other: 0},
+	}, comparison.CounterDiffs)
+	s.Require().Equal(-3, comparison.CounterDiffs[0].Delta())
+}
+
+func (s *UnitSuite) TestComparisonTellsWhenTheConfigChanged() {
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId(baseRunId)).Return(s.run(baseRunId, "hash", nil), nil)
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId(otherRunId)).Return(s.run(otherRunId, "another hash", nil), nil)
+
+	comparison, err := s.handler.Handle(s.ctx, compare_runs.Query{BaseRunId: baseRunId, OtherRunId: otherRunId})
+	s.Require().NoError(err)
+	s.Require().False(comparison.SameConfig)
+}
+
+func (s *UnitSuite) TestReturnErrRunNotFoundWhenARunDoesNotExist() {
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId(baseRunId)).Return(s.run(baseRunId, "hash", nil), nil)
+	s.repositoryMock.EXPECT().Find(s.ctx, domain.RunId(otherRunId)).Return(nil, nil)
+
+	_, err := s.handler.Handle(s.ctx, compare_runs.Query{BaseRunId: baseRunId, OtherRunId: otherRunId})
+	s.Require().ErrorIs(err, domain.ErrRunNotFound)
+}
+
+func (s *UnitSuite) run(id, configHash string, counters map[string]int) *domain.Run {
+	startedAt := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	return domain.NewRun(domain.RunId(id), startedAt, startedAt.Add(time.Hour), "feeder-1", configHash, "", counters)
+}
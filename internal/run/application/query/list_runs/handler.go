@@ -0,0 +1,35 @@
+package list_runs
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/run/domain"
+)
+
+const DefaultLimit = 20
+
+type Query struct {
+	Limit int
+}
+
+type QueryHandler struct {
+	repository domain.RunRepository
+}
+
+func NewQueryHandler(repository domain.RunRepository) *QueryHandler {
+	return &QueryHandler{repository: repository}
+}
+
+var ErrInvalidLimit = errors.New("limit can't be negative")
+
+func (h *QueryHandler) Handle(ctx context.Context, query Query) ([]*domain.Run, error) {
+	if query.Limit < 0 {
+		return nil, ErrInvalidLimit
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	return h.repository.List(ctx, limit)
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "sort"
+
+type CounterDiff struct {
+	Counter string
+	Base    int
+	Other   int
+}
+
+func (d CounterDiff) Delta() int {
+	return d.Other - d.Base
+}
+
+type Comparison struct {
+	Base         *Run
+	Other        *Run
+	SameConfig   bool
+	CounterDiffs []CounterDiff
+}
+
+// Compare returns the value of every counter in any of the runs, sorted by counter name.
+// A counter missing in one of them counts as zero.
+func Compare(base, other *Run) Comparison {
+	names := map[string]struct{}{}
+	for name := range base.counters {
+		names[name] = struct{}{}
+	}
+	for name := range other.counters {
+		names[name] = struct{}{}
+	}
+
+	diffs := make([]CounterDiff, 0, len(names))
+	for name := range names {
+		diffs = append(diffs, CounterDiff{Counter: name, Base: base.counters[name], Other: other.counters[name]})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Counter < diffs[j].Counter
+	})
+
+	return Comparison{
+		Base:         base,
+		Other:        other,
+		SameConfig:   base.configHash == other.configHash,
+		CounterDiffs: diffs,
+	}
+}
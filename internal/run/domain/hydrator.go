@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+type RunDTO struct {
+	ID         string         `bson:"_id"`
+	StartedAt  time.Time      `bson:"started_at"`
+	EndedAt    time.Time      `bson:"ended_at"`
+	Host       string         `bson:"host"`
+	ConfigHash string         `bson:"config_hash"`
+	Snapshot   bool           `bson:"snapshot"`
+	SnapshotOf string         `bson:"snapshot_of,omitempty"`
+	Counters   map[string]int `bson:"counters"`
+}
+
+type Hydrator struct{}
+
+func NewHydrator() *Hydrator {
+	return &Hydrator{}
+}
+
+func (h *Hydrator) Hydrate(dto *RunDTO) *Run {
+	return NewRun(RunId(dto.ID), dto.StartedAt, dto.EndedAt, dto.Host, dto.ConfigHash, RunId(dto.SnapshotOf), dto.Counters)
+}
+
+func (h *Hydrator) Dehydrate(run *Run) *RunDTO {
+	return &RunDTO{
+		ID:         string(run.id),
+		StartedAt:  run.startedAt,
+		EndedAt:    run.endedAt,
+		Host:       run.host,
+		ConfigHash: run.configHash,
+		Snapshot:   run.Snapshot(),
+		SnapshotOf: string(run.snapshotOf),
+		Counters:   run.counters,
+	}
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: feeder-service/internal/run/domain (interfaces: RunRepository)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	domain "feeder-service/internal/run/domain"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRunRepository is a mock of RunRepository interface.
+type MockRunRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRunRepositoryMockRecorder
+}
+
+// MockRunRepositoryMockRecorder is the mock recorder for MockRunRepository.
+type MockRunRepositoryMockRecorder struct {
+	mock *MockRunRepository
+}
+
+// NewMockRunRepository creates a new mock instance.
+func NewMockRunRepository(ctrl *gomock.Controller) *MockRunRepository {
+	mock := &MockRunRepository{ctrl: ctrl}
+	mock.recorder = &MockRunRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRunRepository) EXPECT() *MockRunRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Find mocks base method.
+func (m *MockRunRepository) Find(arg0 context.Context, arg1 domain.RunId) (*domain.Run, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Run)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockRunRepositoryMockRecorder) Find(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockRunRepository)(nil).Find), arg0, arg1)
+}
+
+// List mocks base method.
+func (m *MockRunRepository) List(arg0 context.Context, arg1 int) ([]*domain.Run, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret0, _ := ret[0].([]*domain.Run)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockRunRepositoryMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRunRepository)(nil).List), arg0, arg1)
+}
+
+// Save mocks base method.
+func (m *MockRunRepository) Save(arg0 context.Context, arg1 *domain.Run) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRunRepositoryMockRecorder) Save(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRunRepository)(nil).Save), arg0, arg1)
+}
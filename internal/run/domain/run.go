@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var ErrRunNotFound = errors.New("run not found")
+
+//go:generate mockgen -destination=mock/run_repository_mockgen_mock.go -package=mock . RunRepository
+type RunRepository interface {
+	Save(context.Context, *Run) error
+	Find(context.Context, RunId) (*Run, error)
+	// List returns the latest runs first, at most limit of them.
+	List(ctx context.Context, limit int) ([]*Run, error)
+}
+
+type RunId string
+
+func NewRunId() RunId {
+	randomBytes := make([]byte, 12)
+	_, _ = rand.Read(randomBytes)
+
+	return RunId(hex.EncodeToString(randomBytes))
+}
+
+// Run is the report of a server execution. A snapshot is taken while the server is still running,
+// so its endedAt is the time of the snapshot, and snapshotOf is the id of the run it was taken from.
+type Run struct {
+	id         RunId
+	startedAt  time.Time
+	endedAt    time.Time
+	host       string
+	configHash string
+	snapshotOf RunId
+	counters   map[string]int
+}
+
+func NewRun(id RunId, startedAt, endedAt time.Time, host, configHash string, snapshotOf RunId, counters map[string]int) *Run {
+	return &Run{
+		id:         id,
+		startedAt:  startedAt,
+		endedAt:    endedAt,
+		host:       host,
+		configHash: configHash,
+		snapshotOf: snapshotOf,
+		counters:   counters,
+	}
+}
+
+func (r Run) Id() RunId {
+	return r.id
+}
+
+func (r Run) StartedAt() time.Time {
+	return r.startedAt
+}
+
+func (r Run) EndedAt() time.Time {
+	return r.endedAt
+}
+
+func (r Run) Duration() time.Duration {
+	return r.endedAt.Sub(r.startedAt)
+}
+
+func (r Run) Host() string {
+	return r.host
+}
+
+func (r Run) ConfigHash() string {
+	return r.configHash
+}
+
+func (r Run) Snapshot() bool {
+	return r.snapshotOf != ""
+}
+
+func (r Run) SnapshotOf() RunId {
+	return r.snapshotOf
+}
+
+func (r Run) Counters() map[string]int {
+	return r.counters
+}
@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/run/domain"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "run"
+
+type RunRepository struct {
+	collection *mongo.Collection
+	hydrator   *domain.Hydrator
+}
+
+var ErrMongoDBNil = fmt.Errorf("mongoDB is not defined")
+
+func NewRunRepository(db *mongo.Database, hydrator *domain.Hydrator) (*RunRepository, error) {
+	if db == nil {
+		return nil, ErrMongoDBNil
+	}
+	return &RunRepository{collection: db.Collection(collectionName), hydrator: hydrator}, nil
+}
+
+var ErrSave = fmt.Errorf("error during save execution")
+
+func (r *RunRepository) Save(ctx context.Context, run *domain.Run) error {
+	_, err := r.collection.InsertOne(ctx, r.hydrator.Dehydrate(run))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrSave, err.Error())
+	}
+
+	return nil
+}
+
+var ErrFind = fmt.Errorf("error during find execution")
+
+func (r *RunRepository) Find(ctx context.Context, id domain.RunId) (*domain.Run, error) {
+	var runDTO *domain.RunDTO
+
+	result := r.collection.FindOne(ctx, bson.M{"_id": string(id)})
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, result.Err()
+	}
+
+	err := result.Decode(&runDTO)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFind, err.Error())
+	}
+	return r.hydrator.Hydrate(runDTO), nil
+}
+
+var ErrList = fmt.Errorf("error during list execution")
+
+func (r *RunRepository) List(ctx context.Context, limit int) ([]*domain.Run, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "ended_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrList, err.Error())
+	}
+
+	var runDTOs []*domain.RunDTO
+	err = cursor.All(ctx, &runDTOs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrList, err.Error())
+	}
+
+	runs := make([]*domain.Run, 0, len(runDTOs))
+	for _, runDTO := range runDTOs {
+		runs = append(runs, r.hydrator.Hydrate(runDTO))
+	}
+	return runs, nil
+}
@@ -0,0 +1,80 @@
+//+build integration
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/run/domain"
+	mongo2 "feeder-service/internal/run/infrastructure/persistence/mongo"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"testing"
+	"time"
+)
+
+type IntegrationSuite struct {
+	suite.Suite
+	ctx        context.Context
+	db         *mongo.Database
+	repository *mongo2.RunRepository
+}
+
+func (s *IntegrationSuite) SetupSuite() {
+	s.ctx = context.Background()
+}
+
+func (s *IntegrationSuite) TearDownSuite() {
+	if s.db != nil {
+		err := s.db.Drop(s.ctx)
+		s.Require().NoError(err)
+	}
+}
+
+func TestIntegration(t *testing.T) {
+	suite.Run(t, new(IntegrationSuite))
+}
+
+func (s *IntegrationSuite) TestReturnErrMongoDbNil() {
+	_, err := mongo2.NewRunRepository(nil, domain.NewHydrator())
+	s.Require().Error(err)
+	s.Require().True(errors.Is(err, mongo2.ErrMongoDBNil))
+}
+
+func (s *IntegrationSuite) TestSaveFindAndListTheLatestRunsFirst() {
+	s.initMongoDatabase()
+	var err error
+	s.repository, err = mongo2.NewRunRepository(s.db, domain.NewHydrator())
+	s.Require().NoError(err)
+
+	startedAt := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	latestRun := domain.NewRun(domain.NewRunId(), startedAt, startedAt.Add(time.Hour), "feeder-1", "hash", "", map[string]int{"created_skus": 5})
+	olderRun := domain.NewRun(domain.NewRunId(), startedAt, startedAt.Add(time.Minute), "feeder-1", "hash", latestRun.Id(), map[string]int{"created_skus": 1})
+	s.Require().NoError(s.repository.Save(s.ctx, olderRun))
+	s.Require().NoError(s.repository.Save(s.ctx, latestRun))
+
+	runFromRepository, err := s.repository.Find(s.ctx, latestRun.Id())
+	s.Require().NoError(err)
+	s.Require().Equal(latestRun.Counters(), runFromRepository.Counters())
+	s.Require().True(latestRun.EndedAt().Equal(runFromRepository.EndedAt()))
+
+	snapshotFromRepository, err := s.repository.Find(s.ctx, olderRun.Id())
+	s.Require().NoError(err)
+	s.Require().True(snapshotFromRepository.Snapshot())
+	s.Require().Equal(latestRun.Id(), snapshotFromRepository.SnapshotOf())
+
+	runs, err := s.repository.List(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Len(runs, 1)
+	s.Require().Equal(latestRun.Id(), runs[0].Id())
+}
+
+func (s *IntegrationSuite) initMongoDatabase() {
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	s.Require().NoError(err)
+	err = mongoClient.Connect(s.ctx)
+	s.Require().NoError(err)
+
+	s.db = mongoClient.Database("run_integration_test")
+}
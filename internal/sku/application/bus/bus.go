@@ -0,0 +1,66 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Command is routed by its name to the handler registered for it.
+type Command interface {
+	CommandName() string
+}
+
+// Handler executes a command and returns its result, which is nil for the commands without result besides the error.
+type Handler func(ctx context.Context, command Command) (interface{}, error)
+
+// Middleware wraps the handler of every command, e.g. to log, measure, validate or limit the commands.
+type Middleware func(next Handler) Handler
+
+// Dispatcher is what the transports need from the bus.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, command Command) (interface{}, error)
+}
+
+// Bus routes every command to the handler registered with its name, through the middlewares of the bus.
+// The first middleware is the outermost one, so it sees the commands before the rest of them.
+type Bus struct {
+	middlewares []Middleware
+	mutex       sync.RWMutex
+	handlers    map[string]Handler
+}
+
+func New(middlewares ...Middleware) *Bus {
+	return &Bus{middlewares: middlewares, handlers: map[string]Handler{}}
+}
+
+var ErrHandlerAlreadyRegistered = errors.New("command handler already registered")
+
+// Register routes the commands with the name to the handler wrapped in the middlewares of the bus.
+func (b *Bus) Register(commandName string, handler Handler) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.handlers[commandName]; ok {
+		return fmt.Errorf("%w: %s", ErrHandlerAlreadyRegistered, commandName)
+	}
+	for i := len(b.middlewares) - 1; i >= 0; i-- {
+		handler = b.middlewares[i](handler)
+	}
+	b.handlers[commandName] = handler
+
+	return nil
+}
+
+var ErrNoHandler = errors.New("no command handler registered")
+
+func (b *Bus) Dispatch(ctx context.Context, command Command) (interface{}, error) {
+	b.mutex.RLock()
+	handler, ok := b.handlers[command.CommandName()]
+	b.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoHandler, command.CommandName())
+	}
+
+	return handler(ctx, command)
+}
@@ -0,0 +1,152 @@
+//+build unit
+
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCommand struct {
+	name string
+}
+
+func (c testCommand) CommandName() string {
+	return c.name
+}
+
+type UnitSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestCommandsAreRoutedByNameToTheirHandler() {
+	commandBus := bus.New()
+	s.Require().NoError(commandBus.Register("create", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return "created", nil
+	}))
+	errUpdating := errors.New("error updating")
+	s.Require().NoError(commandBus.Register("update", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, errUpdating
+	}))
+
+	result, err := commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().NoError(err)
+	s.Require().Equal("created", result)
+	_, err = commandBus.Dispatch(s.ctx, testCommand{name: "update"})
+	s.Require().ErrorIs(err, errUpdating)
+	_, err = commandBus.Dispatch(s.ctx, testCommand{name: "delete"})
+	s.Require().ErrorIs(err, bus.ErrNoHandler)
+}
+
+func (s *UnitSuite) TestAHandlerCantBeRegisteredTwiceForTheSameCommand() {
+	commandBus := bus.New()
+	handler := func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, nil
+	}
+	s.Require().NoError(commandBus.Register("create", handler))
+
+	s.Require().ErrorIs(commandBus.Register("create", handler), bus.ErrHandlerAlreadyRegistered)
+}
+
+func (s *UnitSuite) TestMiddlewaresWrapTheHandlersInOrder() {
+	var calls []string
+	middleware := func(name string) bus.Middleware {
+		return func(next bus.Handler) bus.Handler {
+			return func(ctx context.Context, command bus.Command) (interface{}, error) {
+				calls = append(calls, name+" before")
+				result, err := next(ctx, command)
+				calls = append(calls, name+" after")
+				return result, err
+			}
+		}
+	}
+	commandBus := bus.New(middleware("first"), middleware("second"))
+	s.Require().NoError(commandBus.Register("create", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		calls = append(calls, "handler")
+		return nil, nil
+	}))
+
+	_, err := commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"first before", "second before", "handler", "second after", "first after"}, calls)
+}
+
+func (s *UnitSuite) TestTimeoutCancelsTheCommandsThatTakeTooLong() {
+	commandBus := bus.New(bus.Timeout(10 * time.Millisecond))
+	s.Require().NoError(commandBus.Register("create", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+
+	_, err := commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, context.DeadlineExceeded)
+}
+
+func (s *UnitSuite) TestZeroTimeoutLeavesTheCommandsUnlimited() {
+	commandBus := bus.New(bus.Timeout(0))
+	s.Require().NoError(commandBus.Register("create", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		_, hasDeadline := ctx.Deadline()
+		return hasDeadline, nil
+	}))
+
+	hasDeadline, err := commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().NoError(err)
+	s.Require().Equal(false, hasDeadline)
+}
+
+func (s *UnitSuite) TestLoggingLogsTheCommandsAndTheirErrors() {
+	loggerBuffer := &strings.Builder{}
+	commandBus := bus.New(bus.Logging(log.New(loggerBuffer, "", 0)))
+	s.Require().NoError(commandBus.Register("create", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, nil
+	}))
+	s.Require().NoError(commandBus.Register("update", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, errors.New("sku not found")
+	}))
+
+	_, _ = commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	_, _ = commandBus.Dispatch(s.ctx, testCommand{name: "update"})
+	lines := strings.Split(strings.TrimSuffix(loggerBuffer.String(), "\n"), "\n")
+	s.Require().Len(lines, 2)
+	s.Require().True(strings.HasPrefix(lines[0], "create done in "))
+	s.Require().True(strings.HasPrefix(lines[1], "update failed in "))
+	s.Require().True(strings.HasSuffix(lines[1], ": sku not found"))
+}
+
+func (s *UnitSuite) TestTracingRecordsASpanForEveryCommand() {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(tracerProvider)
+	commandBus := bus.New(bus.Tracing())
+	s.Require().NoError(commandBus.Register("create_sku", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, errors.New("error creating sku")
+	}))
+	ctx, parent := tracerProvider.Tracer("test").Start(s.ctx, "sku_reader.Read")
+
+	_, _ = commandBus.Dispatch(ctx, testCommand{name: "create_sku"})
+	parent.End()
+	spans := exporter.GetSpans()
+	s.Require().Len(spans, 2)
+	s.Require().Equal("create_sku.Handle", spans[0].Name)
+	s.Require().Equal(parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	s.Require().Equal(codes.Error, spans[0].Status.Code)
+}
@@ -0,0 +1,192 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Outcome is what a command executed with an idempotency key returned: its result and, when it failed with one of
+// the outcome errors of the Idempotency middleware, that error (Err) and the message of the whole error (Message).
+// Both are empty when the command succeeded.
+type Outcome struct {
+	Result  interface{}
+	Err     string
+	Message string
+}
+
+// IdempotencyStore keeps the outcome of the commands executed with an idempotency key until their ttl passes.
+//go:generate mockgen -destination=mock/idempotency_store_mockgen_mock.go -package=mock . IdempotencyStore
+type IdempotencyStore interface {
+	// Reserve stores the key without outcome for the ttl unless it's already stored, in a single atomic operation,
+	// so only one of the commands dispatched at once with the key reserves it. When the key is already stored it
+	// returns the outcome stored with it, which is nil while the command that reserved it is still executing.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (reserved bool, outcome *Outcome, err error)
+	// Complete stores the outcome of the command of a reserved key for the ttl.
+	Complete(ctx context.Context, key string, outcome Outcome, ttl time.Duration) error
+	// Release removes a reserved key without outcome, so the next command dispatched with it is executed.
+	Release(ctx context.Context, key string) error
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context that makes the Idempotency middleware execute the command only once for the key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key of the context, it's empty when there's none.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+var (
+	// ErrReplayed is what every ReplayError is, see ReplayError.
+	ErrReplayed         = errors.New("command already executed with the idempotency key")
+	ErrIdempotencyStore = errors.New("error storing the idempotency key")
+	// ErrCommandInProgress is returned when the command executed with the same idempotency key doesn't finish
+	// before the context of its replay is done.
+	ErrCommandInProgress = errors.New("command still executing with the idempotency key")
+)
+
+// ReplayError is returned, together with the result of the original command, when a command is dispatched again
+// with the idempotency key of a command that was already executed. It unwraps to the error the original command
+// failed with, so the replay has the same outcome, and it's ErrReplayed, so it's not counted twice.
+type ReplayError struct {
+	Key string
+	// Err is the error of the original command, nil when it succeeded.
+	Err error
+}
+
+func (e *ReplayError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", ErrReplayed.Error(), e.Key)
+	}
+
+	return fmt.Sprintf("%s %s: %s", ErrReplayed.Error(), e.Key, e.Err.Error())
+}
+
+func (e *ReplayError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ReplayError) Is(target error) bool {
+	return target == ErrReplayed
+}
+
+// outcomeError is the error of a replayed outcome: it has the message of the original error and it is the outcome
+// error the original one was.
+type outcomeError struct {
+	err     error
+	message string
+}
+
+func (e *outcomeError) Error() string {
+	return e.message
+}
+
+func (e *outcomeError) Unwrap() error {
+	return e.err
+}
+
+const (
+	// reservationTTL bounds how long a key stays reserved by a command that never completes it, e.g. because its
+	// instance crashed, after it the next command dispatched with the key is executed.
+	reservationTTL = time.Minute
+	// reservationPollInterval is the pause between the checks of a key reserved by a command still executing.
+	reservationPollInterval = 50 * time.Millisecond
+)
+
+// Idempotency executes the commands dispatched with an idempotency key only once while the key is stored: the
+// first one reserves the key and the ones dispatched with it later, or at the same time, wait for its outcome and
+// return it in a ReplayError instead of executing again. The outcome of a command that succeeds, or that fails with
+// any of the outcomeErrors (e.g. a duplicated sku), is stored; the rest of the errors are failures that release the
+// key, so the retries of the command execute it again.
+func Idempotency(store IdempotencyStore, ttl time.Duration, outcomeErrors ...error) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			key := IdempotencyKey(ctx)
+			if key == "" {
+				return next(ctx, command)
+			}
+
+			outcome, err := reserve(ctx, store, key)
+			if err != nil {
+				return nil, err
+			}
+			if outcome != nil {
+				return outcome.Result, &ReplayError{Key: key, Err: replayedError(*outcome, outcomeErrors)}
+			}
+
+			result, err := next(ctx, command)
+			outcomeErr := matchingError(err, outcomeErrors)
+			if err != nil && outcomeErr == nil {
+				// a key that can't be released is reserved until its reservation ttl passes
+				_ = store.Release(ctx, key)
+				return result, err
+			}
+
+			outcome = &Outcome{Result: result}
+			if err != nil {
+				outcome.Err, outcome.Message = outcomeErr.Error(), err.Error()
+			}
+			storeErr := store.Complete(ctx, key, *outcome, ttl)
+			if storeErr != nil {
+				return result, fmt.Errorf("%w %s: %s", ErrIdempotencyStore, key, storeErr.Error())
+			}
+
+			return result, err
+		}
+	}
+}
+
+// reserve returns nil once the key is reserved, or the outcome stored with it once the command that reserved it
+// completes it. The key is reserved again when that command releases it or its reservation ttl passes.
+func reserve(ctx context.Context, store IdempotencyStore, key string) (*Outcome, error) {
+	for {
+		reserved, outcome, err := store.Reserve(ctx, key, reservationTTL)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %s", ErrIdempotencyStore, key, err.Error())
+		}
+		if reserved || outcome != nil {
+			return outcome, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w %s: %s", ErrCommandInProgress, key, ctx.Err().Error())
+		case <-time.After(reservationPollInterval):
+		}
+	}
+}
+
+// matchingError returns the outcome error the error is, nil when it's none of them.
+func matchingError(err error, outcomeErrors []error) error {
+	if err == nil {
+		return nil
+	}
+	for _, outcomeErr := range outcomeErrors {
+		if errors.Is(err, outcomeErr) {
+			return outcomeErr
+		}
+	}
+
+	return nil
+}
+
+// replayedError returns the error of a stored outcome, nil when its command succeeded. An error that's not one of
+// the outcomeErrors anymore keeps its message.
+func replayedError(outcome Outcome, outcomeErrors []error) error {
+	if outcome.Err == "" {
+		return nil
+	}
+	for _, outcomeErr := range outcomeErrors {
+		if outcomeErr.Error() == outcome.Err {
+			return &outcomeError{err: outcomeErr, message: outcome.Message}
+		}
+	}
+
+	return errors.New(outcome.Message)
+}
@@ -0,0 +1,174 @@
+//+build unit
+
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/bus/mock"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const ttl = time.Hour
+
+var errSkuAlreadyExists = errors.New("sku already exists")
+
+type IdempotencyUnitSuite struct {
+	suite.Suite
+	ctx        context.Context
+	mockCtrl   *gomock.Controller
+	storeMock  *mock.MockIdempotencyStore
+	commandBus *bus.Bus
+	executions int32
+	err        error
+	// executing, when it's not nil, blocks the executions until it's closed.
+	executing chan struct{}
+}
+
+func (s *IdempotencyUnitSuite) SetupTest() {
+	s.ctx = bus.WithIdempotencyKey(context.Background(), "key-1")
+	s.mockCtrl = gomock.NewController(s.T())
+	s.storeMock = mock.NewMockIdempotencyStore(s.mockCtrl)
+	s.executions = 0
+	s.err = nil
+	s.executing = nil
+	s.newBus(memory.NewIdempotencyStore())
+}
+
+func (s *IdempotencyUnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestIdempotencyUnitSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyUnitSuite))
+}
+
+func (s *IdempotencyUnitSuite) TestAReplayReturnsTheResultOfTheFirstExecutionWithoutExecutingTheCommand() {
+	result, err := s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().NoError(err)
+	s.Require().Equal(true, result)
+
+	result, err = s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, bus.ErrReplayed)
+	var replay *bus.ReplayError
+	s.Require().ErrorAs(err, &replay)
+	s.Require().NoError(replay.Err)
+	s.Require().Equal(true, result)
+	s.Require().Equal(int32(1), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) TestAReplayReturnsTheOutcomeErrorOfTheFirstExecution() {
+	s.err = fmt.Errorf("%w: ABCD-1234", errSkuAlreadyExists)
+
+	_, err := s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, errSkuAlreadyExists)
+	s.Require().NotErrorIs(err, bus.ErrReplayed)
+
+	_, err = s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, bus.ErrReplayed)
+	s.Require().ErrorIs(err, errSkuAlreadyExists)
+	s.Require().Equal("command already executed with the idempotency key key-1: sku already exists: ABCD-1234", err.Error())
+	s.Require().Equal(int32(1), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) TestAFailedCommandIsExecutedAgainByItsRetries() {
+	s.err = errors.New("connection refused")
+
+	_, err := s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, s.err)
+	s.err = nil
+	_, err = s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().NoError(err)
+	s.Require().Equal(int32(2), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) TestTheCommandsDispatchedAtOnceWithTheSameKeyAreExecutedOnce() {
+	s.executing = make(chan struct{})
+	results := make(chan error, 3)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+			results <- err
+		}()
+	}
+	s.Require().Eventually(func() bool {
+		return atomic.LoadInt32(&s.executions) == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(s.executing)
+	wg.Wait()
+	close(results)
+
+	replays := 0
+	for err := range results {
+		if errors.Is(err, bus.ErrReplayed) {
+			replays++
+			continue
+		}
+		s.Require().NoError(err)
+	}
+	s.Require().Equal(2, replays)
+	s.Require().Equal(int32(1), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) TestAReplayReturnsErrCommandInProgressWhenTheFirstExecutionDoesNotFinishInTime() {
+	s.storeMock.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any()).MinTimes(1).Return(false, nil, nil)
+	s.newBus(s.storeMock)
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Millisecond)
+	defer cancel()
+
+	_, err := s.commandBus.Dispatch(ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, bus.ErrCommandInProgress)
+	s.Require().Equal(int32(0), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) TestTheCommandIsNotExecutedWhenTheStoreFails() {
+	s.storeMock.EXPECT().Reserve(s.ctx, "key-1", gomock.Any()).Return(false, nil, errors.New("connection refused"))
+	s.newBus(s.storeMock)
+
+	_, err := s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, bus.ErrIdempotencyStore)
+	s.Require().Equal(int32(0), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) TestReturnErrIdempotencyStoreWhenTheOutcomeCanNotBeStored() {
+	s.storeMock.EXPECT().Reserve(s.ctx, "key-1", gomock.Any()).Return(true, nil, nil)
+	s.storeMock.EXPECT().Complete(s.ctx, "key-1", bus.Outcome{Result: true}, ttl).Return(errors.New("connection refused"))
+	s.newBus(s.storeMock)
+
+	result, err := s.commandBus.Dispatch(s.ctx, testCommand{name: "create"})
+	s.Require().ErrorIs(err, bus.ErrIdempotencyStore)
+	s.Require().Equal(true, result)
+	s.Require().Equal(int32(1), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) TestTheCommandsWithoutKeyAreAlwaysExecuted() {
+	_, err := s.commandBus.Dispatch(context.Background(), testCommand{name: "create"})
+	s.Require().NoError(err)
+	_, err = s.commandBus.Dispatch(context.Background(), testCommand{name: "create"})
+	s.Require().NoError(err)
+	s.Require().Equal(int32(2), s.executions)
+}
+
+func (s *IdempotencyUnitSuite) newBus(store bus.IdempotencyStore) {
+	s.commandBus = bus.New(bus.Idempotency(store, ttl, errSkuAlreadyExists))
+	s.Require().NoError(s.commandBus.Register("create", func(ctx context.Context, command bus.Command) (interface{}, error) {
+		atomic.AddInt32(&s.executions, 1)
+		if s.executing != nil {
+			<-s.executing
+		}
+		return true, s.err
+	}))
+}
+
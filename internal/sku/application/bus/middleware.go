@@ -0,0 +1,63 @@
+package bus
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"log"
+	"time"
+)
+
+var tracer = otel.Tracer("feeder-service/internal/sku/application/bus")
+
+// Timeout cancels the context of every command after the timeout, a zero timeout leaves the commands unlimited.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			return next(ctx, command)
+		}
+	}
+}
+
+// Logging logs the name, the duration and the error (if any) of every command.
+func Logging(logger *log.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			startedAt := time.Now()
+			result, err := next(ctx, command)
+			if err != nil {
+				logger.Printf("%s failed in %s: %v", command.CommandName(), time.Since(startedAt), err)
+			} else {
+				logger.Printf("%s done in %s", command.CommandName(), time.Since(startedAt))
+			}
+
+			return result, err
+		}
+	}
+}
+
+// Tracing records a span named after the handler of every command, e.g. create_sku.Handle, child of the span of
+// the context. The spans are recorded by the global tracer provider.
+func Tracing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			ctx, span := tracer.Start(ctx, command.CommandName()+".Handle")
+			defer span.End()
+			span.SetAttributes(attribute.String("command.name", command.CommandName()))
+			result, err := next(ctx, command)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return result, err
+		}
+	}
+}
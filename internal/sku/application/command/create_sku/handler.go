@@ -3,12 +3,27 @@ package create_sku
 import (
 	"context"
 	"errors"
+	"feeder-service/internal/sku/application/bus"
 	"feeder-service/internal/sku/domain"
 	"fmt"
+	"sync/atomic"
+	"time"
 )
 
 type Command struct {
 	Sku string
+	// Tenant owns the sku, it's empty for the default tenant.
+	Tenant   string
+	Name     string
+	Brand    string
+	Category string
+	// Price is a decimal amount like "19.99" in the Currency, both are empty when the price is unknown.
+	Price    string
+	Currency string
+	// Stock is nil when it's unknown.
+	Stock *int
+	// Source identifies who sent the sku, e.g. the client address.
+	Source string
 }
 
 //go:generate mockgen -destination=mock/command_handler_interface_mockgen_mock.go -package=mock . CommandHandlerInterface
@@ -16,25 +31,53 @@ type CommandHandlerInterface interface {
 	Handle(context.Context, Command) error
 }
 
-type CommandHandler  struct {
-	repository domain.SkuRepository
+// CommandName routes the command to its handler in the command bus.
+const CommandName = "create_sku"
+
+func (Command) CommandName() string {
+	return CommandName
+}
+
+// BusHandler adapts the handler to the command bus, the commands have no result besides the error.
+func BusHandler(handler CommandHandlerInterface) bus.Handler {
+	return func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, handler.Handle(ctx, command.(Command))
+	}
+}
+
+type CommandHandler struct {
+	repository   domain.SkuRepository
+	skuIdFormats atomic.Value
 }
 
 func NewCommandHandler(repository domain.SkuRepository) *CommandHandler {
-	return &CommandHandler{repository: repository}
+	handler := &CommandHandler{repository: repository}
+	handler.SetSkuIdFormats(domain.DefaultSkuIdFormats)
+
+	return handler
+}
+
+// SetSkuIdFormats replaces the formats used to validate the skus of each tenant in the next commands.
+func (h *CommandHandler) SetSkuIdFormats(skuIdFormats *domain.SkuIdFormats) {
+	h.skuIdFormats.Store(skuIdFormats)
 }
 
 var ErrCreatingSku = errors.New("error creating sku")
 
 func (h *CommandHandler) Handle(ctx context.Context, command Command) error {
-	skuId, err := domain.NewSkuId(command.Sku)
+	skuId, err := h.skuIdFormats.Load().(*domain.SkuIdFormats).NewSkuId(command.Tenant, command.Sku)
 	if err != nil {
 		return err
 	}
 
-	err = h.repository.Save(ctx, domain.NewSku(skuId))
+	attributes, err := domain.ParseAttributes(command.Name, command.Brand, command.Category, command.Price, command.Currency, command.Stock)
 	if err != nil {
-		if errors.Is(err, domain.ErrSkuAlreadyExists) {
+		return err
+	}
+
+	err = h.repository.Save(ctx, domain.NewSku(skuId, attributes, time.Now(), command.Source))
+	if err != nil {
+		if errors.Is(err, domain.ErrSkuAlreadyExists) || errors.Is(err, domain.ErrSkuDeleted) {
 			return err
 		}
 		return h.buildErrCreatingSku(skuId, err.Error())
@@ -46,5 +89,3 @@ func (h *CommandHandler) Handle(ctx context.Context, command Command) error {
 func (h *CommandHandler) buildErrCreatingSku(skuId *domain.SkuId, errorMsg string) error {
 	return fmt.Errorf("%w %s: %s", ErrCreatingSku, skuId.Value(), errorMsg)
 }
-
-
@@ -11,7 +11,9 @@ import (
 	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
+	"strings"
 	"testing"
+	"time"
 )
 
 const sku = "KASL-3423"
@@ -55,12 +57,77 @@ func (s *UnitSuite) TestReturnErrCreatingSkuWhenCallToRepositorySaveReturnError(
 func (s *UnitSuite) TestReturnErrCreatingSkuWhenAlreadyExists() {
 	skuId, err := domain.NewSkuId(sku)
 	s.Require().NoError(err)
-	alreadyExistingSku := domain.NewSku(skuId)
-	s.repositoryMock.EXPECT().Save(s.ctx, alreadyExistingSku).Times(1).Return(fmt.Errorf("%w: %s", domain.ErrSkuAlreadyExists, sku))
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+		s.Require().True(skuId.Equal(skuEntity.Id()))
+		return fmt.Errorf("%w: %s", domain.ErrSkuAlreadyExists, sku)
+	})
 
 	s.executeTestErrSkuAlreadyExists()
 }
 
+func (s *UnitSuite) TestReturnErrSkuDeletedWhenTheSkuIsATombstone() {
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.Any()).Times(1).Return(fmt.Errorf("%w: %s", domain.ErrSkuDeleted, sku))
+
+	err := s.executeCommandHandler(sku)
+	s.Require().ErrorIs(err, domain.ErrSkuDeleted)
+}
+
+func (s *UnitSuite) TestSaveSkuSeenOnceNowFromTheCommandSource() {
+	before := time.Now()
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+		s.Require().Equal(1, skuEntity.SeenCount())
+		s.Require().Equal([]string{"10.0.0.1"}, skuEntity.Sources())
+		s.Require().False(skuEntity.FirstSeen().Before(before))
+		s.Require().Equal(skuEntity.FirstSeen(), skuEntity.LastSeen())
+		return nil
+	})
+
+	err := s.handler.Handle(s.ctx, create_sku.Command{Sku: sku, Source: "10.0.0.1"})
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TestSaveSkuWithItsAttributes() {
+	stock := 5
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+		attributes := skuEntity.Attributes()
+		s.Require().Equal("Running shoes", attributes.Name())
+		s.Require().Equal("Acme", attributes.Brand())
+		s.Require().Equal("Footwear", attributes.Category())
+		s.Require().Equal(int64(1999), attributes.Price().Amount())
+		s.Require().Equal("EUR", attributes.Price().Currency())
+		s.Require().Equal(5, *attributes.Stock())
+		return nil
+	})
+
+	err := s.handler.Handle(s.ctx, create_sku.Command{
+		Sku:      sku,
+		Name:     "Running shoes",
+		Brand:    "Acme",
+		Category: "Footwear",
+		Price:    "19.99",
+		Currency: "EUR",
+		Stock:    &stock,
+	})
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TestReturnErrInvalidAttributeWhenAnAttributeIsNotValid() {
+	negativeStock := -1
+	for name, command := range map[string]create_sku.Command{
+		"price with three decimals": {Sku: sku, Price: "19.999", Currency: "EUR"},
+		"negative price":            {Sku: sku, Price: "-1", Currency: "EUR"},
+		"price without currency":    {Sku: sku, Price: "19.99"},
+		"unknown currency format":   {Sku: sku, Price: "19.99", Currency: "euro"},
+		"negative stock":            {Sku: sku, Stock: &negativeStock},
+		"too long name":             {Sku: sku, Name: strings.Repeat("a", 256)},
+		"brand with spaces":         {Sku: sku, Brand: " Acme "},
+		"category with a new line":  {Sku: sku, Category: "Foot\nwear"},
+	} {
+		err := s.handler.Handle(s.ctx, command)
+		s.Require().ErrorIs(err, domain.ErrInvalidAttribute, name)
+	}
+}
+
 func (s *UnitSuite) TestReturnErrInvalidSkuWhenHasLessThanNineCharacters() {
 	s.executeTestInvalidSku("ABCD-123")
 }
@@ -81,6 +148,48 @@ func (s *UnitSuite) TestReturnErrInvalidSkuWhenTheLastFourCharactersAreNotNumber
 	s.executeTestInvalidSku("ABCD-ABCD")
 }
 
+func (s *UnitSuite) TestSkuIdFormatCanBeReplaced() {
+	skuIdFormat, err := domain.NewSkuIdFormat("^[A-Z]{3}-[0-9]{6}$")
+	s.Require().NoError(err)
+	s.handler.SetSkuIdFormats(domain.NewSkuIdFormats(skuIdFormat, nil))
+
+	s.executeTestInvalidSku(sku)
+
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.Any()).Times(1).Return(nil)
+	s.Require().NoError(s.executeCommandHandler("KAS-342310"))
+}
+
+func (s *UnitSuite) TestReturnErrInvalidSkuWhenTheSkuHasAColonWhateverTheFormat() {
+	skuIdFormat, err := domain.NewSkuIdFormat("^.+$")
+	s.Require().NoError(err)
+	s.handler.SetSkuIdFormats(domain.NewSkuIdFormats(skuIdFormat, nil))
+
+	s.executeTestInvalidSku("acme:ACME-123456")
+}
+
+func (s *UnitSuite) TestSkusOfATenantAreValidatedWithTheFormatOfTheTenant() {
+	acmeFormat, err := domain.NewSkuIdFormat("^ACME-[0-9]{6}$")
+	s.Require().NoError(err)
+	s.handler.SetSkuIdFormats(domain.NewSkuIdFormats(domain.DefaultSkuIdFormat, map[domain.Tenant]*domain.SkuIdFormat{"acme": acmeFormat}))
+
+	err = s.handler.Handle(s.ctx, create_sku.Command{Sku: sku, Tenant: "acme"})
+	s.Require().ErrorIs(err, domain.ErrInvalidSku)
+	err = s.handler.Handle(s.ctx, create_sku.Command{Sku: sku, Tenant: "Acme Inc"})
+	s.Require().ErrorIs(err, domain.ErrInvalidTenant)
+
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+		s.Require().Equal(domain.Tenant("acme"), skuEntity.Id().Tenant())
+		s.Require().Equal("acme:ACME-123456", skuEntity.Id().Key())
+		return nil
+	})
+	s.Require().NoError(s.handler.Handle(s.ctx, create_sku.Command{Sku: "ACME-123456", Tenant: "acme"}))
+}
+
+func (s *UnitSuite) TestReturnErrInvalidSkuIdFormatWhenThePatternDoesNotCompile() {
+	_, err := domain.NewSkuIdFormat("^[A-Z")
+	s.Require().ErrorIs(err, domain.ErrInvalidSkuIdFormat)
+}
+
 func (s *UnitSuite) repositorySaveNoErrorExpectation(sku string) {
 	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
 		expectedSkuId, err := domain.NewSkuId(sku)
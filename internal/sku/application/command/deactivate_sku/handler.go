@@ -0,0 +1,66 @@
+package deactivate_sku
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/transition_sku"
+	"feeder-service/internal/sku/domain"
+	"sync/atomic"
+)
+
+// Command deactivates an active sku, an inactive sku is kept but retired from the catalog.
+type Command struct {
+	Sku string
+	// Tenant owns the sku, it's empty for the default tenant.
+	Tenant string
+}
+
+//go:generate mockgen -destination=mock/command_handler_interface_mockgen_mock.go -package=mock . CommandHandlerInterface
+type CommandHandlerInterface interface {
+	Handle(context.Context, Command) error
+}
+
+// CommandName routes the command to its handler in the command bus.
+const CommandName = "deactivate_sku"
+
+func (Command) CommandName() string {
+	return CommandName
+}
+
+// BusHandler adapts the handler to the command bus, the commands have no result besides the error.
+func BusHandler(handler CommandHandlerInterface) bus.Handler {
+	return func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, handler.Handle(ctx, command.(Command))
+	}
+}
+
+type CommandHandler struct {
+	repository   domain.SkuRepository
+	skuIdFormats atomic.Value
+}
+
+func NewCommandHandler(repository domain.SkuRepository) *CommandHandler {
+	handler := &CommandHandler{repository: repository}
+	handler.SetSkuIdFormats(domain.DefaultSkuIdFormats)
+
+	return handler
+}
+
+// SetSkuIdFormats replaces the formats used to validate the skus of each tenant in the next commands.
+func (h *CommandHandler) SetSkuIdFormats(skuIdFormats *domain.SkuIdFormats) {
+	h.skuIdFormats.Store(skuIdFormats)
+}
+
+var ErrDeactivatingSku = errors.New("error deactivating sku")
+
+// Handle returns domain.ErrSkuNotFound when the sku doesn't exist, domain.ErrSkuUnchanged when it's already inactive
+// and domain.ErrSkuDeleted when it was deleted. A concurrent modification of the sku is retried.
+func (h *CommandHandler) Handle(ctx context.Context, command Command) error {
+	skuId, err := h.skuIdFormats.Load().(*domain.SkuIdFormats).NewSkuId(command.Tenant, command.Sku)
+	if err != nil {
+		return err
+	}
+
+	return transition_sku.Transition(ctx, h.repository, skuId, (*domain.Sku).Deactivate, ErrDeactivatingSku)
+}
@@ -0,0 +1,81 @@
+//+build unit
+
+package delete_sku_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/command/delete_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/domain/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+const sku = "KASL-3423"
+
+type UnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	repositoryMock *mock.MockSkuRepository
+	mockCtrl       *gomock.Controller
+	handler        *delete_sku.CommandHandler
+	skuId          *domain.SkuId
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockSkuRepository(s.mockCtrl)
+	s.handler = delete_sku.NewCommandHandler(s.repositoryMock)
+	var err error
+	s.skuId, err = domain.NewSkuId(sku)
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestDeleteAnInactiveSkuLeavingATombstone() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(domain.StatusInactive), nil)
+	s.repositoryMock.EXPECT().Update(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+		s.Require().Equal(domain.StatusDeleted, skuEntity.Status())
+		return nil
+	})
+
+	err := s.handler.Handle(s.ctx, delete_sku.Command{Sku: sku})
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TestReturnErrSkuUnchangedWhenTheSkuIsAlreadyDeleted() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(domain.StatusDeleted), nil)
+
+	err := s.handler.Handle(s.ctx, delete_sku.Command{Sku: sku})
+	s.Require().ErrorIs(err, domain.ErrSkuUnchanged)
+}
+
+func (s *UnitSuite) TestReturnErrSkuNotFoundWhenTheSkuDoesNotExist() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(nil, nil)
+
+	err := s.handler.Handle(s.ctx, delete_sku.Command{Sku: sku})
+	s.Require().ErrorIs(err, domain.ErrSkuNotFound)
+}
+
+func (s *UnitSuite) TestReturnErrDeletingSkuWhenCallToRepositoryUpdateReturnError() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(domain.StatusInactive), nil)
+	s.repositoryMock.EXPECT().Update(s.ctx, gomock.Any()).Return(errors.New("repository error"))
+
+	err := s.handler.Handle(s.ctx, delete_sku.Command{Sku: sku})
+	s.Require().ErrorIs(err, delete_sku.ErrDeletingSku)
+	s.Require().Equal("error deleting sku "+sku+": repository error", err.Error())
+}
+
+func (s *UnitSuite) existingSku(status domain.Status) *domain.Sku {
+	return domain.NewHydrator().Hydrate(&domain.SkuDTO{ID: sku, Status: status.String()})
+}
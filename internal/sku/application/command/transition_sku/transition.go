@@ -0,0 +1,37 @@
+package transition_sku
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"fmt"
+)
+
+// Transition finds the sku, changes its status with the transition and stores it, doing it again over the stored sku
+// when it's modified concurrently. It returns domain.ErrSkuNotFound when the sku doesn't exist and the error of the
+// transition as it is, the rest of the errors are wrapped in errTransitioning.
+func Transition(ctx context.Context, repository domain.SkuRepository, skuId *domain.SkuId, transition func(*domain.Sku) error, errTransitioning error) error {
+	return domain.RetryOnConcurrentModification(ctx, func() error {
+		sku, err := repository.Find(ctx, skuId)
+		if err != nil {
+			return fmt.Errorf("%w %s: %s", errTransitioning, skuId.Value(), err.Error())
+		}
+		if sku == nil {
+			return fmt.Errorf("%w: %s", domain.ErrSkuNotFound, skuId.Value())
+		}
+		err = transition(sku)
+		if err != nil {
+			return err
+		}
+
+		err = repository.Update(ctx, sku)
+		if err != nil {
+			if errors.Is(err, domain.ErrSkuNotFound) || errors.Is(err, domain.ErrConcurrentModification) {
+				return err
+			}
+			return fmt.Errorf("%w %s: %s", errTransitioning, skuId.Value(), err.Error())
+		}
+
+		return nil
+	})
+}
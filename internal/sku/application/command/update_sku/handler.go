@@ -0,0 +1,107 @@
+package update_sku
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/domain"
+	"fmt"
+	"sync/atomic"
+)
+
+// Command updates the attributes of an existing sku, the empty attributes keep their current value.
+type Command struct {
+	Sku string
+	// Tenant owns the sku, it's empty for the default tenant.
+	Tenant   string
+	Name     string
+	Brand    string
+	Category string
+	// Price is a decimal amount like "19.99" in the Currency, both are empty when the price is not updated.
+	Price    string
+	Currency string
+	// Stock is nil when it's not updated.
+	Stock *int
+}
+
+//go:generate mockgen -destination=mock/command_handler_interface_mockgen_mock.go -package=mock . CommandHandlerInterface
+type CommandHandlerInterface interface {
+	Handle(context.Context, Command) error
+}
+
+// CommandName routes the command to its handler in the command bus.
+const CommandName = "update_sku"
+
+func (Command) CommandName() string {
+	return CommandName
+}
+
+// BusHandler adapts the handler to the command bus, the commands have no result besides the error.
+func BusHandler(handler CommandHandlerInterface) bus.Handler {
+	return func(ctx context.Context, command bus.Command) (interface{}, error) {
+		return nil, handler.Handle(ctx, command.(Command))
+	}
+}
+
+type CommandHandler struct {
+	repository   domain.SkuRepository
+	skuIdFormats atomic.Value
+}
+
+func NewCommandHandler(repository domain.SkuRepository) *CommandHandler {
+	handler := &CommandHandler{repository: repository}
+	handler.SetSkuIdFormats(domain.DefaultSkuIdFormats)
+
+	return handler
+}
+
+// SetSkuIdFormats replaces the formats used to validate the skus of each tenant in the next commands.
+func (h *CommandHandler) SetSkuIdFormats(skuIdFormats *domain.SkuIdFormats) {
+	h.skuIdFormats.Store(skuIdFormats)
+}
+
+var ErrUpdatingSku = errors.New("error updating sku")
+
+// Handle returns domain.ErrSkuNotFound when the sku doesn't exist and domain.ErrSkuUnchanged when
+// the command doesn't change any of its attributes. When the sku is modified concurrently the update is
+// done again over the stored sku, and domain.ErrConcurrentModification is returned when the retries run out.
+func (h *CommandHandler) Handle(ctx context.Context, command Command) error {
+	skuId, err := h.skuIdFormats.Load().(*domain.SkuIdFormats).NewSkuId(command.Tenant, command.Sku)
+	if err != nil {
+		return err
+	}
+	attributes, err := domain.ParseAttributes(command.Name, command.Brand, command.Category, command.Price, command.Currency, command.Stock)
+	if err != nil {
+		return err
+	}
+
+	return domain.RetryOnConcurrentModification(ctx, func() error {
+		sku, err := h.repository.Find(ctx, skuId)
+		if err != nil {
+			return fmt.Errorf("%w %s: %s", ErrUpdatingSku, skuId.Value(), err.Error())
+		}
+		if sku == nil {
+			return fmt.Errorf("%w: %s", domain.ErrSkuNotFound, skuId.Value())
+		}
+
+		return Update(ctx, h.repository, sku, attributes)
+	})
+}
+
+// Update merges the attributes into the sku and stores it when any of them changed.
+func Update(ctx context.Context, repository domain.SkuRepository, sku *domain.Sku, attributes domain.Attributes) error {
+	err := sku.Update(attributes)
+	if err != nil {
+		return err
+	}
+
+	err = repository.Update(ctx, sku)
+	if err != nil {
+		if errors.Is(err, domain.ErrSkuNotFound) || errors.Is(err, domain.ErrConcurrentModification) {
+			return err
+		}
+		return fmt.Errorf("%w %s: %s", ErrUpdatingSku, sku.Id().Value(), err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+//+build unit
+
+package update_sku_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/command/update_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/domain/mock"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+const sku = "KASL-3423"
+
+type UnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	repositoryMock *mock.MockSkuRepository
+	mockCtrl       *gomock.Controller
+	handler        *update_sku.CommandHandler
+	skuId          *domain.SkuId
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockSkuRepository(s.mockCtrl)
+	s.handler = update_sku.NewCommandHandler(s.repositoryMock)
+	var err error
+	s.skuId, err = domain.NewSkuId(sku)
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestUpdateOnlyTheAttributesOfTheCommand() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(), nil)
+	s.repositoryMock.EXPECT().Update(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+		attributes := skuEntity.Attributes()
+		s.Require().Equal("Running shoes", attributes.Name())
+		s.Require().Equal("Acme Sports", attributes.Brand())
+		s.Require().Equal(int64(1500), attributes.Price().Amount())
+		s.Require().Equal(3, *attributes.Stock())
+		return nil
+	})
+
+	err := s.handler.Handle(s.ctx, update_sku.Command{Sku: sku, Brand: "Acme Sports", Price: "15", Currency: "EUR"})
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TestReturnErrSkuUnchangedWhenNoAttributeChanges() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(), nil)
+
+	err := s.handler.Handle(s.ctx, update_sku.Command{Sku: sku, Brand: "Acme", Price: "19.99", Currency: "EUR"})
+	s.Require().ErrorIs(err, domain.ErrSkuUnchanged)
+}
+
+func (s *UnitSuite) TestReturnErrSkuNotFoundWhenTheSkuDoesNotExist() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(nil, nil)
+
+	err := s.handler.Handle(s.ctx, update_sku.Command{Sku: sku, Brand: "Acme"})
+	s.Require().ErrorIs(err, domain.ErrSkuNotFound)
+}
+
+func (s *UnitSuite) TestReturnErrInvalidSkuOrAttributeBeforeLookingUpTheSku() {
+	err := s.handler.Handle(s.ctx, update_sku.Command{Sku: "ABCD-123"})
+	s.Require().ErrorIs(err, domain.ErrInvalidSku)
+
+	err = s.handler.Handle(s.ctx, update_sku.Command{Sku: sku, Price: "1.234", Currency: "EUR"})
+	s.Require().ErrorIs(err, domain.ErrInvalidAttribute)
+}
+
+func (s *UnitSuite) TestReturnErrUpdatingSkuWhenCallToRepositoryUpdateReturnError() {
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(), nil)
+	s.repositoryMock.EXPECT().Update(s.ctx, gomock.Any()).Return(errors.New("repository error"))
+
+	err := s.handler.Handle(s.ctx, update_sku.Command{Sku: sku, Name: "Trail shoes"})
+	s.Require().ErrorIs(err, update_sku.ErrUpdatingSku)
+	s.Require().Equal("error updating sku "+sku+": repository error", err.Error())
+}
+
+func (s *UnitSuite) TestRetryOverTheStoredSkuWhenItIsModifiedConcurrently() {
+	concurrentModification := fmt.Errorf("%w: %s", domain.ErrConcurrentModification, sku)
+	gomock.InOrder(
+		s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(), nil),
+		s.repositoryMock.EXPECT().Update(s.ctx, gomock.Any()).Return(concurrentModification),
+		s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku(), nil),
+		s.repositoryMock.EXPECT().Update(s.ctx, gomock.Any()).Return(nil),
+	)
+
+	err := s.handler.Handle(s.ctx, update_sku.Command{Sku: sku, Name: "Trail shoes"})
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TestReturnErrConcurrentModificationWhenTheRetriesRunOut() {
+	concurrentModification := fmt.Errorf("%w: %s", domain.ErrConcurrentModification, sku)
+	attempts := domain.ConcurrentModificationRetries + 1
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Times(attempts).DoAndReturn(func(context.Context, *domain.SkuId, ...domain.Status) (*domain.Sku, error) {
+		return s.existingSku(), nil
+	})
+	s.repositoryMock.EXPECT().Update(s.ctx, gomock.Any()).Times(attempts).Return(concurrentModification)
+
+	err := s.handler.Handle(s.ctx, update_sku.Command{Sku: sku, Name: "Trail shoes"})
+	s.Require().ErrorIs(err, domain.ErrConcurrentModification)
+}
+
+func (s *UnitSuite) existingSku() *domain.Sku {
+	price, err := domain.NewPrice("19.99", "EUR")
+	s.Require().NoError(err)
+	stock := 3
+	attributes, err := domain.NewAttributes("Running shoes", "Acme", "", price, &stock)
+	s.Require().NoError(err)
+
+	return domain.NewSku(s.skuId, attributes, time.Now(), "")
+}
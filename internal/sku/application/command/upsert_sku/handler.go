@@ -0,0 +1,108 @@
+package upsert_sku
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/update_sku"
+	"feeder-service/internal/sku/domain"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Command creates the sku when it doesn't exist and updates its attributes otherwise,
+// the empty attributes keep their current value.
+type Command struct {
+	Sku string
+	// Tenant owns the sku, it's empty for the default tenant.
+	Tenant   string
+	Name     string
+	Brand    string
+	Category string
+	// Price is a decimal amount like "19.99" in the Currency, both are empty when the price is unknown.
+	Price    string
+	Currency string
+	// Stock is nil when it's unknown.
+	Stock *int
+	// Source identifies who sent the sku, e.g. the client address.
+	Source string
+}
+
+//go:generate mockgen -destination=mock/command_handler_interface_mockgen_mock.go -package=mock . CommandHandlerInterface
+type CommandHandlerInterface interface {
+	// Handle returns true when the sku was created.
+	Handle(context.Context, Command) (bool, error)
+}
+
+// CommandName routes the command to its handler in the command bus.
+const CommandName = "upsert_sku"
+
+func (Command) CommandName() string {
+	return CommandName
+}
+
+// BusHandler adapts the handler to the command bus, the result of a command tells whether the sku was created.
+func BusHandler(handler CommandHandlerInterface) bus.Handler {
+	return func(ctx context.Context, command bus.Command) (interface{}, error) {
+		created, err := handler.Handle(ctx, command.(Command))
+		return created, err
+	}
+}
+
+type CommandHandler struct {
+	repository   domain.SkuRepository
+	skuIdFormats atomic.Value
+}
+
+func NewCommandHandler(repository domain.SkuRepository) *CommandHandler {
+	handler := &CommandHandler{repository: repository}
+	handler.SetSkuIdFormats(domain.DefaultSkuIdFormats)
+
+	return handler
+}
+
+// SetSkuIdFormats replaces the formats used to validate the skus of each tenant in the next commands.
+func (h *CommandHandler) SetSkuIdFormats(skuIdFormats *domain.SkuIdFormats) {
+	h.skuIdFormats.Store(skuIdFormats)
+}
+
+var ErrUpsertingSku = errors.New("error upserting sku")
+
+// Handle returns domain.ErrSkuUnchanged when the sku exists and the command doesn't change any of its attributes.
+// Every command records a sighting of the sku, as the creation does, whether it creates the sku or updates it.
+// When the sku is modified concurrently the update is done again over the stored sku.
+func (h *CommandHandler) Handle(ctx context.Context, command Command) (bool, error) {
+	skuId, err := h.skuIdFormats.Load().(*domain.SkuIdFormats).NewSkuId(command.Tenant, command.Sku)
+	if err != nil {
+		return false, err
+	}
+	attributes, err := domain.ParseAttributes(command.Name, command.Brand, command.Category, command.Price, command.Currency, command.Stock)
+	if err != nil {
+		return false, err
+	}
+
+	err = h.repository.Save(ctx, domain.NewSku(skuId, attributes, time.Now(), command.Source))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, domain.ErrSkuDeleted) {
+		return false, err
+	}
+	if !errors.Is(err, domain.ErrSkuAlreadyExists) {
+		return false, fmt.Errorf("%w %s: %s", ErrUpsertingSku, skuId.Value(), err.Error())
+	}
+
+	// the sku exists and Save recorded its sighting, so only its attributes are left to update
+	return false, domain.RetryOnConcurrentModification(ctx, func() error {
+		sku, err := h.repository.Find(ctx, skuId)
+		if err != nil {
+			return fmt.Errorf("%w %s: %s", ErrUpsertingSku, skuId.Value(), err.Error())
+		}
+		if sku == nil {
+			return fmt.Errorf("%w: %s", domain.ErrSkuNotFound, skuId.Value())
+		}
+
+		return update_sku.Update(ctx, h.repository, sku, attributes)
+	})
+}
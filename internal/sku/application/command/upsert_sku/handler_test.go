@@ -0,0 +1,110 @@
+//+build unit
+
+package upsert_sku_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/application/command/upsert_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/domain/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+const sku = "KASL-3423"
+
+type UnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	repositoryMock *mock.MockSkuRepository
+	mockCtrl       *gomock.Controller
+	handler        *upsert_sku.CommandHandler
+	skuId          *domain.SkuId
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockSkuRepository(s.mockCtrl)
+	s.handler = upsert_sku.NewCommandHandler(s.repositoryMock)
+	var err error
+	s.skuId, err = domain.NewSkuId(sku)
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestCreateTheSkuWhenItDoesNotExist() {
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+		s.Require().Equal("Acme", skuEntity.Attributes().Brand())
+		s.Require().Equal([]string{"10.0.0.1"}, skuEntity.Sources())
+		return nil
+	})
+
+	created, err := s.handler.Handle(s.ctx, upsert_sku.Command{Sku: sku, Brand: "Acme", Source: "10.0.0.1"})
+	s.Require().NoError(err)
+	s.Require().True(created)
+}
+
+func (s *UnitSuite) TestUpdateTheSkuWhenItExistsAfterRecordingItsSighting() {
+	gomock.InOrder(
+		s.repositoryMock.EXPECT().Save(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+			s.Require().Equal([]string{"10.0.0.1"}, skuEntity.Sources())
+			return domain.ErrSkuAlreadyExists
+		}),
+		s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku("Acme"), nil),
+		s.repositoryMock.EXPECT().Update(s.ctx, gomock.AssignableToTypeOf(&domain.Sku{})).Times(1).DoAndReturn(func(ctx context.Context, skuEntity *domain.Sku) error {
+			s.Require().Equal("Acme Sports", skuEntity.Attributes().Brand())
+			return nil
+		}),
+	)
+
+	created, err := s.handler.Handle(s.ctx, upsert_sku.Command{Sku: sku, Brand: "Acme Sports", Source: "10.0.0.1"})
+	s.Require().NoError(err)
+	s.Require().False(created)
+}
+
+func (s *UnitSuite) TestReturnErrSkuUnchangedWhenTheSkuExistsWithTheSameAttributes() {
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.Any()).Return(domain.ErrSkuAlreadyExists)
+	s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku("Acme"), nil)
+
+	_, err := s.handler.Handle(s.ctx, upsert_sku.Command{Sku: sku, Brand: "Acme"})
+	s.Require().ErrorIs(err, domain.ErrSkuUnchanged)
+}
+
+func (s *UnitSuite) TestTheRetryOfAConcurrentModificationDoesNotRecordTheSightingAgain() {
+	gomock.InOrder(
+		s.repositoryMock.EXPECT().Save(s.ctx, gomock.Any()).Times(1).Return(domain.ErrSkuAlreadyExists),
+		s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku("Acme"), nil),
+		s.repositoryMock.EXPECT().Update(s.ctx, gomock.Any()).Return(domain.ErrConcurrentModification),
+		s.repositoryMock.EXPECT().Find(s.ctx, s.skuId).Return(s.existingSku("Acme"), nil),
+		s.repositoryMock.EXPECT().Update(s.ctx, gomock.Any()).Return(nil),
+	)
+
+	created, err := s.handler.Handle(s.ctx, upsert_sku.Command{Sku: sku, Brand: "Acme Sports"})
+	s.Require().NoError(err)
+	s.Require().False(created)
+}
+
+func (s *UnitSuite) TestReturnErrSkuDeletedWhenTheSkuIsATombstone() {
+	s.repositoryMock.EXPECT().Save(s.ctx, gomock.Any()).Return(domain.ErrSkuDeleted)
+
+	created, err := s.handler.Handle(s.ctx, upsert_sku.Command{Sku: sku, Brand: "Acme Sports"})
+	s.Require().ErrorIs(err, domain.ErrSkuDeleted)
+	s.Require().False(created)
+}
+
+func (s *UnitSuite) existingSku(brand string) *domain.Sku {
+	attributes, err := domain.NewAttributes("", brand, "", nil, nil)
+	s.Require().NoError(err)
+
+	return domain.NewSku(s.skuId, attributes, time.Now(), "")
+}
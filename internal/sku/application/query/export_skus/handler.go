@@ -0,0 +1,47 @@
+package export_skus
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+)
+
+type Query struct {
+	// Tenant selects the skus of the tenant, the ones of every tenant are exported when it's empty.
+	Tenant string
+	// Prefixes selects the skus whose value starts with any of them.
+	Prefixes []string
+	// Statuses selects the skus with any of the statuses, the ones not deleted are exported when it's empty.
+	Statuses []string
+}
+
+type QueryHandler struct {
+	repository domain.SkuRepository
+}
+
+func NewQueryHandler(repository domain.SkuRepository) *QueryHandler {
+	return &QueryHandler{repository: repository}
+}
+
+// Handle calls fn with every sku selected by the query ordered by key, as they were when the export started.
+func (h *QueryHandler) Handle(ctx context.Context, query Query, fn func(*domain.Sku) error) error {
+	filter := domain.SkuFilter{Prefixes: query.Prefixes, Statuses: []domain.Status{domain.StatusActive, domain.StatusInactive}}
+	if query.Tenant != "" {
+		tenant, err := domain.NewTenant(query.Tenant)
+		if err != nil {
+			return err
+		}
+		filter.Tenant = tenant
+	}
+	if len(query.Statuses) > 0 {
+		filter.Statuses = nil
+		for _, value := range query.Statuses {
+			status, err := domain.ParseStatus(value)
+			if err != nil {
+				return err
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	return h.repository.Scan(ctx, filter, fn)
+}
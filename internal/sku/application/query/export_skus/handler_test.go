@@ -0,0 +1,62 @@
+//+build unit
+
+package export_skus_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/application/query/export_skus"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/domain/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	repositoryMock *mock.MockSkuRepository
+	mockCtrl       *gomock.Controller
+	handler        *export_skus.QueryHandler
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockSkuRepository(s.mockCtrl)
+	s.handler = export_skus.NewQueryHandler(s.repositoryMock)
+}
+
+func (s *UnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestTheDeletedSkusAreNotExportedByDefault() {
+	s.repositoryMock.EXPECT().Scan(s.ctx, domain.SkuFilter{
+		Tenant:   "acme",
+		Prefixes: []string{"ABCD"},
+		Statuses: []domain.Status{domain.StatusActive, domain.StatusInactive},
+	}, gomock.Any()).Return(nil)
+
+	err := s.handler.Handle(s.ctx, export_skus.Query{Tenant: "acme", Prefixes: []string{"ABCD"}}, func(*domain.Sku) error { return nil })
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TestExportTheSkusWithTheGivenStatuses() {
+	s.repositoryMock.EXPECT().Scan(s.ctx, domain.SkuFilter{Statuses: []domain.Status{domain.StatusDeleted}}, gomock.Any()).Return(nil)
+
+	err := s.handler.Handle(s.ctx, export_skus.Query{Statuses: []string{"deleted"}}, func(*domain.Sku) error { return nil })
+	s.Require().NoError(err)
+}
+
+func (s *UnitSuite) TestReturnAnErrorWithAnInvalidTenantOrStatus() {
+	err := s.handler.Handle(s.ctx, export_skus.Query{Tenant: "ACME"}, func(*domain.Sku) error { return nil })
+	s.Require().ErrorIs(err, domain.ErrInvalidTenant)
+
+	err = s.handler.Handle(s.ctx, export_skus.Query{Statuses: []string{"archived"}}, func(*domain.Sku) error { return nil })
+	s.Require().ErrorIs(err, domain.ErrInvalidStatus)
+}
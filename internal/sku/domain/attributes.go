@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInvalidAttribute = errors.New("invalid sku attribute")
+
+const maxTextAttributeLength = 255
+
+// Attributes describe the product of an sku, all of them are optional as the plain sku messages don't carry them.
+type Attributes struct {
+	name     string
+	brand    string
+	category string
+	price    *Price
+	stock    *int
+}
+
+func NewAttributes(name, brand, category string, price *Price, stock *int) (Attributes, error) {
+	for _, attribute := range []struct{ name, value string }{
+		{"name", name},
+		{"brand", brand},
+		{"category", category},
+	} {
+		err := validateTextAttribute(attribute.name, attribute.value)
+		if err != nil {
+			return Attributes{}, err
+		}
+	}
+	if stock != nil && *stock < 0 {
+		return Attributes{}, fmt.Errorf("%w: stock can't be negative: %d", ErrInvalidAttribute, *stock)
+	}
+
+	return Attributes{name: name, brand: brand, category: category, price: price, stock: stock}, nil
+}
+
+// ParseAttributes builds the attributes from the values of a message, the price is unknown when both
+// the amount and the currency are empty.
+func ParseAttributes(name, brand, category, priceAmount, currency string, stock *int) (Attributes, error) {
+	var price *Price
+	if priceAmount != "" || currency != "" {
+		var err error
+		price, err = NewPrice(priceAmount, currency)
+		if err != nil {
+			return Attributes{}, err
+		}
+	}
+
+	return NewAttributes(name, brand, category, price, stock)
+}
+
+// Merge returns the attributes with the known values of the other ones, so an empty name or a nil
+// price or stock in the other attributes keep the current value.
+func (a Attributes) Merge(other Attributes) Attributes {
+	merged := a
+	if other.name != "" {
+		merged.name = other.name
+	}
+	if other.brand != "" {
+		merged.brand = other.brand
+	}
+	if other.category != "" {
+		merged.category = other.category
+	}
+	if other.price != nil {
+		merged.price = other.price
+	}
+	if other.stock != nil {
+		merged.stock = other.stock
+	}
+
+	return merged
+}
+
+func (a Attributes) Equal(other Attributes) bool {
+	samePrice := a.price == other.price || (a.price != nil && other.price != nil && *a.price == *other.price)
+	sameStock := a.stock == other.stock || (a.stock != nil && other.stock != nil && *a.stock == *other.stock)
+
+	return a.name == other.name && a.brand == other.brand && a.category == other.category && samePrice && sameStock
+}
+
+func validateTextAttribute(name, value string) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("%w: %s is not valid utf-8", ErrInvalidAttribute, name)
+	}
+	if utf8.RuneCountInString(value) > maxTextAttributeLength {
+		return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidAttribute, name, maxTextAttributeLength)
+	}
+	if strings.TrimSpace(value) != value {
+		return fmt.Errorf("%w: %s has leading or trailing spaces", ErrInvalidAttribute, name)
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%w: %s has control characters", ErrInvalidAttribute, name)
+	}
+
+	return nil
+}
+
+func (a Attributes) Name() string {
+	return a.name
+}
+
+func (a Attributes) Brand() string {
+	return a.brand
+}
+
+func (a Attributes) Category() string {
+	return a.category
+}
+
+// Price returns nil when the price is unknown.
+func (a Attributes) Price() *Price {
+	return a.price
+}
+
+// Stock returns nil when the stock is unknown.
+func (a Attributes) Stock() *int {
+	return a.stock
+}
+
+// Price is an amount in minor units (cents) of an ISO 4217 currency, so it's never rounded.
+type Price struct {
+	amount   int64
+	currency string
+}
+
+var (
+	currencyRegexp    = regexp.MustCompile("^[A-Z]{3}$")
+	priceAmountRegexp = regexp.MustCompile(`^([0-9]+)(\.([0-9]{1,2}))?$`)
+)
+
+// NewPrice parses a decimal amount with up to two decimals, like "19.99", in the given currency.
+func NewPrice(amount, currency string) (*Price, error) {
+	if !currencyRegexp.MatchString(currency) {
+		return nil, fmt.Errorf("%w: currency has to be an ISO 4217 code: %q", ErrInvalidAttribute, currency)
+	}
+	matches := priceAmountRegexp.FindStringSubmatch(amount)
+	if matches == nil {
+		return nil, fmt.Errorf("%w: price has to be a positive amount with up to two decimals: %q", ErrInvalidAttribute, amount)
+	}
+	units, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil || units > math.MaxInt64/100-1 {
+		return nil, fmt.Errorf("%w: price is too big: %q", ErrInvalidAttribute, amount)
+	}
+	cents, _ := strconv.ParseInt((matches[3] + "00")[:2], 10, 64)
+
+	return NewPriceFromMinorUnits(units*100+cents, currency), nil
+}
+
+func NewPriceFromMinorUnits(amount int64, currency string) *Price {
+	return &Price{amount: amount, currency: currency}
+}
+
+// Amount returns the price in minor units.
+func (p Price) Amount() int64 {
+	return p.amount
+}
+
+func (p Price) Currency() string {
+	return p.currency
+}
+
+func (p Price) String() string {
+	return fmt.Sprintf("%d.%02d %s", p.amount/100, p.amount%100, p.currency)
+}
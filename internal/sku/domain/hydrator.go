@@ -1,7 +1,32 @@
 package domain
 
+import (
+	"strings"
+	"time"
+)
+
+// SkuDTO is stored with the key of the sku as ID, the tenant is only stored for the skus of the other tenants
+// than the default one.
 type SkuDTO struct {
-	ID string `bson:"_id"`
+	ID        string    `bson:"_id"`
+	Tenant    string    `bson:"tenant,omitempty"`
+	Name      string    `bson:"name,omitempty"`
+	Brand     string    `bson:"brand,omitempty"`
+	Category  string    `bson:"category,omitempty"`
+	Price     *PriceDTO `bson:"price,omitempty"`
+	Stock     *int      `bson:"stock,omitempty"`
+	Status    string    `bson:"status,omitempty"`
+	Version   int       `bson:"version"`
+	FirstSeen time.Time `bson:"first_seen"`
+	LastSeen  time.Time `bson:"last_seen"`
+	SeenCount int       `bson:"seen_count"`
+	Sources   []string  `bson:"sources,omitempty"`
+}
+
+// PriceDTO stores the amount in minor units.
+type PriceDTO struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
 }
 
 type Hydrator struct {}
@@ -11,13 +36,70 @@ func NewHydrator() *Hydrator {
 }
 
 func (h *Hydrator) Hydrate(dto *SkuDTO) *Sku {
+	var price *Price
+	if dto.Price != nil {
+		price = NewPriceFromMinorUnits(dto.Price.Amount, dto.Price.Currency)
+	}
+
+	// the skus stored before the status was introduced are active
+	status := StatusActive
+	if dto.Status != "" {
+		status = Status(dto.Status)
+	}
+
+	// the skus stored before the tenants were introduced belong to the default tenant
+	tenant := DefaultTenant
+	value := dto.ID
+	if dto.Tenant != "" {
+		tenant = Tenant(dto.Tenant)
+		value = strings.TrimPrefix(dto.ID, dto.Tenant+":")
+	}
+
 	return &Sku{
 		id: &SkuId{
-			value: dto.ID,
+			tenant: tenant,
+			value:  value,
+		},
+		attributes: Attributes{
+			name:     dto.Name,
+			brand:    dto.Brand,
+			category: dto.Category,
+			price:    price,
+			stock:    dto.Stock,
 		},
+		status:    status,
+		version:   dto.Version,
+		firstSeen: dto.FirstSeen,
+		lastSeen:  dto.LastSeen,
+		seenCount: dto.SeenCount,
+		sources:   dto.Sources,
 	}
 }
 
 func (h *Hydrator) Dehydrate(sku *Sku) *SkuDTO {
-	return &SkuDTO{ID: sku.id.value}
-}
\ No newline at end of file
+	var price *PriceDTO
+	if sku.attributes.price != nil {
+		price = &PriceDTO{Amount: sku.attributes.price.amount, Currency: sku.attributes.price.currency}
+	}
+
+	var tenant string
+	if sku.id.tenant != DefaultTenant {
+		tenant = sku.id.tenant.String()
+	}
+
+	return &SkuDTO{
+		ID:        sku.id.Key(),
+		Tenant:    tenant,
+		Name:      sku.attributes.name,
+		Brand:     sku.attributes.brand,
+		Category:  sku.attributes.category,
+		Price:     price,
+		Stock:     sku.attributes.stock,
+		Status:    string(sku.status),
+		Version:   sku.version,
+		FirstSeen: sku.firstSeen,
+		LastSeen:  sku.lastSeen,
+		SeenCount: sku.seenCount,
+		Sources:   sku.sources,
+	}
+}
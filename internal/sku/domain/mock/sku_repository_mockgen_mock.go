@@ -36,18 +36,37 @@ func (m *MockSkuRepository) EXPECT() *MockSkuRepositoryMockRecorder {
 }
 
 // Find mocks base method.
-func (m *MockSkuRepository) Find(arg0 context.Context, arg1 *domain.SkuId) (*domain.Sku, error) {
+func (m *MockSkuRepository) Find(arg0 context.Context, arg1 *domain.SkuId, arg2 ...domain.Status) (*domain.Sku, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Find", arg0, arg1)
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Find", varargs...)
 	ret0, _ := ret[0].(*domain.Sku)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Find indicates an expected call of Find.
-func (mr *MockSkuRepositoryMockRecorder) Find(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockSkuRepositoryMockRecorder) Find(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockSkuRepository)(nil).Find), arg0, arg1)
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockSkuRepository)(nil).Find), varargs...)
+}
+
+// Restore mocks base method.
+func (m *MockSkuRepository) Restore(arg0 context.Context, arg1 *domain.Sku) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockSkuRepositoryMockRecorder) Restore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockSkuRepository)(nil).Restore), arg0, arg1)
 }
 
 // Save mocks base method.
@@ -63,3 +82,31 @@ func (mr *MockSkuRepositoryMockRecorder) Save(arg0, arg1 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockSkuRepository)(nil).Save), arg0, arg1)
 }
+
+// Scan mocks base method.
+func (m *MockSkuRepository) Scan(arg0 context.Context, arg1 domain.SkuFilter, arg2 func(*domain.Sku) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockSkuRepositoryMockRecorder) Scan(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockSkuRepository)(nil).Scan), arg0, arg1, arg2)
+}
+
+// Update mocks base method.
+func (m *MockSkuRepository) Update(arg0 context.Context, arg1 *domain.Sku) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSkuRepositoryMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSkuRepository)(nil).Update), arg0, arg1)
+}
@@ -3,24 +3,200 @@ package domain
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
-var ErrSkuAlreadyExists = errors.New("sku already exists")
+var (
+	ErrSkuAlreadyExists = errors.New("sku already exists")
+	ErrSkuNotFound      = errors.New("sku not found")
+	// ErrSkuUnchanged means that an update didn't change any attribute of the sku, so nothing was stored.
+	ErrSkuUnchanged = errors.New("sku unchanged")
+)
+
+// OutcomeErrors are the errors of the commands that tell what they did, or didn't, to the sku instead of a failure:
+// executing the same command again returns the same error.
+var OutcomeErrors = []error{
+	ErrSkuAlreadyExists, ErrSkuUnchanged, ErrSkuNotFound, ErrSkuDeleted, ErrInvalidSku, ErrInvalidAttribute, ErrInvalidTenant,
+}
 
 //go:generate mockgen -destination=mock/sku_repository_mockgen_mock.go -package=mock . SkuRepository
 type SkuRepository interface {
-	Find(context.Context, *SkuId) (*Sku, error)
+	// Find returns the sku when it has any of the statuses, or whatever its status is when none is given.
+	// It returns nil when there's no such sku.
+	Find(ctx context.Context, id *SkuId, statuses ...Status) (*Sku, error)
+	// Save creates the sku or, when it already exists, records one more sighting of it and returns ErrSkuAlreadyExists.
+	// Both happen in a single atomic operation, so concurrent sightings of the same sku are never lost.
+	// A deleted sku is neither created again nor updated, Save returns ErrSkuDeleted instead. A new sku is
+	// stored with version 0, and recording a sighting doesn't change the version as it never conflicts.
 	Save(context.Context, *Sku) error
+	// Update stores the attributes and status of an existing sku and increments its version. It's conditional on the
+	// version of the sku: when the stored one is not the same anymore it returns ErrConcurrentModification, and it
+	// returns ErrSkuNotFound when the sku doesn't exist.
+	Update(context.Context, *Sku) error
+	// Scan calls fn with every sku selected by the filter ordered by key, as they were when the scan started: the
+	// changes made while it's scanning are not seen. It stops at the first error of fn and returns it.
+	Scan(ctx context.Context, filter SkuFilter, fn func(*Sku) error) error
+	// Restore stores the sku exactly as it is, replacing the stored one with the same id whatever its status,
+	// version and sightings. It's meant to restore the skus of a snapshot, not to handle the messages.
+	Restore(context.Context, *Sku) error
+}
+
+// SkuFilter selects the skus of a scan, the zero filter selects all of them.
+type SkuFilter struct {
+	// Tenant selects the skus of the tenant, the ones of every tenant are selected when it's empty.
+	Tenant Tenant
+	// Prefixes selects the skus whose value starts with any of them, whatever their value is when it's empty.
+	Prefixes []string
+	// Statuses selects the skus with any of the statuses, whatever their status is when it's empty.
+	Statuses []Status
+}
+
+// Matches returns whether the filter selects the sku.
+func (f SkuFilter) Matches(sku *Sku) bool {
+	if f.Tenant != "" && sku.id.tenant != f.Tenant {
+		return false
+	}
+
+	return f.matchesPrefix(sku.id.value) && f.matchesStatus(sku.status)
+}
+
+func (f SkuFilter) matchesPrefix(value string) bool {
+	for _, prefix := range f.Prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+
+	return len(f.Prefixes) == 0
 }
 
+func (f SkuFilter) matchesStatus(status Status) bool {
+	for _, filterStatus := range f.Statuses {
+		if status == filterStatus {
+			return true
+		}
+	}
+
+	return len(f.Statuses) == 0
+}
+
+// Sku keeps the attributes of the product, its status and track of every time it's received: when it was
+// seen for the first and last time, how many times it has been seen and the sources that sent it.
+// The version counts the updates of the stored sku, it's the one expected when the sku is updated.
 type Sku struct {
-	id *SkuId
+	id         *SkuId
+	attributes Attributes
+	status     Status
+	version    int
+	firstSeen  time.Time
+	lastSeen   time.Time
+	seenCount  int
+	sources    []string
 }
 
-func NewSku(id *SkuId) *Sku {
-	return &Sku{id: id}
+// NewSku returns an active sku seen once at seenAt, the source is not recorded when it's empty.
+func NewSku(id *SkuId, attributes Attributes, seenAt time.Time, source string) *Sku {
+	sku := &Sku{id: id, attributes: attributes, status: StatusActive, firstSeen: seenAt, lastSeen: seenAt, seenCount: 1}
+	sku.addSource(source)
+
+	return sku
 }
 
 func (s Sku) Id() *SkuId {
 	return s.id
 }
+
+func (s Sku) Attributes() Attributes {
+	return s.attributes
+}
+
+func (s Sku) Status() Status {
+	return s.status
+}
+
+func (s Sku) Version() int {
+	return s.version
+}
+
+func (s Sku) FirstSeen() time.Time {
+	return s.firstSeen
+}
+
+func (s Sku) LastSeen() time.Time {
+	return s.lastSeen
+}
+
+func (s Sku) SeenCount() int {
+	return s.seenCount
+}
+
+func (s Sku) Sources() []string {
+	return s.sources
+}
+
+// Update merges the attributes into the ones of the sku and returns ErrSkuUnchanged when none of them changed.
+// The attributes of a deleted sku can't be updated.
+func (s *Sku) Update(attributes Attributes) error {
+	if s.status == StatusDeleted {
+		return fmt.Errorf("%w: %s", ErrSkuDeleted, s.id.Value())
+	}
+	merged := s.attributes.Merge(attributes)
+	if merged.Equal(s.attributes) {
+		return fmt.Errorf("%w: %s", ErrSkuUnchanged, s.id.Value())
+	}
+	s.attributes = merged
+
+	return nil
+}
+
+func (s *Sku) Deactivate() error {
+	return s.changeStatus(StatusInactive)
+}
+
+// Reactivate returns ErrSkuDeleted when the sku was deleted, as a tombstone is never reactivated.
+func (s *Sku) Reactivate() error {
+	return s.changeStatus(StatusActive)
+}
+
+// Delete leaves the sku as a tombstone.
+func (s *Sku) Delete() error {
+	return s.changeStatus(StatusDeleted)
+}
+
+func (s *Sku) changeStatus(status Status) error {
+	if s.status == status {
+		return fmt.Errorf("%w: %s is already %s", ErrSkuUnchanged, s.id.Value(), status)
+	}
+	if s.status == StatusDeleted {
+		return fmt.Errorf("%w: %s", ErrSkuDeleted, s.id.Value())
+	}
+	s.status = status
+
+	return nil
+}
+
+// Seen records another sighting of the sku.
+func (s *Sku) Seen(seenAt time.Time, source string) {
+	if seenAt.Before(s.firstSeen) {
+		s.firstSeen = seenAt
+	}
+	if seenAt.After(s.lastSeen) {
+		s.lastSeen = seenAt
+	}
+	s.seenCount++
+	s.addSource(source)
+}
+
+func (s *Sku) addSource(source string) {
+	if source == "" {
+		return
+	}
+	for _, knownSource := range s.sources {
+		if knownSource == source {
+			return
+		}
+	}
+	s.sources = append(s.sources, source)
+}
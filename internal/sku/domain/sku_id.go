@@ -4,26 +4,109 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
+// SkuId is unique inside its tenant.
 type SkuId struct {
-	value string
+	tenant Tenant
+	value  string
 }
 
 func (i SkuId) Equal(anotherSkuId *SkuId) bool {
-	return anotherSkuId.value == i.value
+	return anotherSkuId.tenant == i.tenant && anotherSkuId.value == i.value
 }
 
 var ErrInvalidSku = errors.New("invalid Sku provided")
 func NewSkuId(value string) (*SkuId, error) {
-	match, err := regexp.MatchString("^[A-Z]{4}-[0-9]{4}$", value)
-	if err != nil || !match {
+	return DefaultSkuIdFormat.NewSkuId(value)
+}
+
+func (i *SkuId) Value() string {
+	return i.value
+}
+
+func (i *SkuId) Tenant() Tenant {
+	return i.tenant
+}
+
+// Key is unique across tenants: the value for the skus of the default tenant and the value prefixed with
+// the tenant and a colon for the rest of them.
+func (i *SkuId) Key() string {
+	if i.tenant == DefaultTenant {
+		return i.value
+	}
+
+	return i.tenant.String() + ":" + i.value
+}
+
+const DefaultSkuIdPattern = "^[A-Z]{4}-[0-9]{4}$"
+
+var DefaultSkuIdFormat = &SkuIdFormat{pattern: regexp.MustCompile(DefaultSkuIdPattern)}
+
+// DefaultSkuIdFormats validates the skus of every tenant with the DefaultSkuIdFormat.
+var DefaultSkuIdFormats = NewSkuIdFormats(DefaultSkuIdFormat, nil)
+
+// SkuIdFormat is the rule a sku value has to match to be a valid SkuId.
+type SkuIdFormat struct {
+	pattern *regexp.Regexp
+}
+
+var ErrInvalidSkuIdFormat = errors.New("invalid sku format")
+func NewSkuIdFormat(pattern string) (*SkuIdFormat, error) {
+	compiledPattern, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSkuIdFormat, err.Error())
+	}
+
+	return &SkuIdFormat{pattern: compiledPattern}, nil
+}
+
+// NewSkuId returns the id of an sku of the default tenant.
+func (f *SkuIdFormat) NewSkuId(value string) (*SkuId, error) {
+	return f.NewTenantSkuId(DefaultTenant, value)
+}
+
+// NewTenantSkuId returns the id of an sku of the tenant. A value with a colon is invalid whatever the format is, as
+// it's the separator of the tenant in the Key: "acme:ABCD-1234" of the default tenant would be ABCD-1234 of acme.
+func (f *SkuIdFormat) NewTenantSkuId(tenant Tenant, value string) (*SkuId, error) {
+	if strings.Contains(value, ":") || !f.pattern.MatchString(value) {
 		return nil, fmt.Errorf("%w: %s", ErrInvalidSku, value)
 	}
 
-	return &SkuId{value: value}, nil
+	return &SkuId{tenant: tenant, value: value}, nil
 }
 
-func (i *SkuId) Value() string {
-	return i.value
-}
\ No newline at end of file
+func (f *SkuIdFormat) Pattern() string {
+	return f.pattern.String()
+}
+
+// SkuIdFormats holds the format of each tenant, the tenants without their own format use the default one.
+type SkuIdFormats struct {
+	defaultFormat *SkuIdFormat
+	tenantFormats map[Tenant]*SkuIdFormat
+}
+
+func NewSkuIdFormats(defaultFormat *SkuIdFormat, tenantFormats map[Tenant]*SkuIdFormat) *SkuIdFormats {
+	return &SkuIdFormats{defaultFormat: defaultFormat, tenantFormats: tenantFormats}
+}
+
+func (f *SkuIdFormats) Format(tenant Tenant) *SkuIdFormat {
+	format, ok := f.tenantFormats[tenant]
+	if !ok {
+		return f.defaultFormat
+	}
+
+	return format
+}
+
+// NewSkuId returns the id of the sku in the tenant validated with the format of the tenant, an empty tenant
+// is the default one.
+func (f *SkuIdFormats) NewSkuId(tenant, value string) (*SkuId, error) {
+	skuTenant, err := NewTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Format(skuTenant).NewTenantSkuId(skuTenant, value)
+}
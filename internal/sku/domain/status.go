@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status is the lifecycle of an sku: an active sku can be deactivated and reactivated, and both can be deleted.
+// A deleted sku is kept as a tombstone, so it's never created again.
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+	StatusDeleted  Status = "deleted"
+)
+
+// ErrSkuDeleted means that the sku has a tombstone, so it can't be created, changed or reactivated.
+var ErrSkuDeleted = errors.New("sku deleted")
+
+var ErrInvalidStatus = errors.New("invalid sku status")
+
+func ParseStatus(value string) (Status, error) {
+	switch status := Status(value); status {
+	case StatusActive, StatusInactive, StatusDeleted:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidStatus, value)
+	}
+}
+
+func (s Status) String() string {
+	return string(s)
+}
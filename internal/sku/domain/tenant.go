@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Tenant is the retail brand an sku belongs to, the same sku value can exist in several tenants as different skus.
+type Tenant string
+
+// DefaultTenant owns the skus received without tenant, and all the skus stored before the tenants were introduced.
+const DefaultTenant Tenant = "default"
+
+var (
+	ErrInvalidTenant = errors.New("invalid tenant")
+	tenantPattern    = regexp.MustCompile("^[a-z0-9][a-z0-9_-]{0,62}$")
+)
+
+// NewTenant returns the tenant of the value, an empty value is the default tenant. A tenant is up to 63
+// lowercase letters, digits, dashes or underscores starting with a letter or a digit.
+func NewTenant(value string) (Tenant, error) {
+	if value == "" {
+		return DefaultTenant, nil
+	}
+	if !tenantPattern.MatchString(value) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTenant, value)
+	}
+
+	return Tenant(value), nil
+}
+
+func (t Tenant) String() string {
+	return string(t)
+}
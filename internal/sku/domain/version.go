@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrConcurrentModification means that the sku was modified by someone else since it was found, so the
+// modification was not stored and it has to be done again over the stored sku.
+var ErrConcurrentModification = errors.New("sku modified concurrently")
+
+const (
+	// ConcurrentModificationRetries is how many times a modification is retried after a concurrent one.
+	ConcurrentModificationRetries = 3
+	concurrentModificationBackoff = 5 * time.Millisecond
+)
+
+// RetryOnConcurrentModification calls modify again while it returns ErrConcurrentModification, up to
+// ConcurrentModificationRetries times, waiting a bit longer before each retry. Modify has to find the sku every
+// time it's called, so every retry works over the latest version. The last error is returned when the retries
+// run out or the context is done.
+func RetryOnConcurrentModification(ctx context.Context, modify func() error) error {
+	err := modify()
+	for retry := 1; retry <= ConcurrentModificationRetries && errors.Is(err, ErrConcurrentModification); retry++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(retry) * concurrentModificationBackoff):
+		}
+		err = modify()
+	}
+
+	return err
+}
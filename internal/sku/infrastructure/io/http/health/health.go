@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Check returns an error when the dependency it verifies is not usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Handler struct {
+	readinessChecks []namedCheck
+	timeout         time.Duration
+}
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+const (
+	statusOk       = "ok"
+	statusReady    = "ready"
+	statusNotReady = "not ready"
+)
+
+func NewHandler(timeout time.Duration) *Handler {
+	return &Handler{timeout: timeout}
+}
+
+// AddReadinessCheck registers a check that has to pass for /readyz to answer 200.
+func (h *Handler) AddReadinessCheck(name string, check Check) {
+	h.readinessChecks = append(h.readinessChecks, namedCheck{name: name, check: check})
+}
+
+func (h *Handler) ServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.liveness)
+	mux.HandleFunc("/readyz", h.readiness)
+
+	return mux
+}
+
+func (h *Handler) liveness(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(statusOk))
+}
+
+func (h *Handler) readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	response := readinessResponse{Status: statusReady, Checks: map[string]string{}}
+	statusCode := http.StatusOK
+	for _, readinessCheck := range h.readinessChecks {
+		if err := readinessCheck.check(ctx); err != nil {
+			response.Checks[readinessCheck.name] = err.Error()
+			response.Status = statusNotReady
+			statusCode = http.StatusServiceUnavailable
+			continue
+		}
+		response.Checks[readinessCheck.name] = statusOk
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,85 @@
+//+build unit
+
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/io/http/health"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	handler *health.Handler
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.handler = health.NewHandler(time.Second)
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestLivenessIsAlwaysOk() {
+	s.handler.AddReadinessCheck("failing", func(ctx context.Context) error {
+		return errors.New("mongo is down")
+	})
+
+	recorder := s.request("/healthz")
+	s.Require().Equal(http.StatusOK, recorder.Code)
+	s.Require().Equal("ok", recorder.Body.String())
+}
+
+func (s *UnitSuite) TestReadinessIsOkWhenAllChecksPass() {
+	s.handler.AddReadinessCheck("listener", func(ctx context.Context) error { return nil })
+	s.handler.AddReadinessCheck("mongo", func(ctx context.Context) error { return nil })
+
+	recorder := s.request("/readyz")
+	s.Require().Equal(http.StatusOK, recorder.Code)
+	body := s.decodeReadinessBody(recorder)
+	s.Require().Equal("ready", body["status"])
+	s.Require().Equal(map[string]interface{}{"listener": "ok", "mongo": "ok"}, body["checks"])
+}
+
+func (s *UnitSuite) TestReadinessIsServiceUnavailableWhenACheckFails() {
+	s.handler.AddReadinessCheck("listener", func(ctx context.Context) error { return nil })
+	s.handler.AddReadinessCheck("mongo", func(ctx context.Context) error { return errors.New("mongo is down") })
+
+	recorder := s.request("/readyz")
+	s.Require().Equal(http.StatusServiceUnavailable, recorder.Code)
+	body := s.decodeReadinessBody(recorder)
+	s.Require().Equal("not ready", body["status"])
+	s.Require().Equal(map[string]interface{}{"listener": "ok", "mongo": "mongo is down"}, body["checks"])
+}
+
+func (s *UnitSuite) TestReadinessChecksReceiveAContextWithTheConfiguredTimeout() {
+	s.handler = health.NewHandler(10 * time.Millisecond)
+	s.handler.AddReadinessCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	recorder := s.request("/readyz")
+	s.Require().Equal(http.StatusServiceUnavailable, recorder.Code)
+}
+
+func (s *UnitSuite) request(path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	s.handler.ServeMux().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return recorder
+}
+
+func (s *UnitSuite) decodeReadinessBody(recorder *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	s.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+
+	return body
+}
@@ -0,0 +1,149 @@
+package ipfilter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Rules holds the CIDRs (or single ips) allowed and denied. A denied ip is always rejected and,
+// when Allow is not empty, only the ips inside it are accepted.
+type Rules struct {
+	Allow []string
+	Deny  []string
+}
+
+type Filter struct {
+	mutex sync.RWMutex
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+func New(rules Rules) (*Filter, error) {
+	filter := &Filter{}
+	err := filter.Reload(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Reload replaces the rules in use. When any of the new rules is invalid the previous ones are kept.
+func (f *Filter) Reload(rules Rules) error {
+	allow, err := parseNetworks(rules.Allow)
+	if err != nil {
+		return err
+	}
+	deny, err := parseNetworks(rules.Deny)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.allow = allow
+	f.deny = deny
+
+	return nil
+}
+
+func (f *Filter) Allowed(ip net.IP) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if contains(f.deny, ip) {
+		return false
+	}
+
+	return len(f.allow) == 0 || contains(f.allow, ip)
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var ErrInvalidNetwork = errors.New("invalid ip or cidr")
+
+func parseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		network, err := parseNetwork(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+func parseNetwork(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidNetwork, value)
+		}
+		if ip.To4() != nil {
+			return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidNetwork, value)
+	}
+
+	return network, nil
+}
+
+var ErrInvalidRule = errors.New("invalid ip filter rule, expected 'allow <cidr>' or 'deny <cidr>'")
+
+// ParseRules reads one rule per line with the format "allow <cidr>" or "deny <cidr>".
+// Empty lines and lines starting with # are ignored.
+func ParseRules(reader io.Reader) (Rules, error) {
+	rules := Rules{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return Rules{}, fmt.Errorf("%w: %s", ErrInvalidRule, line)
+		}
+		switch fields[0] {
+		case "allow":
+			rules.Allow = append(rules.Allow, fields[1])
+		case "deny":
+			rules.Deny = append(rules.Deny, fields[1])
+		default:
+			return Rules{}, fmt.Errorf("%w: %s", ErrInvalidRule, line)
+		}
+	}
+
+	return rules, scanner.Err()
+}
+
+func ParseRulesFile(fileName string) (Rules, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return Rules{}, err
+	}
+	defer file.Close()
+
+	return ParseRules(file)
+}
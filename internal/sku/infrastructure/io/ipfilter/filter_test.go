@@ -0,0 +1,80 @@
+//+build unit
+
+package ipfilter_test
+
+import (
+	"feeder-service/internal/sku/infrastructure/io/ipfilter"
+	"github.com/stretchr/testify/suite"
+	"net"
+	"strings"
+	"testing"
+)
+
+type UnitSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestEverythingIsAllowedWithoutRules() {
+	filter := s.newFilter(ipfilter.Rules{})
+
+	s.Require().True(filter.Allowed(net.ParseIP("203.0.113.7")))
+}
+
+func (s *UnitSuite) TestOnlyAllowListedIpsAreAllowed() {
+	filter := s.newFilter(ipfilter.Rules{Allow: []string{"10.0.0.0/8", "192.168.1.10"}})
+
+	s.Require().True(filter.Allowed(net.ParseIP("10.20.30.40")))
+	s.Require().True(filter.Allowed(net.ParseIP("192.168.1.10")))
+	s.Require().False(filter.Allowed(net.ParseIP("192.168.1.11")))
+}
+
+func (s *UnitSuite) TestDenyListWinsOverAllowList() {
+	filter := s.newFilter(ipfilter.Rules{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.1.0.0/16"}})
+
+	s.Require().True(filter.Allowed(net.ParseIP("10.2.0.1")))
+	s.Require().False(filter.Allowed(net.ParseIP("10.1.0.1")))
+}
+
+func (s *UnitSuite) TestIpv6Networks() {
+	filter := s.newFilter(ipfilter.Rules{Deny: []string{"2001:db8::/32", "::1"}})
+
+	s.Require().False(filter.Allowed(net.ParseIP("2001:db8::1")))
+	s.Require().False(filter.Allowed(net.ParseIP("::1")))
+	s.Require().True(filter.Allowed(net.ParseIP("2001:db9::1")))
+}
+
+func (s *UnitSuite) TestReloadReplacesTheRules() {
+	filter := s.newFilter(ipfilter.Rules{Deny: []string{"127.0.0.1"}})
+	s.Require().False(filter.Allowed(net.ParseIP("127.0.0.1")))
+
+	s.Require().NoError(filter.Reload(ipfilter.Rules{}))
+	s.Require().True(filter.Allowed(net.ParseIP("127.0.0.1")))
+}
+
+func (s *UnitSuite) TestInvalidReloadKeepsThePreviousRules() {
+	filter := s.newFilter(ipfilter.Rules{Deny: []string{"127.0.0.1"}})
+
+	err := filter.Reload(ipfilter.Rules{Deny: []string{"not-an-ip"}})
+	s.Require().ErrorIs(err, ipfilter.ErrInvalidNetwork)
+	s.Require().False(filter.Allowed(net.ParseIP("127.0.0.1")))
+}
+
+func (s *UnitSuite) TestParseRules() {
+	rules, err := ipfilter.ParseRules(strings.NewReader("# office\nallow 10.0.0.0/8\n\ndeny 10.1.0.0/16\n"))
+	s.Require().NoError(err)
+	s.Require().Equal(ipfilter.Rules{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.1.0.0/16"}}, rules)
+
+	_, err = ipfilter.ParseRules(strings.NewReader("permit 10.0.0.0/8"))
+	s.Require().ErrorIs(err, ipfilter.ErrInvalidRule)
+}
+
+func (s *UnitSuite) newFilter(rules ipfilter.Rules) *ipfilter.Filter {
+	filter, err := ipfilter.New(rules)
+	s.Require().NoError(err)
+
+	return filter
+}
@@ -0,0 +1,185 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/application/command/deactivate_sku"
+	"feeder-service/internal/sku/application/command/delete_sku"
+	"feeder-service/internal/sku/application/command/reactivate_sku"
+	"feeder-service/internal/sku/application/command/update_sku"
+	"feeder-service/internal/sku/application/command/upsert_sku"
+	"fmt"
+	"strings"
+)
+
+// Payload is the content of an ingestion message. A message is either a plain sku line like "ABCD-1234"
+// or a JSON object with the sku and its attributes:
+//
+//	{"sku": "ABCD-1234", "name": "Shoes", "brand": "Acme", "price": 19.99, "currency": "EUR", "stock": 5, "category": "Footwear"}
+//
+// The op of a JSON message tells the command to execute, the plain messages always create the sku.
+// The tenant of a JSON message owns the sku, the plain messages and the ones without tenant belong to
+// the default tenant. The idempotency key of a JSON message makes its retries replay the result of the first one.
+type Payload struct {
+	Op             string      `json:"op,omitempty"`
+	IdempotencyKey string      `json:"idempotency_key,omitempty"`
+	Tenant         string      `json:"tenant,omitempty"`
+	Sku            string      `json:"sku"`
+	Name           string      `json:"name,omitempty"`
+	Brand          string      `json:"brand,omitempty"`
+	Category       string      `json:"category,omitempty"`
+	Price          json.Number `json:"price,omitempty"`
+	Currency       string      `json:"currency,omitempty"`
+	Stock          *int        `json:"stock,omitempty"`
+}
+
+const (
+	OpCreate     = "create"
+	OpUpdate     = "update"
+	OpUpsert     = "upsert"
+	OpDeactivate = "deactivate"
+	OpReactivate = "reactivate"
+	OpDelete     = "delete"
+)
+
+var ErrMalformedMessage = errors.New("malformed message")
+
+// ErrUnsupportedOperation is the error of a message whose op has no command handler.
+var ErrUnsupportedOperation = errors.New("unsupported operation")
+
+// Normalise returns the message as the server parses it, without the leading zeros the feeders pad the skus with.
+// The tools that read the skus from files normalise them too, so a line is handled as the server would.
+func Normalise(message string) string {
+	return strings.TrimLeft(message, "0")
+}
+
+// Parse returns the payload of a message, it only fails when a JSON message can't be decoded.
+func Parse(message string) (Payload, error) {
+	if !IsJSON(message) {
+		return Payload{Sku: message}, nil
+	}
+
+	var payload Payload
+	decoder := json.NewDecoder(strings.NewReader(message))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	err := decoder.Decode(&payload)
+	if err != nil {
+		return Payload{}, fmt.Errorf("%w: %s", ErrMalformedMessage, err.Error())
+	}
+	if decoder.More() {
+		return Payload{}, fmt.Errorf("%w: more than one JSON value", ErrMalformedMessage)
+	}
+	switch payload.Op {
+	case "", OpCreate, OpUpdate, OpUpsert, OpDeactivate, OpReactivate, OpDelete:
+	default:
+		return Payload{}, fmt.Errorf("%w: unknown op %q", ErrMalformedMessage, payload.Op)
+	}
+
+	return payload, nil
+}
+
+// IsJSON tells the JSON messages from the plain ones.
+func IsJSON(message string) bool {
+	return strings.HasPrefix(strings.TrimSpace(message), "{")
+}
+
+// Encode returns the JSON message of the payload, or the plain one when it only carries the sku.
+func (p Payload) Encode() (string, error) {
+	if p == (Payload{Sku: p.Sku}) {
+		return p.Sku, nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(p)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// Operation returns the op of the payload, create when it's not set.
+func (p Payload) Operation() string {
+	if p.Op == "" {
+		return OpCreate
+	}
+
+	return p.Op
+}
+
+// Command returns the command of the payload op, the source identifies who sent the payload.
+func (p Payload) Command(source string) bus.Command {
+	switch p.Operation() {
+	case OpUpdate:
+		return p.UpdateSkuCommand()
+	case OpUpsert:
+		return p.UpsertSkuCommand(source)
+	case OpDeactivate:
+		return p.DeactivateSkuCommand()
+	case OpReactivate:
+		return p.ReactivateSkuCommand()
+	case OpDelete:
+		return p.DeleteSkuCommand()
+	default:
+		return p.CreateSkuCommand(source)
+	}
+}
+
+func (p Payload) CreateSkuCommand(source string) create_sku.Command {
+	return create_sku.Command{
+		Sku:      p.Sku,
+		Tenant:   p.Tenant,
+		Name:     p.Name,
+		Brand:    p.Brand,
+		Category: p.Category,
+		Price:    p.Price.String(),
+		Currency: p.Currency,
+		Stock:    p.Stock,
+		Source:   source,
+	}
+}
+
+func (p Payload) UpdateSkuCommand() update_sku.Command {
+	return update_sku.Command{
+		Sku:      p.Sku,
+		Tenant:   p.Tenant,
+		Name:     p.Name,
+		Brand:    p.Brand,
+		Category: p.Category,
+		Price:    p.Price.String(),
+		Currency: p.Currency,
+		Stock:    p.Stock,
+	}
+}
+
+func (p Payload) UpsertSkuCommand(source string) upsert_sku.Command {
+	return upsert_sku.Command{
+		Sku:      p.Sku,
+		Tenant:   p.Tenant,
+		Name:     p.Name,
+		Brand:    p.Brand,
+		Category: p.Category,
+		Price:    p.Price.String(),
+		Currency: p.Currency,
+		Stock:    p.Stock,
+		Source:   source,
+	}
+}
+
+func (p Payload) DeactivateSkuCommand() deactivate_sku.Command {
+	return deactivate_sku.Command{Sku: p.Sku, Tenant: p.Tenant}
+}
+
+func (p Payload) ReactivateSkuCommand() reactivate_sku.Command {
+	return reactivate_sku.Command{Sku: p.Sku, Tenant: p.Tenant}
+}
+
+func (p Payload) DeleteSkuCommand() delete_sku.Command {
+	return delete_sku.Command{Sku: p.Sku, Tenant: p.Tenant}
+}
@@ -0,0 +1,128 @@
+//+build unit
+
+package payload_test
+
+import (
+	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/application/command/deactivate_sku"
+	"feeder-service/internal/sku/application/command/delete_sku"
+	"feeder-service/internal/sku/application/command/update_sku"
+	"feeder-service/internal/sku/application/command/upsert_sku"
+	"feeder-service/internal/sku/infrastructure/io/payload"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type UnitSuite struct {
+	suite.Suite
+}
+
+func TestUnitSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestPlainMessagesOnlyCarryTheSku() {
+	skuPayload, err := payload.Parse("ABCD-1234")
+	s.Require().NoError(err)
+	s.Require().Equal(payload.Payload{Sku: "ABCD-1234"}, skuPayload)
+}
+
+func (s *UnitSuite) TestJSONMessagesCarryTheAttributes() {
+	skuPayload, err := payload.Parse(`{"sku": "ABCD-1234", "name": "Shoes", "brand": "Acme", "price": 19.99, "currency": "EUR", "stock": 5, "category": "Footwear"}`)
+	s.Require().NoError(err)
+
+	stock := 5
+	s.Require().Equal(create_sku.Command{
+		Sku:      "ABCD-1234",
+		Name:     "Shoes",
+		Brand:    "Acme",
+		Category: "Footwear",
+		Price:    "19.99",
+		Currency: "EUR",
+		Stock:    &stock,
+		Source:   "10.0.0.1",
+	}, skuPayload.CreateSkuCommand("10.0.0.1"))
+}
+
+func (s *UnitSuite) TestTheTenantOfTheMessageIsCarriedToTheCommand() {
+	skuPayload, err := payload.Parse(`{"op": "delete", "tenant": "acme", "sku": "ABCD-1234"}`)
+	s.Require().NoError(err)
+	s.Require().Equal(delete_sku.Command{Sku: "ABCD-1234", Tenant: "acme"}, skuPayload.DeleteSkuCommand())
+	s.Require().Equal("acme", skuPayload.CreateSkuCommand("").Tenant)
+}
+
+func (s *UnitSuite) TestThePriceCanBeAString() {
+	skuPayload, err := payload.Parse(`{"sku": "ABCD-1234", "price": "19.90", "currency": "EUR"}`)
+	s.Require().NoError(err)
+	s.Require().Equal("19.90", skuPayload.CreateSkuCommand("").Price)
+}
+
+func (s *UnitSuite) TestTheOpOfTheMessageSelectsTheCommand() {
+	skuPayload, err := payload.Parse("ABCD-1234")
+	s.Require().NoError(err)
+	s.Require().Equal(payload.OpCreate, skuPayload.Operation())
+
+	skuPayload, err = payload.Parse(`{"op": "update", "sku": "ABCD-1234", "stock": 0}`)
+	s.Require().NoError(err)
+	s.Require().Equal(payload.OpUpdate, skuPayload.Operation())
+	stock := 0
+	s.Require().Equal(update_sku.Command{Sku: "ABCD-1234", Stock: &stock}, skuPayload.UpdateSkuCommand())
+
+	skuPayload, err = payload.Parse(`{"op": "upsert", "sku": "ABCD-1234", "brand": "Acme"}`)
+	s.Require().NoError(err)
+	s.Require().Equal(payload.OpUpsert, skuPayload.Operation())
+	s.Require().Equal(upsert_sku.Command{Sku: "ABCD-1234", Brand: "Acme", Source: "10.0.0.1"}, skuPayload.UpsertSkuCommand("10.0.0.1"))
+
+	skuPayload, err = payload.Parse(`{"op": "delete", "sku": "ABCD-1234"}`)
+	s.Require().NoError(err)
+	s.Require().Equal(payload.OpDelete, skuPayload.Operation())
+	s.Require().Equal(delete_sku.Command{Sku: "ABCD-1234"}, skuPayload.DeleteSkuCommand())
+}
+
+func (s *UnitSuite) TestTheCommandOfThePayloadIsTheOneOfItsOp() {
+	skuPayload, err := payload.Parse("ABCD-1234")
+	s.Require().NoError(err)
+	s.Require().Equal(create_sku.Command{Sku: "ABCD-1234", Source: "10.0.0.1"}, skuPayload.Command("10.0.0.1"))
+
+	skuPayload, err = payload.Parse(`{"op": "upsert", "sku": "ABCD-1234"}`)
+	s.Require().NoError(err)
+	s.Require().Equal(upsert_sku.Command{Sku: "ABCD-1234", Source: "10.0.0.1"}, skuPayload.Command("10.0.0.1"))
+
+	skuPayload, err = payload.Parse(`{"op": "deactivate", "tenant": "acme", "sku": "ABCD-1234"}`)
+	s.Require().NoError(err)
+	s.Require().Equal(deactivate_sku.Command{Sku: "ABCD-1234", Tenant: "acme"}, skuPayload.Command("10.0.0.1"))
+	s.Require().Equal(deactivate_sku.CommandName, skuPayload.Command("").CommandName())
+}
+
+func (s *UnitSuite) TestReturnErrMalformedMessageWhenTheJSONCantBeDecoded() {
+	for _, message := range []string{
+		`{"sku": "ABCD-1234"`,
+		`{"sku": "ABCD-1234", "colour": "red"}`,
+		`{"sku": "ABCD-1234", "stock": "five"}`,
+		`{"sku": "ABCD-1234"} {"sku": "ABCD-1235"}`,
+		`{"op": "archive", "sku": "ABCD-1234"}`,
+	} {
+		_, err := payload.Parse(message)
+		s.Require().ErrorIs(err, payload.ErrMalformedMessage, message)
+	}
+}
+
+func (s *UnitSuite) TestEncodeIsTheInverseOfParse() {
+	stock := 0
+	for _, skuPayload := range []payload.Payload{
+		{Sku: "ABCD-1234"},
+		{Sku: "ABCD-1234", Name: "Shoes & socks", Price: "19.99", Currency: "EUR", Stock: &stock},
+	} {
+		message, err := skuPayload.Encode()
+		s.Require().NoError(err)
+		parsed, err := payload.Parse(message)
+		s.Require().NoError(err)
+		s.Require().Equal(skuPayload, parsed)
+	}
+}
+
+func (s *UnitSuite) TestNormaliseTrimsTheLeadingZerosOnly() {
+	s.Require().Equal("ABCD-0100", payload.Normalise("00ABCD-0100"))
+	s.Require().Equal("abcd-0100", payload.Normalise("abcd-0100"))
+	s.Require().Equal(`{"sku": "ABCD-0100"}`, payload.Normalise(`{"sku": "ABCD-0100"}`))
+}
@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Policy decides what happens with a request that arrives when the client has no tokens left.
+type Policy string
+
+const (
+	PolicyReject Policy = "reject"
+	PolicyDelay  Policy = "delay"
+	PolicyDrop   Policy = "drop"
+)
+
+var ErrUnknownPolicy = errors.New("unknown rate limit overflow policy")
+
+func ParsePolicy(value string) (Policy, error) {
+	switch policy := Policy(value); policy {
+	case PolicyReject, PolicyDelay, PolicyDrop:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknownPolicy, value)
+}
+
+// Limit defines a token bucket refilled at RatePerSecond tokens per second holding at most Burst tokens.
+// A RatePerSecond of zero disables the limit.
+type Limit struct {
+	RatePerSecond float64
+	Burst         int
+	Policy        Policy
+}
+
+func (l Limit) enabled() bool {
+	return l.RatePerSecond > 0
+}
+
+func (l Limit) burst() int {
+	if l.Burst < 1 {
+		return 1
+	}
+
+	return l.Burst
+}
+
+type Config struct {
+	Default Limit
+	Clients map[string]Limit
+}
+
+func (c Config) limitFor(key string) Limit {
+	if limit, ok := c.Clients[key]; ok {
+		return limit
+	}
+
+	return c.Default
+}
+
+var (
+	ErrRejected = errors.New("rate limit exceeded, request rejected")
+	ErrDropped  = errors.New("rate limit exceeded, request dropped")
+)
+
+// minSweepSize is the number of buckets that starts the first sweep of the idle ones.
+const minSweepSize = 1024
+
+type Limiter struct {
+	mutex   sync.Mutex
+	config  Config
+	buckets map[string]*bucket
+	// sweepSize is the number of buckets that starts the next sweep, twice the ones left by the previous sweep,
+	// so the sweeps take a constant time per request.
+	sweepSize int
+}
+
+func New(config Config) *Limiter {
+	return &Limiter{config: config, buckets: map[string]*bucket{}, sweepSize: minSweepSize}
+}
+
+// Clients returns the number of clients with a bucket, the idle ones are removed as new clients arrive.
+func (l *Limiter) Clients() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.buckets)
+}
+
+// Update replaces the limits in use, the tokens already consumed by each client are kept.
+func (l *Limiter) Update(config Config) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.config = config
+}
+
+// Wait takes a token for the given client key. When there are no tokens left the client Limit policy applies:
+// PolicyReject returns ErrRejected, PolicyDrop returns ErrDropped and PolicyDelay blocks until a token is available,
+// returning ErrRejected if that would happen after the context deadline. It returns how long the caller was delayed.
+func (l *Limiter) Wait(ctx context.Context, key string) (time.Duration, error) {
+	delay, err := l.reserve(ctx, key)
+	if err != nil || delay == 0 {
+		return 0, err
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		l.release(key)
+		return 0, fmt.Errorf("%w: %s", ErrRejected, ctx.Err())
+	}
+}
+
+// release gives back the token taken for a delayed request that didn't wait for it.
+func (l *Limiter) release(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(float64(l.config.limitFor(key).burst()), b.tokens+1)
+	}
+}
+
+func (l *Limiter) reserve(ctx context.Context, key string) (time.Duration, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	limit := l.config.limitFor(key)
+	if !limit.enabled() {
+		return 0, nil
+	}
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.sweepSize {
+			l.sweep(now)
+		}
+		b = newBucket(limit, now)
+		l.buckets[key] = b
+	}
+	b.refill(limit, now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, nil
+	}
+
+	switch limit.Policy {
+	case PolicyDrop:
+		return 0, ErrDropped
+	case PolicyDelay:
+		delay := b.timeUntilNextToken(limit)
+		if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+			return 0, ErrRejected
+		}
+		b.tokens--
+		return delay, nil
+	default:
+		return 0, ErrRejected
+	}
+}
+
+// sweep removes the buckets refilled to their burst, a new bucket of their clients would be the same.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		limit := l.config.limitFor(key)
+		b.refill(limit, now)
+		if !limit.enabled() || b.tokens >= float64(limit.burst()) {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweepSize = 2 * len(l.buckets)
+	if l.sweepSize < minSweepSize {
+		l.sweepSize = minSweepSize
+	}
+}
+
+type bucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newBucket(limit Limit, now time.Time) *bucket {
+	return &bucket{tokens: float64(limit.burst()), lastRefill: now}
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens = math.Min(float64(limit.burst()), b.tokens+elapsed*limit.RatePerSecond)
+	b.lastRefill = now
+}
+
+func (b *bucket) timeUntilNextToken(limit Limit) time.Duration {
+	missingTokens := 1 - b.tokens
+	return time.Duration(missingTokens / limit.RatePerSecond * float64(time.Second))
+}
@@ -0,0 +1,162 @@
+//+build unit
+
+package ratelimit_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+const (
+	client        = "10.0.0.1"
+	anotherClient = "10.0.0.2"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestRequestsAreAllowedUntilTheBurstIsConsumed() {
+	limiter := ratelimit.New(ratelimit.Config{Default: s.slowLimit(3, ratelimit.PolicyReject)})
+
+	for i := 0; i < 3; i++ {
+		s.requireAllowed(limiter, client)
+	}
+	s.requireError(limiter, client, ratelimit.ErrRejected)
+}
+
+func (s *UnitSuite) TestEachClientHasItsOwnBucket() {
+	limiter := ratelimit.New(ratelimit.Config{Default: s.slowLimit(1, ratelimit.PolicyReject)})
+
+	s.requireAllowed(limiter, client)
+	s.requireAllowed(limiter, anotherClient)
+	s.requireError(limiter, client, ratelimit.ErrRejected)
+}
+
+func (s *UnitSuite) TestClientLimitOverridesTheDefaultOne() {
+	limiter := ratelimit.New(ratelimit.Config{
+		Default: s.slowLimit(1, ratelimit.PolicyReject),
+		Clients: map[string]ratelimit.Limit{anotherClient: s.slowLimit(1, ratelimit.PolicyDrop)},
+	})
+
+	s.requireAllowed(limiter, anotherClient)
+	s.requireError(limiter, anotherClient, ratelimit.ErrDropped)
+}
+
+func (s *UnitSuite) TestDisabledLimitAlwaysAllows() {
+	limiter := ratelimit.New(ratelimit.Config{})
+
+	for i := 0; i < 100; i++ {
+		s.requireAllowed(limiter, client)
+	}
+}
+
+func (s *UnitSuite) TestTokensAreRefilled() {
+	limiter := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{RatePerSecond: 100, Burst: 1, Policy: ratelimit.PolicyReject}})
+
+	s.requireAllowed(limiter, client)
+	s.requireError(limiter, client, ratelimit.ErrRejected)
+	time.Sleep(20 * time.Millisecond)
+	s.requireAllowed(limiter, client)
+}
+
+func (s *UnitSuite) TestDelayPolicyWaitsForTheNextToken() {
+	limiter := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{RatePerSecond: 20, Burst: 1, Policy: ratelimit.PolicyDelay}})
+
+	s.requireAllowed(limiter, client)
+	start := time.Now()
+	delay, err := limiter.Wait(s.ctx, client)
+	s.Require().NoError(err)
+	s.Require().Greater(delay, time.Duration(0))
+	s.Require().GreaterOrEqual(time.Since(start), delay)
+}
+
+func (s *UnitSuite) TestDelayPolicyRejectsWhenTheTokenArrivesAfterTheDeadline() {
+	limiter := ratelimit.New(ratelimit.Config{Default: s.slowLimit(1, ratelimit.PolicyDelay)})
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Millisecond)
+	defer cancel()
+
+	_, err := limiter.Wait(ctx, client)
+	s.Require().NoError(err)
+	_, err = limiter.Wait(ctx, client)
+	s.Require().ErrorIs(err, ratelimit.ErrRejected)
+}
+
+func (s *UnitSuite) TestACancelledDelayGivesTheTokenBack() {
+	limiter := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{RatePerSecond: 10, Burst: 1, Policy: ratelimit.PolicyDelay}})
+	s.requireAllowed(limiter, client)
+	ctx, cancel := context.WithCancel(s.ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := limiter.Wait(ctx, client)
+	s.Require().ErrorIs(err, ratelimit.ErrRejected)
+	delay, err := limiter.Wait(s.ctx, client)
+	s.Require().NoError(err)
+	s.Require().Less(delay, 100*time.Millisecond, "the next request only waits for the token of the first one")
+}
+
+func (s *UnitSuite) TestTheBucketsOfTheIdleClientsAreRemoved() {
+	limiter := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{RatePerSecond: 1000, Burst: 1, Policy: ratelimit.PolicyReject}})
+	for i := 0; i < 2000; i++ {
+		s.requireAllowed(limiter, fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	s.Require().Less(limiter.Clients(), 2000)
+
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 2000; i++ {
+		s.requireAllowed(limiter, fmt.Sprintf("10.1.%d.%d", i/256, i%256))
+	}
+	s.Require().LessOrEqual(limiter.Clients(), 2000)
+}
+
+func (s *UnitSuite) TestUpdateReplacesTheLimits() {
+	limiter := ratelimit.New(ratelimit.Config{Default: s.slowLimit(1, ratelimit.PolicyReject)})
+	s.requireAllowed(limiter, client)
+	s.requireError(limiter, client, ratelimit.ErrRejected)
+
+	limiter.Update(ratelimit.Config{Default: s.slowLimit(1, ratelimit.PolicyDrop)})
+	s.requireError(limiter, client, ratelimit.ErrDropped)
+
+	limiter.Update(ratelimit.Config{})
+	s.requireAllowed(limiter, client)
+}
+
+func (s *UnitSuite) TestParsePolicy() {
+	policy, err := ratelimit.ParsePolicy("delay")
+	s.Require().NoError(err)
+	s.Require().Equal(ratelimit.PolicyDelay, policy)
+
+	_, err = ratelimit.ParsePolicy("ignore")
+	s.Require().ErrorIs(err, ratelimit.ErrUnknownPolicy)
+}
+
+func (s *UnitSuite) slowLimit(burst int, policy ratelimit.Policy) ratelimit.Limit {
+	return ratelimit.Limit{RatePerSecond: 0.001, Burst: burst, Policy: policy}
+}
+
+func (s *UnitSuite) requireAllowed(limiter *ratelimit.Limiter, key string) {
+	delay, err := limiter.Wait(s.ctx, key)
+	s.Require().NoError(err)
+	s.Require().Zero(delay)
+}
+
+func (s *UnitSuite) requireError(limiter *ratelimit.Limiter, key string, expectedErr error) {
+	_, err := limiter.Wait(s.ctx, key)
+	s.Require().ErrorIs(err, expectedErr)
+}
@@ -0,0 +1,92 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Destination is where the formatted report is sent.
+type Destination interface {
+	Send(ctx context.Context, content []byte, contentType string) error
+}
+
+const DestinationStdout = "stdout"
+
+// NewDestination returns the destination of a target: stdout when it's empty or "stdout", an HTTP POST when
+// it's an http or https url and a file otherwise.
+func NewDestination(target string, httpClient *http.Client) Destination {
+	switch {
+	case target == "" || target == DestinationStdout:
+		return WriterDestination{Writer: os.Stdout}
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		return HTTPDestination{URL: target, Client: httpClient}
+	default:
+		return FileDestination{FileName: target}
+	}
+}
+
+type WriterDestination struct {
+	Writer io.Writer
+}
+
+func (d WriterDestination) Send(_ context.Context, content []byte, _ string) error {
+	_, err := d.Writer.Write(content)
+
+	return err
+}
+
+// FileDestination replaces the content of the file with the report, so it always holds the last one.
+type FileDestination struct {
+	FileName string
+}
+
+func (d FileDestination) Send(_ context.Context, content []byte, _ string) error {
+	return os.WriteFile(d.FileName, content, 0666)
+}
+
+type HTTPDestination struct {
+	URL    string
+	Client *http.Client
+}
+
+var ErrPostingReport = errors.New("error posting report")
+
+func (d HTTPDestination) Send(ctx context.Context, content []byte, contentType string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPostingReport, err.Error())
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPostingReport, err.Error())
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%w: %s answered %s", ErrPostingReport, d.URL, response.Status)
+	}
+
+	return nil
+}
+
+// Publish formats the summary and sends it to the destination.
+func Publish(ctx context.Context, summary Summary, formatter Formatter, destination Destination) error {
+	content, err := formatter.Format(summary)
+	if err != nil {
+		return err
+	}
+
+	return destination.Send(ctx, content, formatter.ContentType())
+}
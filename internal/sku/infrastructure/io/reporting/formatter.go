@@ -0,0 +1,182 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Formatter interface {
+	Format(Summary) ([]byte, error)
+	ContentType() string
+}
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var ErrUnknownFormat = errors.New("unknown report format")
+
+func NewFormatter(format string) (Formatter, error) {
+	switch format {
+	case FormatText:
+		return TextFormatter{}, nil
+	case FormatJSON:
+		return JSONFormatter{}, nil
+	case FormatCSV:
+		return CSVFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// TextFormatter writes the summary as English sentences for the humans reading the output.
+type TextFormatter struct{}
+
+func (TextFormatter) Format(summary Summary) ([]byte, error) {
+	var buffer bytes.Buffer
+	connections := summary.Connections
+	throttling := summary.Throttling
+	if summary.DryRun {
+		buffer.WriteString("Dry run, no sku was stored: the counters are what the run would have done\n")
+	}
+	fmt.Fprintf(&buffer, "Received %d unique product skus, %d duplicates, %d discard values\n",
+		summary.CreatedSkus, summary.DuplicatedSkus, summary.InvalidSkus)
+	fmt.Fprintf(&buffer, "Updated %d skus, %d unchanged, %d not found\n",
+		summary.UpdatedSkus, summary.UnchangedSkus, summary.NotFoundSkus)
+	fmt.Fprintf(&buffer, "Deactivated %d skus, %d reactivated, %d deleted, %d rejected by their tombstone\n",
+		summary.DeactivatedSkus, summary.ReactivatedSkus, summary.DeletedSkus, summary.TombstonedSkus)
+	if summary.ReplayedSkus > 0 {
+		fmt.Fprintf(&buffer, "Replayed %d retried messages by their idempotency key\n", summary.ReplayedSkus)
+	}
+	for _, reason := range sortedKeys(summary.InvalidReasons) {
+		fmt.Fprintf(&buffer, "Discarded %d values by %s\n", summary.InvalidReasons[reason], reason)
+	}
+	for _, tenant := range sortedTenants(summary.Tenants) {
+		counters := summary.Tenants[tenant]
+		fmt.Fprintf(&buffer, "Tenant %s received %d unique product skus, %d duplicates, %d updated, %d discard values, %d over its quota\n",
+			tenant, counters["created_skus"], counters["duplicated_skus"], counters["updated_skus"], counters["invalid_skus"],
+			counters["quota_exceeded"])
+	}
+	fmt.Fprintf(&buffer, "Accepted %d connections that sent %d messages\n", connections.Accepted, connections.Messages)
+	fmt.Fprintf(&buffer, "Denied %d connections by the ip filter\n", connections.Denied)
+	fmt.Fprintf(&buffer, "Closed %d idle connections, %d slow connections and %d too long messages\n",
+		connections.IdleTimeouts, connections.ReadTimeouts, connections.OversizedMessages)
+	fmt.Fprintf(&buffer, "Throttled %d rejected, %d delayed, %d dropped messages\n",
+		throttling.Rejected, throttling.Delayed, throttling.Dropped)
+	if connections.GracePeriodExceeded {
+		fmt.Fprintf(&buffer, "Force closed %d connections after the grace period\n", connections.ForceClosed)
+	}
+	fmt.Fprintf(&buffer, "Ran for %s at %.2f skus per second\n",
+		time.Duration(summary.DurationSeconds*float64(time.Second)).Round(time.Millisecond), summary.Throughput)
+
+	return buffer.Bytes(), nil
+}
+
+func (TextFormatter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(summary Summary) ([]byte, error) {
+	content, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+func (JSONFormatter) ContentType() string {
+	return "application/json"
+}
+
+// CSVFormatter writes a header and a single row, the invalid reasons and the throttled clients are flattened
+// into their own columns sorted by name, so the columns only change when a new reason or client shows up.
+type CSVFormatter struct{}
+
+func (CSVFormatter) Format(summary Summary) ([]byte, error) {
+	connections := summary.Connections
+	throttling := summary.Throttling
+	header := []string{
+		"started_at", "ended_at", "duration_seconds", "throughput",
+		"created_skus", "duplicated_skus", "updated_skus", "unchanged_skus", "not_found_skus",
+		"deactivated_skus", "reactivated_skus", "deleted_skus", "tombstoned_skus", "replayed_skus", "invalid_skus",
+		"accepted_connections", "received_messages", "denied_connections", "idle_timeouts", "read_timeouts", "oversized_messages",
+		"force_closed_connections", "grace_period_exceeded",
+		"throttled_rejected", "throttled_delayed", "throttled_dropped", "dry_run",
+	}
+	row := []string{
+		summary.StartedAt.Format(time.RFC3339), summary.EndedAt.Format(time.RFC3339),
+		formatFloat(summary.DurationSeconds), formatFloat(summary.Throughput),
+		strconv.Itoa(summary.CreatedSkus), strconv.Itoa(summary.DuplicatedSkus), strconv.Itoa(summary.UpdatedSkus),
+		strconv.Itoa(summary.UnchangedSkus), strconv.Itoa(summary.NotFoundSkus),
+		strconv.Itoa(summary.DeactivatedSkus), strconv.Itoa(summary.ReactivatedSkus), strconv.Itoa(summary.DeletedSkus),
+		strconv.Itoa(summary.TombstonedSkus), strconv.Itoa(summary.ReplayedSkus), strconv.Itoa(summary.InvalidSkus),
+		strconv.Itoa(connections.Accepted), strconv.Itoa(connections.Messages), strconv.Itoa(connections.Denied), strconv.Itoa(connections.IdleTimeouts),
+		strconv.Itoa(connections.ReadTimeouts), strconv.Itoa(connections.OversizedMessages),
+		strconv.Itoa(connections.ForceClosed), strconv.FormatBool(connections.GracePeriodExceeded),
+		strconv.Itoa(throttling.Rejected), strconv.Itoa(throttling.Delayed), strconv.Itoa(throttling.Dropped),
+		strconv.FormatBool(summary.DryRun),
+	}
+	for _, reason := range sortedKeys(summary.InvalidReasons) {
+		header = append(header, "invalid_skus_"+reason)
+		row = append(row, strconv.Itoa(summary.InvalidReasons[reason]))
+	}
+	for _, client := range sortedKeys(throttling.Clients) {
+		header = append(header, "throttled_client_"+client)
+		row = append(row, strconv.Itoa(throttling.Clients[client]))
+	}
+	for _, tenant := range sortedTenants(summary.Tenants) {
+		counters := summary.Tenants[tenant]
+		for _, name := range sortedKeys(counters) {
+			header = append(header, "tenant_"+tenant+"_"+name)
+			row = append(row, strconv.Itoa(counters[name]))
+		}
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	err := writer.WriteAll([][]string{header, row})
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (CSVFormatter) ContentType() string {
+	return "text/csv"
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 3, 64)
+}
+
+func sortedKeys(values map[string]int) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedTenants(tenants map[string]map[string]int) []string {
+	keys := make([]string, 0, len(tenants))
+	for key := range tenants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
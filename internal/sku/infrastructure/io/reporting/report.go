@@ -0,0 +1,323 @@
+package reporting
+
+import (
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/application/command/deactivate_sku"
+	"feeder-service/internal/sku/application/command/delete_sku"
+	"feeder-service/internal/sku/application/command/reactivate_sku"
+	"feeder-service/internal/sku/application/command/update_sku"
+	"feeder-service/internal/sku/application/command/upsert_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/payload"
+)
+
+// SkuCounters counts the messages by the result of their command.
+type SkuCounters struct {
+	CreatedSkus    int
+	DuplicatedSkus int
+	UpdatedSkus    int
+	// UnchangedSkus counts the updates and upserts that didn't change any attribute.
+	UnchangedSkus int
+	// NotFoundSkus counts the updates and status changes of skus that don't exist.
+	NotFoundSkus    int
+	DeactivatedSkus int
+	ReactivatedSkus int
+	DeletedSkus     int
+	// TombstonedSkus counts the messages of deleted skus, rejected because of their tombstone.
+	TombstonedSkus int
+	// ReplayedSkus counts the retries of messages already handled with the same idempotency key, they're not
+	// counted again by the result of their command.
+	ReplayedSkus int
+	InvalidSkus  int
+}
+
+// Report counts the results of the skus of a run, whatever transport they came from: the messages of the socket
+// server, or the lines of an import.
+type Report struct {
+	SkuCounters
+	// InvalidReasons holds the count of invalid skus of each reason, see the InvalidReason constants.
+	InvalidReasons map[string]int
+	// Tenants breaks down the sku counters by tenant, the messages with an invalid tenant are left out.
+	Tenants map[string]TenantReport
+	// AcceptedConnections counts the connections that sent an sku, the rejected ones are counted by reason.
+	AcceptedConnections int
+	// ReceivedMessages counts the messages read from the accepted connections, a session sends several of them.
+	ReceivedMessages  int
+	DeniedConnections int
+	IdleTimeouts      int
+	ReadTimeouts      int
+	OversizedMessages int
+	Throttling        ThrottlingReport
+	// GracePeriodExceeded is true when the shutdown had to close ForceClosedConnections connections.
+	GracePeriodExceeded    bool
+	ForceClosedConnections int
+	// DryRun is true when no sku was stored, the counters are the results the messages would have had.
+	DryRun bool
+}
+
+type TenantReport struct {
+	SkuCounters
+	// QuotaExceeded counts the messages discarded because the tenant reached its quota, they're invalid skus too.
+	QuotaExceeded int
+}
+
+// ThrottlingReport counts the messages affected by the rate limiter, Clients holds the count of each client.
+type ThrottlingReport struct {
+	Rejected int
+	Delayed  int
+	Dropped  int
+	Clients  map[string]int
+}
+
+const (
+	InvalidReasonFormat      = "invalid_format"
+	InvalidReasonAttributes  = "invalid_attributes"
+	InvalidReasonMalformed   = "malformed_message"
+	InvalidReasonUnsupported = "unsupported_operation"
+	InvalidReasonPersistence = "persistence_error"
+	// InvalidReasonConflict is a modification that kept conflicting with concurrent ones after every retry.
+	InvalidReasonConflict = "concurrent_modification"
+	InvalidReasonTenant   = "invalid_tenant"
+	InvalidReasonQuota    = "quota_exceeded"
+	InvalidReasonUnknown  = "unknown"
+)
+
+// ErrQuotaExceeded is the error of the skus discarded because their tenant reached its quota, see InvalidReasonQuota.
+var ErrQuotaExceeded = errors.New("tenant quota exceeded")
+
+// Copy returns a report that doesn't share its maps with the original, so it can be read while the original is
+// still counting.
+func (r *Report) Copy() Report {
+	copied := *r
+	if r.InvalidReasons != nil {
+		copied.InvalidReasons = make(map[string]int, len(r.InvalidReasons))
+		for reason, count := range r.InvalidReasons {
+			copied.InvalidReasons[reason] = count
+		}
+	}
+	if r.Tenants != nil {
+		copied.Tenants = make(map[string]TenantReport, len(r.Tenants))
+		for tenant, tenantReport := range r.Tenants {
+			copied.Tenants[tenant] = tenantReport
+		}
+	}
+	if r.Throttling.Clients != nil {
+		copied.Throttling.Clients = make(map[string]int, len(r.Throttling.Clients))
+		for client, count := range r.Throttling.Clients {
+			copied.Throttling.Clients[client] = count
+		}
+	}
+
+	return copied
+}
+
+// Counters flattens the report into named counters, so it can be stored and compared with other runs.
+func (r Report) Counters() map[string]int {
+	counters := map[string]int{
+		"denied_connections":       r.DeniedConnections,
+		"idle_timeouts":            r.IdleTimeouts,
+		"read_timeouts":            r.ReadTimeouts,
+		"oversized_messages":       r.OversizedMessages,
+		"throttled_rejected":       r.Throttling.Rejected,
+		"throttled_delayed":        r.Throttling.Delayed,
+		"throttled_dropped":        r.Throttling.Dropped,
+		"accepted_connections":     r.AcceptedConnections,
+		"received_messages":        r.ReceivedMessages,
+		"force_closed_connections": r.ForceClosedConnections,
+	}
+	for name, count := range r.SkuCounters.Counters() {
+		counters[name] = count
+	}
+	for reason, count := range r.InvalidReasons {
+		counters["invalid_skus_"+reason] = count
+	}
+	for tenant, tenantReport := range r.Tenants {
+		for name, count := range tenantReport.Counters() {
+			counters["tenant_"+tenant+"_"+name] = count
+		}
+	}
+
+	return counters
+}
+
+// Counters names each sku counter.
+func (c SkuCounters) Counters() map[string]int {
+	return map[string]int{
+		"created_skus":     c.CreatedSkus,
+		"duplicated_skus":  c.DuplicatedSkus,
+		"updated_skus":     c.UpdatedSkus,
+		"unchanged_skus":   c.UnchangedSkus,
+		"not_found_skus":   c.NotFoundSkus,
+		"deactivated_skus": c.DeactivatedSkus,
+		"reactivated_skus": c.ReactivatedSkus,
+		"deleted_skus":     c.DeletedSkus,
+		"tombstoned_skus":  c.TombstonedSkus,
+		"replayed_skus":    c.ReplayedSkus,
+		"invalid_skus":     c.InvalidSkus,
+	}
+}
+
+// Counters names the sku counters of the tenant and its quota exceeded messages.
+func (r TenantReport) Counters() map[string]int {
+	counters := r.SkuCounters.Counters()
+	counters["quota_exceeded"] = r.QuotaExceeded
+
+	return counters
+}
+
+// Result is what the command of a message did to the sku when it didn't fail.
+type Result int
+
+const (
+	ResultNone Result = iota
+	ResultCreated
+	ResultUpdated
+	ResultDeactivated
+	ResultReactivated
+	ResultDeleted
+)
+
+// RecordCreateResult counts the result of a create_sku command, like the skus of an import. The error is the one
+// returned by the command bus.
+func (r *Report) RecordCreateResult(tenant string, err error) {
+	r.RecordResult(tenant, ResultCreated, err)
+}
+
+// RecordResult counts the result of the command of a message, or the reason why it failed, in the report and
+// in the report of its tenant. The tenant is empty when the message has no valid tenant.
+func (r *Report) RecordResult(tenant string, result Result, err error) {
+	if !r.SkuCounters.count(result, err) {
+		r.recordInvalidReason(err)
+	}
+	if tenant == "" {
+		return
+	}
+
+	if r.Tenants == nil {
+		r.Tenants = map[string]TenantReport{}
+	}
+	tenantReport := r.Tenants[tenant]
+	tenantReport.count(result, err)
+	if errors.Is(err, ErrQuotaExceeded) {
+		tenantReport.QuotaExceeded++
+	}
+	r.Tenants[tenant] = tenantReport
+}
+
+// Outcomes name what the command of a message did to the sku, each one is counted by its own sku counter.
+const (
+	OutcomeCreated     = "created"
+	OutcomeDuplicated  = "duplicated"
+	OutcomeUpdated     = "updated"
+	OutcomeUnchanged   = "unchanged"
+	OutcomeNotFound    = "not_found"
+	OutcomeDeactivated = "deactivated"
+	OutcomeReactivated = "reactivated"
+	OutcomeDeleted     = "deleted"
+	OutcomeTombstoned  = "tombstoned"
+	OutcomeInvalid     = "invalid"
+)
+
+// Outcome returns the outcome of the result of the command of a message, or of the error it failed with. A replay
+// has the outcome of the original command.
+func Outcome(result Result, err error) string {
+	var replay *bus.ReplayError
+	if errors.As(err, &replay) {
+		err = replay.Err
+	}
+
+	switch {
+	case err == nil && result == ResultCreated:
+		return OutcomeCreated
+	case err == nil && result == ResultUpdated:
+		return OutcomeUpdated
+	case err == nil && result == ResultDeactivated:
+		return OutcomeDeactivated
+	case err == nil && result == ResultReactivated:
+		return OutcomeReactivated
+	case err == nil && result == ResultDeleted:
+		return OutcomeDeleted
+	case errors.Is(err, domain.ErrSkuAlreadyExists):
+		return OutcomeDuplicated
+	case errors.Is(err, domain.ErrSkuUnchanged):
+		return OutcomeUnchanged
+	case errors.Is(err, domain.ErrSkuNotFound):
+		return OutcomeNotFound
+	case errors.Is(err, domain.ErrSkuDeleted):
+		return OutcomeTombstoned
+	default:
+		return OutcomeInvalid
+	}
+}
+
+// count adds the result of the command of a message, it returns false when the message is invalid. A replay is only
+// counted as replayed, the original command was already counted by its outcome.
+func (c *SkuCounters) count(result Result, err error) bool {
+	if errors.Is(err, bus.ErrReplayed) {
+		c.ReplayedSkus++
+		return true
+	}
+
+	switch Outcome(result, err) {
+	case OutcomeCreated:
+		c.CreatedSkus++
+	case OutcomeUpdated:
+		c.UpdatedSkus++
+	case OutcomeDeactivated:
+		c.DeactivatedSkus++
+	case OutcomeReactivated:
+		c.ReactivatedSkus++
+	case OutcomeDeleted:
+		c.DeletedSkus++
+	case OutcomeDuplicated:
+		c.DuplicatedSkus++
+	case OutcomeUnchanged:
+		c.UnchangedSkus++
+	case OutcomeNotFound:
+		c.NotFoundSkus++
+	case OutcomeTombstoned:
+		c.TombstonedSkus++
+	default:
+		c.InvalidSkus++
+		return false
+	}
+
+	return true
+}
+
+// recordInvalidReason counts an invalid sku by the reason of the command handler error.
+func (r *Report) recordInvalidReason(err error) {
+	if r.InvalidReasons == nil {
+		r.InvalidReasons = map[string]int{}
+	}
+	r.InvalidReasons[InvalidReason(err)]++
+}
+
+// InvalidReason returns the InvalidReason constant of the error of an invalid sku.
+func InvalidReason(err error) string {
+	reason := InvalidReasonUnknown
+	switch {
+	case errors.Is(err, domain.ErrInvalidSku):
+		reason = InvalidReasonFormat
+	case errors.Is(err, domain.ErrInvalidAttribute):
+		reason = InvalidReasonAttributes
+	case errors.Is(err, payload.ErrMalformedMessage):
+		reason = InvalidReasonMalformed
+	case errors.Is(err, payload.ErrUnsupportedOperation):
+		reason = InvalidReasonUnsupported
+	case errors.Is(err, domain.ErrConcurrentModification):
+		reason = InvalidReasonConflict
+	case errors.Is(err, domain.ErrInvalidTenant):
+		reason = InvalidReasonTenant
+	case errors.Is(err, ErrQuotaExceeded):
+		reason = InvalidReasonQuota
+	case errors.Is(err, create_sku.ErrCreatingSku), errors.Is(err, update_sku.ErrUpdatingSku), errors.Is(err, upsert_sku.ErrUpsertingSku),
+		errors.Is(err, deactivate_sku.ErrDeactivatingSku), errors.Is(err, reactivate_sku.ErrReactivatingSku),
+		errors.Is(err, delete_sku.ErrDeletingSku), errors.Is(err, bus.ErrIdempotencyStore):
+		reason = InvalidReasonPersistence
+	}
+
+	return reason
+}
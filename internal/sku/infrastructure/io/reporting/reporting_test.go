@@ -0,0 +1,139 @@
+//+build unit
+
+package reporting_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx     context.Context
+	summary reporting.Summary
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	startedAt := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	s.summary = reporting.NewSummary(reporting.Report{
+		SkuCounters:    reporting.SkuCounters{CreatedSkus: 6, DuplicatedSkus: 2, InvalidSkus: 2},
+		InvalidReasons: map[string]int{reporting.InvalidReasonFormat: 2},
+		Tenants: map[string]reporting.TenantReport{
+			"acme": {SkuCounters: reporting.SkuCounters{CreatedSkus: 4, InvalidSkus: 1}, QuotaExceeded: 1},
+		},
+		AcceptedConnections: 10,
+		ReceivedMessages:    12,
+		DeniedConnections:   1,
+		Throttling:          reporting.ThrottlingReport{Rejected: 1, Clients: map[string]int{"127.0.0.1": 1}},
+	}, startedAt, startedAt.Add(5*time.Second))
+}
+
+func TestUnitSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestSummaryDerivesDurationAndThroughput() {
+	s.Require().Equal(5.0, s.summary.DurationSeconds)
+	s.Require().Equal(2.0, s.summary.Throughput)
+}
+
+func (s *UnitSuite) TestTextFormatterKeepsTheReportSentences() {
+	content, err := reporting.TextFormatter{}.Format(s.summary)
+	s.Require().NoError(err)
+	s.Require().Contains(string(content), "Received 6 unique product skus, 2 duplicates, 2 discard values\n")
+	s.Require().Contains(string(content), "Discarded 2 values by invalid_format\n")
+	s.Require().Contains(string(content), "Tenant acme received 4 unique product skus, 0 duplicates, 0 updated, 1 discard values, 1 over its quota\n")
+	s.Require().Contains(string(content), "Accepted 10 connections that sent 12 messages\n")
+	s.Require().Contains(string(content), "Denied 1 connections by the ip filter\n")
+	s.Require().Contains(string(content), "Ran for 5s at 2.00 skus per second\n")
+	s.Require().NotContains(string(content), "Dry run")
+}
+
+func (s *UnitSuite) TestTextFormatterStartsWithTheDryRunWarning() {
+	s.summary.DryRun = true
+	content, err := reporting.TextFormatter{}.Format(s.summary)
+	s.Require().NoError(err)
+	s.Require().True(strings.HasPrefix(string(content), "Dry run, no sku was stored: the counters are what the run would have done\n"))
+}
+
+func (s *UnitSuite) TestJSONFormatterWritesAllTheFields() {
+	content, err := reporting.JSONFormatter{}.Format(s.summary)
+	s.Require().NoError(err)
+
+	var decoded reporting.Summary
+	s.Require().NoError(json.Unmarshal(content, &decoded))
+	s.Require().Equal(s.summary, decoded)
+}
+
+func (s *UnitSuite) TestCSVFormatterWritesAHeaderAndARow() {
+	content, err := reporting.CSVFormatter{}.Format(s.summary)
+	s.Require().NoError(err)
+
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	s.Require().NoError(err)
+	s.Require().Len(records, 2)
+	row := map[string]string{}
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	s.Require().Equal("6", row["created_skus"])
+	s.Require().Equal("2.000", row["throughput"])
+	s.Require().Equal("2", row["invalid_skus_invalid_format"])
+	s.Require().Equal("1", row["throttled_client_127.0.0.1"])
+	s.Require().Equal("4", row["tenant_acme_created_skus"])
+	s.Require().Equal("1", row["tenant_acme_quota_exceeded"])
+	s.Require().Equal("false", row["dry_run"])
+}
+
+func (s *UnitSuite) TestUnknownFormatIsRejected() {
+	_, err := reporting.NewFormatter("xml")
+	s.Require().ErrorIs(err, reporting.ErrUnknownFormat)
+}
+
+func (s *UnitSuite) TestFileDestinationWritesTheReport() {
+	fileName := filepath.Join(s.T().TempDir(), "report.json")
+	destination := reporting.NewDestination(fileName, nil)
+
+	s.Require().NoError(reporting.Publish(s.ctx, s.summary, reporting.JSONFormatter{}, destination))
+	content, err := os.ReadFile(fileName)
+	s.Require().NoError(err)
+	s.Require().Contains(string(content), `"created_skus":6`)
+}
+
+func (s *UnitSuite) TestHTTPDestinationPostsTheReport() {
+	var contentType, body string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		content, _ := io.ReadAll(r.Body)
+		body = string(content)
+	}))
+	defer httpServer.Close()
+	destination := reporting.NewDestination(httpServer.URL, httpServer.Client())
+
+	s.Require().NoError(reporting.Publish(s.ctx, s.summary, reporting.CSVFormatter{}, destination))
+	s.Require().Equal("text/csv", contentType)
+	s.Require().True(strings.HasPrefix(body, "started_at,"))
+}
+
+func (s *UnitSuite) TestHTTPDestinationFailsWhenTheReportIsNotAccepted() {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer httpServer.Close()
+	destination := reporting.NewDestination(httpServer.URL, httpServer.Client())
+
+	err := reporting.Publish(s.ctx, s.summary, reporting.TextFormatter{}, destination)
+	s.Require().ErrorIs(err, reporting.ErrPostingReport)
+}
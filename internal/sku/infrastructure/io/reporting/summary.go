@@ -0,0 +1,109 @@
+package reporting
+
+import (
+	"time"
+)
+
+// Summary is the report of a run extended with the figures derived from it, it's what the formatters write.
+type Summary struct {
+	StartedAt       time.Time `json:"started_at"`
+	EndedAt         time.Time `json:"ended_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	// Throughput is the number of skus handled per second, whatever their result.
+	Throughput      float64        `json:"throughput"`
+	CreatedSkus     int            `json:"created_skus"`
+	DuplicatedSkus  int            `json:"duplicated_skus"`
+	UpdatedSkus     int            `json:"updated_skus"`
+	UnchangedSkus   int            `json:"unchanged_skus"`
+	NotFoundSkus    int            `json:"not_found_skus"`
+	DeactivatedSkus int            `json:"deactivated_skus"`
+	ReactivatedSkus int            `json:"reactivated_skus"`
+	DeletedSkus     int            `json:"deleted_skus"`
+	TombstonedSkus  int            `json:"tombstoned_skus"`
+	ReplayedSkus    int            `json:"replayed_skus"`
+	InvalidSkus     int            `json:"invalid_skus"`
+	InvalidReasons  map[string]int `json:"invalid_reasons"`
+	// Tenants holds the named sku counters of each tenant.
+	Tenants     map[string]map[string]int `json:"tenants"`
+	Connections ConnectionStats           `json:"connections"`
+	Throttling  ThrottlingStats           `json:"throttling"`
+	// DryRun is true when the skus were validated without storing them, see server.WithDryRun.
+	DryRun bool `json:"dry_run"`
+}
+
+type ConnectionStats struct {
+	Accepted            int  `json:"accepted"`
+	Messages            int  `json:"messages"`
+	Denied              int  `json:"denied"`
+	IdleTimeouts        int  `json:"idle_timeouts"`
+	ReadTimeouts        int  `json:"read_timeouts"`
+	OversizedMessages   int  `json:"oversized_messages"`
+	ForceClosed         int  `json:"force_closed"`
+	GracePeriodExceeded bool `json:"grace_period_exceeded"`
+}
+
+type ThrottlingStats struct {
+	Rejected int            `json:"rejected"`
+	Delayed  int            `json:"delayed"`
+	Dropped  int            `json:"dropped"`
+	Clients  map[string]int `json:"clients"`
+}
+
+func NewSummary(report Report, startedAt, endedAt time.Time) Summary {
+	duration := endedAt.Sub(startedAt).Seconds()
+	var throughput float64
+	if duration > 0 {
+		handled := report.CreatedSkus + report.DuplicatedSkus + report.UpdatedSkus + report.UnchangedSkus + report.NotFoundSkus +
+			report.DeactivatedSkus + report.ReactivatedSkus + report.DeletedSkus + report.TombstonedSkus + report.ReplayedSkus + report.InvalidSkus
+		throughput = float64(handled) / duration
+	}
+	invalidReasons := report.InvalidReasons
+	if invalidReasons == nil {
+		invalidReasons = map[string]int{}
+	}
+	tenants := map[string]map[string]int{}
+	for tenant, tenantReport := range report.Tenants {
+		tenants[tenant] = tenantReport.Counters()
+	}
+	throttledClients := report.Throttling.Clients
+	if throttledClients == nil {
+		throttledClients = map[string]int{}
+	}
+
+	return Summary{
+		StartedAt:       startedAt,
+		EndedAt:         endedAt,
+		DurationSeconds: duration,
+		Throughput:      throughput,
+		DryRun:          report.DryRun,
+		CreatedSkus:     report.CreatedSkus,
+		DuplicatedSkus:  report.DuplicatedSkus,
+		UpdatedSkus:     report.UpdatedSkus,
+		UnchangedSkus:   report.UnchangedSkus,
+		NotFoundSkus:    report.NotFoundSkus,
+		DeactivatedSkus: report.DeactivatedSkus,
+		ReactivatedSkus: report.ReactivatedSkus,
+		DeletedSkus:     report.DeletedSkus,
+		TombstonedSkus:  report.TombstonedSkus,
+		ReplayedSkus:    report.ReplayedSkus,
+		InvalidSkus:     report.InvalidSkus,
+		InvalidReasons:  invalidReasons,
+		Tenants:         tenants,
+		Connections: ConnectionStats{
+			Accepted:            report.AcceptedConnections,
+			Messages:            report.ReceivedMessages,
+			Denied:              report.DeniedConnections,
+			IdleTimeouts:        report.IdleTimeouts,
+			ReadTimeouts:        report.ReadTimeouts,
+			OversizedMessages:   report.OversizedMessages,
+			ForceClosed:         report.ForceClosedConnections,
+			GracePeriodExceeded: report.GracePeriodExceeded,
+		},
+		Throttling: ThrottlingStats{
+			Rejected: report.Throttling.Rejected,
+			Delayed:  report.Throttling.Delayed,
+			Dropped:  report.Throttling.Dropped,
+			Clients:  throttledClients,
+		},
+	}
+}
@@ -0,0 +1,153 @@
+package sku_export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Formats of the exported files.
+const (
+	// FormatNewline has the value of an sku per line, like the plain files of the imports.
+	FormatNewline = "newline"
+	// FormatCSV has a header and a row with the attributes, status and sightings of each sku.
+	FormatCSV = "csv"
+	// FormatJSONL has a JSON object with the attributes, status and sightings of each sku per line.
+	FormatJSONL = "jsonl"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Writer writes the exported skus one after another, Flush has to be called after the last one.
+type Writer interface {
+	Write(*domain.Sku) error
+	Flush() error
+}
+
+func NewWriter(format string, output io.Writer) (Writer, error) {
+	switch format {
+	case FormatNewline:
+		return &newlineWriter{writer: bufio.NewWriter(output)}, nil
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(output)}, nil
+	case FormatJSONL:
+		writer := bufio.NewWriter(output)
+		return &jsonlWriter{writer: writer, encoder: json.NewEncoder(writer)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+type newlineWriter struct {
+	writer *bufio.Writer
+}
+
+func (w *newlineWriter) Write(sku *domain.Sku) error {
+	_, err := w.writer.WriteString(sku.Id().Value() + "\n")
+	return err
+}
+
+func (w *newlineWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+var csvHeader = []string{"tenant", "sku", "name", "brand", "category", "price", "currency", "stock", "status", "first_seen", "last_seen", "seen_count"}
+
+// csvWriter writes the header before the first sku, or on Flush when there's none.
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) Write(sku *domain.Sku) error {
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+	record := exportRecord(sku)
+	var stock string
+	if record.Stock != nil {
+		stock = strconv.Itoa(*record.Stock)
+	}
+
+	return w.writer.Write([]string{
+		record.Tenant, record.Sku, record.Name, record.Brand, record.Category, record.Price, record.Currency, stock,
+		record.Status, record.FirstSeen.Format(time.RFC3339), record.LastSeen.Format(time.RFC3339), strconv.Itoa(record.SeenCount),
+	})
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+
+	return w.writer.Write(csvHeader)
+}
+
+func (w *csvWriter) Flush() error {
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+	w.writer.Flush()
+
+	return w.writer.Error()
+}
+
+type jsonlWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(sku *domain.Sku) error {
+	return w.encoder.Encode(exportRecord(sku))
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+// record is an exported sku, the price is a decimal amount like the one of the messages.
+type record struct {
+	Tenant    string    `json:"tenant"`
+	Sku       string    `json:"sku"`
+	Name      string    `json:"name,omitempty"`
+	Brand     string    `json:"brand,omitempty"`
+	Category  string    `json:"category,omitempty"`
+	Price     string    `json:"price,omitempty"`
+	Currency  string    `json:"currency,omitempty"`
+	Stock     *int      `json:"stock,omitempty"`
+	Status    string    `json:"status"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	SeenCount int       `json:"seen_count"`
+}
+
+func exportRecord(sku *domain.Sku) record {
+	attributes := sku.Attributes()
+	exported := record{
+		Tenant:    sku.Id().Tenant().String(),
+		Sku:       sku.Id().Value(),
+		Name:      attributes.Name(),
+		Brand:     attributes.Brand(),
+		Category:  attributes.Category(),
+		Stock:     attributes.Stock(),
+		Status:    sku.Status().String(),
+		FirstSeen: sku.FirstSeen().UTC(),
+		LastSeen:  sku.LastSeen().UTC(),
+		SeenCount: sku.SeenCount(),
+	}
+	if price := attributes.Price(); price != nil {
+		exported.Price = fmt.Sprintf("%d.%02d", price.Amount()/100, price.Amount()%100)
+		exported.Currency = price.Currency()
+	}
+
+	return exported
+}
@@ -0,0 +1,164 @@
+//+build unit
+
+package sku_export_test
+
+import (
+	"bytes"
+	"context"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/sku_export"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx        context.Context
+	seenAt     time.Time
+	repository *memory.SkuRepository
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.seenAt = time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	s.repository = memory.NewSkuRepository(domain.NewHydrator())
+
+	stock := 5
+	price, err := domain.NewPrice("19.9", "EUR")
+	s.Require().NoError(err)
+	attributes, err := domain.NewAttributes("Running shoes, blue", "Acme", "", price, &stock)
+	s.Require().NoError(err)
+	s.save("", "ABCD-1234", attributes)
+	s.save("acme", "ABCD-1234", domain.Attributes{})
+	deleted := s.save("", "EFGH-5678", domain.Attributes{})
+	s.Require().NoError(deleted.Delete())
+	s.Require().NoError(s.repository.Update(s.ctx, deleted))
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) save(tenant domain.Tenant, value string, attributes domain.Attributes) *domain.Sku {
+	if tenant == "" {
+		tenant = domain.DefaultTenant
+	}
+	skuId, err := domain.DefaultSkuIdFormat.NewTenantSkuId(tenant, value)
+	s.Require().NoError(err)
+	sku := domain.NewSku(skuId, attributes, s.seenAt, "10.0.0.1")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+
+	return sku
+}
+
+func (s *UnitSuite) export(format string, filter domain.SkuFilter) string {
+	output := &strings.Builder{}
+	writer, err := sku_export.NewWriter(format, output)
+	s.Require().NoError(err)
+	s.Require().NoError(s.repository.Scan(s.ctx, filter, writer.Write))
+	s.Require().NoError(writer.Flush())
+
+	return output.String()
+}
+
+func (s *UnitSuite) TestExportTheSkuValuesOnePerLine() {
+	s.Require().Equal("ABCD-1234\nEFGH-5678\n", s.export(sku_export.FormatNewline, domain.SkuFilter{Tenant: domain.DefaultTenant}))
+}
+
+func (s *UnitSuite) TestExportTheSkusAsCsv() {
+	s.Require().Equal(
+		"tenant,sku,name,brand,category,price,currency,stock,status,first_seen,last_seen,seen_count\n"+
+			"default,ABCD-1234,\"Running shoes, blue\",Acme,,19.90,EUR,5,active,2021-09-01T10:00:00Z,2021-09-01T10:00:00Z,1\n"+
+			"acme,ABCD-1234,,,,,,,active,2021-09-01T10:00:00Z,2021-09-01T10:00:00Z,1\n",
+		s.export(sku_export.FormatCSV, domain.SkuFilter{Statuses: []domain.Status{domain.StatusActive}}),
+	)
+	s.Require().Equal(
+		"tenant,sku,name,brand,category,price,currency,stock,status,first_seen,last_seen,seen_count\n",
+		s.export(sku_export.FormatCSV, domain.SkuFilter{Prefixes: []string{"ZZZZ"}}),
+	)
+}
+
+func (s *UnitSuite) TestExportTheSkusAsJsonLines() {
+	s.Require().Equal(
+		`{"tenant":"default","sku":"ABCD-1234","name":"Running shoes, blue","brand":"Acme","price":"19.90","currency":"EUR","stock":5,"status":"active","first_seen":"2021-09-01T10:00:00Z","last_seen":"2021-09-01T10:00:00Z","seen_count":1}`+"\n",
+		s.export(sku_export.FormatJSONL, domain.SkuFilter{Tenant: domain.DefaultTenant, Prefixes: []string{"ABCD"}}),
+	)
+}
+
+func (s *UnitSuite) TestReturnErrUnknownFormat() {
+	_, err := sku_export.NewWriter("xml", &strings.Builder{})
+	s.Require().ErrorIs(err, sku_export.ErrUnknownFormat)
+}
+
+func (s *UnitSuite) TestRestoreTheSnapshotOfARepositoryInAnother() {
+	snapshot := &bytes.Buffer{}
+	count, err := sku_export.WriteSnapshot(s.ctx, s.repository, domain.SkuFilter{}, snapshot, s.seenAt)
+	s.Require().NoError(err)
+	s.Require().Equal(3, count)
+
+	target := memory.NewSkuRepository(domain.NewHydrator())
+	count, err = sku_export.RestoreSnapshot(s.ctx, bytes.NewReader(snapshot.Bytes()), target)
+	s.Require().NoError(err)
+	s.Require().Equal(3, count)
+
+	restored := &bytes.Buffer{}
+	_, err = sku_export.WriteSnapshot(s.ctx, target, domain.SkuFilter{}, restored, s.seenAt)
+	s.Require().NoError(err)
+	s.Require().Equal(snapshot.String(), restored.String())
+	skuId, err := domain.NewSkuId("EFGH-5678")
+	s.Require().NoError(err)
+	tombstone, err := target.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusDeleted, tombstone.Status())
+	s.Require().Equal(1, tombstone.Version())
+}
+
+func (s *UnitSuite) TestASnapshotThatCanNotSeekIsRestored() {
+	snapshot := &bytes.Buffer{}
+	_, err := sku_export.WriteSnapshot(s.ctx, s.repository, domain.SkuFilter{}, snapshot, s.seenAt)
+	s.Require().NoError(err)
+
+	target := memory.NewSkuRepository(domain.NewHydrator())
+	count, err := sku_export.RestoreSnapshot(s.ctx, io.MultiReader(bytes.NewReader(snapshot.Bytes())), target)
+	s.Require().NoError(err)
+	s.Require().Equal(3, count)
+	restored := &bytes.Buffer{}
+	_, err = sku_export.WriteSnapshot(s.ctx, target, domain.SkuFilter{}, restored, s.seenAt)
+	s.Require().NoError(err)
+	s.Require().Equal(snapshot.String(), restored.String())
+}
+
+func (s *UnitSuite) TestATruncatedSnapshotLeavesTheRepositoryUnchanged() {
+	snapshot := &bytes.Buffer{}
+	_, err := sku_export.WriteSnapshot(s.ctx, s.repository, domain.SkuFilter{}, snapshot, s.seenAt)
+	s.Require().NoError(err)
+	lines := strings.SplitAfter(snapshot.String(), "\n")
+	target := memory.NewSkuRepository(domain.NewHydrator())
+	skuId, err := domain.NewSkuId("ABCD-1234")
+	s.Require().NoError(err)
+	s.Require().NoError(target.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, s.seenAt.Add(time.Hour), "10.0.0.2")))
+	before := &bytes.Buffer{}
+	_, err = sku_export.WriteSnapshot(s.ctx, target, domain.SkuFilter{}, before, s.seenAt)
+	s.Require().NoError(err)
+
+	truncated := strings.Join(lines[:3], "")
+	for _, input := range []io.Reader{strings.NewReader(truncated), io.MultiReader(strings.NewReader(truncated))} {
+		count, err := sku_export.RestoreSnapshot(s.ctx, input, target)
+		s.Require().ErrorIs(err, sku_export.ErrTruncatedSnapshot)
+		s.Require().Zero(count)
+	}
+	count, err := sku_export.RestoreSnapshot(s.ctx, strings.NewReader(strings.Join(lines[:4], "")+`{"count":2}`+"\n"), target)
+	s.Require().ErrorIs(err, sku_export.ErrInvalidSnapshot)
+	s.Require().Zero(count)
+	after := &bytes.Buffer{}
+	_, err = sku_export.WriteSnapshot(s.ctx, target, domain.SkuFilter{}, after, s.seenAt)
+	s.Require().NoError(err)
+	s.Require().Equal(before.String(), after.String())
+
+	_, err = sku_export.RestoreSnapshot(s.ctx, strings.NewReader("ABCD-1234\n"), memory.NewSkuRepository(domain.NewHydrator()))
+	s.Require().ErrorIs(err, sku_export.ErrInvalidSnapshot)
+}
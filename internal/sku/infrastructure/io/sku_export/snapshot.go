@@ -0,0 +1,221 @@
+package sku_export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// A snapshot is a JSON lines file: a header, the complete state of every sku (attributes, status, version and
+// sightings) and a trailer with the count of skus, so a truncated snapshot is detected when it's restored.
+const (
+	snapshotFormat  = "feeder-sku-snapshot"
+	snapshotVersion = 1
+	// maxSnapshotLine is the longest sku of a snapshot, the sources of an sku are unbounded.
+	maxSnapshotLine = 16 * 1024 * 1024
+)
+
+var (
+	ErrInvalidSnapshot   = errors.New("invalid snapshot")
+	ErrTruncatedSnapshot = errors.New("truncated snapshot")
+)
+
+type snapshotHeader struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	TakenAt time.Time `json:"taken_at"`
+}
+
+type snapshotTrailer struct {
+	Count int `json:"count"`
+}
+
+// snapshotSku is the complete state of an sku, the price is stored in minor units like in the repository.
+type snapshotSku struct {
+	ID        string         `json:"id"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Brand     string         `json:"brand,omitempty"`
+	Category  string         `json:"category,omitempty"`
+	Price     *snapshotPrice `json:"price,omitempty"`
+	Stock     *int           `json:"stock,omitempty"`
+	Status    string         `json:"status,omitempty"`
+	Version   int            `json:"version"`
+	FirstSeen time.Time      `json:"first_seen"`
+	LastSeen  time.Time      `json:"last_seen"`
+	SeenCount int            `json:"seen_count"`
+	Sources   []string       `json:"sources,omitempty"`
+}
+
+type snapshotPrice struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// snapshotLine is any line after the header: an sku, or the trailer when it has a count.
+type snapshotLine struct {
+	snapshotSku
+	Count *int `json:"count"`
+}
+
+// WriteSnapshot writes the skus of the repository selected by the filter, whatever their status, as they were
+// when the snapshot started. It returns the number of skus written.
+func WriteSnapshot(ctx context.Context, repository domain.SkuRepository, filter domain.SkuFilter, output io.Writer, takenAt time.Time) (int, error) {
+	hydrator := domain.NewHydrator()
+	writer := bufio.NewWriter(output)
+	encoder := json.NewEncoder(writer)
+	err := encoder.Encode(snapshotHeader{Format: snapshotFormat, Version: snapshotVersion, TakenAt: takenAt.UTC()})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = repository.Scan(ctx, filter, func(sku *domain.Sku) error {
+		count++
+		return encoder.Encode(newSnapshotSku(hydrator.Dehydrate(sku)))
+	})
+	if err != nil {
+		return count, err
+	}
+	err = encoder.Encode(snapshotTrailer{Count: count})
+	if err != nil {
+		return count, err
+	}
+
+	return count, writer.Flush()
+}
+
+// RestoreSnapshot stores every sku of the snapshot in the repository exactly as it was, replacing the stored ones.
+// It returns the number of skus restored. The whole snapshot is read and checked, its trailer included, before any
+// sku is restored, so an invalid or truncated snapshot (ErrTruncatedSnapshot) leaves the repository unchanged. An
+// input that can't seek, like a pipe, is copied to a temporary file while it's checked.
+func RestoreSnapshot(ctx context.Context, input io.Reader, repository domain.SkuRepository) (int, error) {
+	var start int64
+	seeker, seekable := input.(io.ReadSeeker)
+	if seekable {
+		var err error
+		start, err = seeker.Seek(0, io.SeekCurrent)
+		seekable = err == nil
+	}
+	if !seekable {
+		file, err := os.CreateTemp("", "sku-snapshot-*.jsonl")
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}()
+		input, seeker = io.TeeReader(input, file), file
+	}
+
+	_, err := readSnapshot(input, func(snapshotSku) error { return nil })
+	if err != nil {
+		return 0, err
+	}
+	_, err = seeker.Seek(start, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	hydrator := domain.NewHydrator()
+	return readSnapshot(seeker, func(sku snapshotSku) error {
+		return repository.Restore(ctx, hydrator.Hydrate(sku.dto()))
+	})
+}
+
+// readSnapshot calls fn with every sku of the snapshot and returns their number, or an error as soon as the
+// snapshot is found invalid or truncated.
+func readSnapshot(input io.Reader, fn func(snapshotSku) error) (int, error) {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSnapshotLine)
+	if !scanner.Scan() {
+		return 0, fmt.Errorf("%w: empty snapshot %v", ErrInvalidSnapshot, scanner.Err())
+	}
+	var header snapshotHeader
+	err := json.Unmarshal(scanner.Bytes(), &header)
+	if err != nil || header.Format != snapshotFormat || header.Version != snapshotVersion {
+		return 0, fmt.Errorf("%w: unknown header %s", ErrInvalidSnapshot, scanner.Text())
+	}
+
+	count := 0
+	for scanner.Scan() {
+		var line snapshotLine
+		err = json.Unmarshal(scanner.Bytes(), &line)
+		if err != nil {
+			return count, fmt.Errorf("%w: sku %d: %s", ErrInvalidSnapshot, count+1, err.Error())
+		}
+		if line.Count != nil {
+			if *line.Count != count || scanner.Scan() {
+				return count, fmt.Errorf("%w: the trailer counts %d skus but there are %d", ErrInvalidSnapshot, *line.Count, count)
+			}
+			return count, nil
+		}
+		if line.ID == "" {
+			return count, fmt.Errorf("%w: sku %d has no id", ErrInvalidSnapshot, count+1)
+		}
+
+		err = fn(line.snapshotSku)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	if scanner.Err() != nil {
+		return count, scanner.Err()
+	}
+
+	return count, fmt.Errorf("%w: %d skus read", ErrTruncatedSnapshot, count)
+}
+
+func newSnapshotSku(skuDTO *domain.SkuDTO) snapshotSku {
+	var price *snapshotPrice
+	if skuDTO.Price != nil {
+		price = &snapshotPrice{Amount: skuDTO.Price.Amount, Currency: skuDTO.Price.Currency}
+	}
+
+	return snapshotSku{
+		ID:        skuDTO.ID,
+		Tenant:    skuDTO.Tenant,
+		Name:      skuDTO.Name,
+		Brand:     skuDTO.Brand,
+		Category:  skuDTO.Category,
+		Price:     price,
+		Stock:     skuDTO.Stock,
+		Status:    skuDTO.Status,
+		Version:   skuDTO.Version,
+		FirstSeen: skuDTO.FirstSeen.UTC(),
+		LastSeen:  skuDTO.LastSeen.UTC(),
+		SeenCount: skuDTO.SeenCount,
+		Sources:   skuDTO.Sources,
+	}
+}
+
+func (s snapshotSku) dto() *domain.SkuDTO {
+	var price *domain.PriceDTO
+	if s.Price != nil {
+		price = &domain.PriceDTO{Amount: s.Price.Amount, Currency: s.Price.Currency}
+	}
+
+	return &domain.SkuDTO{
+		ID:        s.ID,
+		Tenant:    s.Tenant,
+		Name:      s.Name,
+		Brand:     s.Brand,
+		Category:  s.Category,
+		Price:     price,
+		Stock:     s.Stock,
+		Status:    s.Status,
+		Version:   s.Version,
+		FirstSeen: s.FirstSeen,
+		LastSeen:  s.LastSeen,
+		SeenCount: s.SeenCount,
+		Sources:   s.Sources,
+	}
+}
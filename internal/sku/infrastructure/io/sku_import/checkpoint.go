@@ -0,0 +1,95 @@
+package sku_import
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint is the progress of the import of a file, saved after every batch so an interrupted import resumes
+// after the last batch imported.
+type Checkpoint struct {
+	// Path, Size and ModifiedAt identify the imported file, a checkpoint of another file or of a modified one is
+	// rejected.
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	StartedAt  time.Time `json:"started_at"`
+	// Position is the number of lines (or csv records) of the file already imported.
+	Position int `json:"position"`
+	// Report counts the results of the skus already imported.
+	Report reporting.Report `json:"report"`
+}
+
+var ErrCheckpointMismatch = errors.New("the checkpoint belongs to another file or the file was modified")
+
+// NewCheckpoint returns the checkpoint of an import of the file starting from its beginning.
+func NewCheckpoint(path string, startedAt time.Time) (*Checkpoint, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absolutePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkpoint{Path: absolutePath, Size: info.Size(), ModifiedAt: info.ModTime().UTC(), StartedAt: startedAt}, nil
+}
+
+// LoadCheckpoint returns the checkpoint saved in the file, or nil when there's none.
+func LoadCheckpoint(fileName string) (*Checkpoint, error) {
+	content, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &Checkpoint{}
+	err = json.Unmarshal(content, checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the checkpoint %s: %w", fileName, err)
+	}
+
+	return checkpoint, nil
+}
+
+// Resumes returns an error wrapping ErrCheckpointMismatch unless the checkpoint continues the import of the same
+// version of the file as the other one.
+func (c *Checkpoint) Resumes(other *Checkpoint) error {
+	if c.Path != other.Path || c.Size != other.Size || !c.ModifiedAt.Equal(other.ModifiedAt) {
+		return fmt.Errorf("%w: %s", ErrCheckpointMismatch, c.Path)
+	}
+
+	return nil
+}
+
+// Id identifies the import of the file, it scopes the journal of its batch in progress.
+func (c *Checkpoint) Id() string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", c.Path, c.Size, c.ModifiedAt.UnixNano())))
+
+	return hex.EncodeToString(hash[:8])
+}
+
+// Save writes the checkpoint to a temporary file renamed to the file name, so an interruption while saving leaves
+// the previous checkpoint.
+func (c *Checkpoint) Save(fileName string) error {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	temporaryFileName := fileName + ".tmp"
+	err = os.WriteFile(temporaryFileName, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryFileName, fileName)
+}
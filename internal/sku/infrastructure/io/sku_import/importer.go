@@ -0,0 +1,198 @@
+package sku_import
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/payload"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+)
+
+// Default parallelism and checkpoint frequency of the imports.
+const (
+	DefaultWorkers   = 8
+	DefaultBatchSize = 1000
+)
+
+// ErrInterrupted is returned when the import stops before the end of the file, after saving its checkpoint.
+var ErrInterrupted = errors.New("import interrupted")
+
+// Importer creates the skus of a file through the create_sku command of the command bus, like the socket server
+// does with the skus of the messages, so they go through the same normalisation, validation and middlewares.
+type Importer struct {
+	commandBus      bus.Dispatcher
+	tenant          domain.Tenant
+	workers         int
+	batchSize       int
+	journalFileName string
+}
+
+type Option func(*Importer)
+
+// WithTenant imports the skus for the tenant instead of the default one.
+func WithTenant(tenant domain.Tenant) Option {
+	return func(i *Importer) {
+		i.tenant = tenant
+	}
+}
+
+// WithWorkers limits the number of skus created at once.
+func WithWorkers(workers int) Option {
+	return func(i *Importer) {
+		if workers > 0 {
+			i.workers = workers
+		}
+	}
+}
+
+// WithBatchSize sets the number of skus imported between two checkpoints.
+func WithBatchSize(batchSize int) Option {
+	return func(i *Importer) {
+		if batchSize > 0 {
+			i.batchSize = batchSize
+		}
+	}
+}
+
+// WithJournal records the outcome of the skus of the batch in progress in the file, so a crashed import resumes
+// its last batch without creating its skus again and its report counts them like an uninterrupted import. The
+// file is removed once the file is imported.
+func WithJournal(fileName string) Option {
+	return func(i *Importer) {
+		i.journalFileName = fileName
+	}
+}
+
+func New(commandBus bus.Dispatcher, options ...Option) *Importer {
+	importer := &Importer{
+		commandBus: commandBus,
+		tenant:     domain.DefaultTenant,
+		workers:    DefaultWorkers,
+		batchSize:  DefaultBatchSize,
+	}
+	for _, option := range options {
+		option(importer)
+	}
+
+	return importer
+}
+
+// line is an sku of the file and its position, or the error of a malformed one.
+type line struct {
+	position int
+	sku      string
+	err      error
+}
+
+// Import creates the skus of the source after the position of the checkpoint, counting their results in the
+// report of the checkpoint, where the malformed lines are invalid. The checkpoint is saved after every batch and at the end of the file. Once the
+// context is done it finishes the current batch and returns ErrInterrupted.
+func (i *Importer) Import(ctx context.Context, source *Source, checkpoint *Checkpoint, save func(*Checkpoint) error) error {
+	journal, err := openJournal(i.journalFileName, checkpoint)
+	if err != nil {
+		return err
+	}
+	defer journal.close()
+
+	var batch []line
+	for {
+		position, sku, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, ErrMalformedRecord) {
+			return fmt.Errorf("error reading line %d: %w", position+1, err)
+		}
+		if position <= checkpoint.Position {
+			continue
+		}
+		batch = append(batch, line{position: position, sku: payload.Normalise(sku), err: err})
+		if len(batch) < i.batchSize {
+			continue
+		}
+
+		err = i.importBatch(checkpoint, journal, batch)
+		if err != nil {
+			return err
+		}
+		checkpoint.Position = position
+		err = save(checkpoint)
+		if err != nil {
+			return err
+		}
+		err = journal.reset(checkpoint)
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w at line %d", ErrInterrupted, position)
+		}
+	}
+
+	err = i.importBatch(checkpoint, journal, batch)
+	if err != nil {
+		return err
+	}
+	if source.Position() > checkpoint.Position {
+		checkpoint.Position = source.Position()
+	}
+	err = save(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return journal.remove()
+}
+
+// importBatch creates the skus of the batch with up to workers commands at once, except the ones the journal has
+// an outcome for. The commands don't inherit the cancellation of the import, the bus limits their duration, so an
+// interrupted import doesn't leave a batch half imported.
+func (i *Importer) importBatch(checkpoint *Checkpoint, journal *journal, batch []line) error {
+	commandSource := "import:" + filepath.Base(checkpoint.Path)
+	workers := make(chan struct{}, i.workers)
+	var mutex sync.Mutex
+	var journalErr error
+	var wg sync.WaitGroup
+	for _, skuLine := range batch {
+		outcome, recorded := journal.outcome(skuLine.position)
+		if skuLine.err != nil {
+			outcome, recorded = skuLine.err, true
+		}
+		if recorded {
+			mutex.Lock()
+			checkpoint.Report.RecordCreateResult(i.tenant.String(), outcome)
+			mutex.Unlock()
+			continue
+		}
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(skuLine line) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			_, err := i.commandBus.Dispatch(context.Background(), create_sku.Command{
+				Sku:    skuLine.sku,
+				Tenant: i.tenant.String(),
+				Source: commandSource,
+			})
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			checkpoint.Report.RecordCreateResult(i.tenant.String(), err)
+			err = journal.record(skuLine.position, err)
+			if err != nil && journalErr == nil {
+				journalErr = fmt.Errorf("error recording line %d in the journal: %w", skuLine.position, err)
+			}
+		}(skuLine)
+	}
+	wg.Wait()
+
+	return journalErr
+}
@@ -0,0 +1,162 @@
+package sku_import
+
+import (
+	"bufio"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// journal records in a file the outcome of every sku of the batch in progress, so the batch of a crashed import
+// resumes with the outcomes of the skus it already created instead of creating them again, which would count them
+// as duplicated. Like the Idempotency middleware of the bus, it only records the successes and the outcome errors
+// of the domain, the skus that failed with any other error are created again. Its first line is the id and the
+// position of the checkpoint it continues, the journal of any other checkpoint is ignored.
+type journal struct {
+	file *os.File
+	// outcomes holds the outcome of the skus recorded before the crash by their position, nil for the created ones.
+	outcomes map[int]error
+}
+
+// openJournal returns the journal of the checkpoint saved in the file, it's nil when the file name is empty.
+func openJournal(fileName string, checkpoint *Checkpoint) (*journal, error) {
+	if fileName == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{file: file, outcomes: map[int]error{}}
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || scanner.Text() != journalHeader(checkpoint) {
+		err = j.reset(checkpoint)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		return j, nil
+	}
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		position, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		outcome, ok := outcomeOf(fields[1])
+		if ok {
+			j.outcomes[position] = outcome
+		}
+	}
+	if scanner.Err() != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error reading the journal %s: %w", fileName, scanner.Err())
+	}
+
+	_, err = file.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+func journalHeader(checkpoint *Checkpoint) string {
+	return fmt.Sprintf("%s %d", checkpoint.Id(), checkpoint.Position)
+}
+
+// outcomeOf returns the outcome error recorded with its message, nil for an empty one, and false when it's none of
+// the outcome errors of the domain.
+func outcomeOf(message string) (error, bool) {
+	if message == "" {
+		return nil, true
+	}
+	for _, outcomeErr := range domain.OutcomeErrors {
+		if outcomeErr.Error() == message {
+			return outcomeErr, true
+		}
+	}
+
+	return nil, false
+}
+
+// outcome returns the outcome recorded for the sku of the position before the crash.
+func (j *journal) outcome(position int) (error, bool) {
+	if j == nil {
+		return nil, false
+	}
+	outcome, ok := j.outcomes[position]
+
+	return outcome, ok
+}
+
+// record appends the outcome of the sku of the position unless it failed with an error other than the outcome
+// errors of the domain.
+func (j *journal) record(position int, err error) error {
+	if j == nil {
+		return nil
+	}
+	message := ""
+	if err != nil {
+		for _, outcomeErr := range domain.OutcomeErrors {
+			if errors.Is(err, outcomeErr) {
+				message = outcomeErr.Error()
+				break
+			}
+		}
+		if message == "" {
+			return nil
+		}
+	}
+	_, err = fmt.Fprintf(j.file, "%d\t%s\n", position, message)
+
+	return err
+}
+
+// reset empties the journal once the checkpoint is saved, as its batch is imported.
+func (j *journal) reset(checkpoint *Checkpoint) error {
+	if j == nil {
+		return nil
+	}
+	j.outcomes = map[int]error{}
+	err := j.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = j.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(j.file, journalHeader(checkpoint))
+
+	return err
+}
+
+// remove closes the journal and removes its file once the file is imported.
+func (j *journal) remove() error {
+	if j == nil {
+		return nil
+	}
+	err := j.file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(j.file.Name())
+}
+
+func (j *journal) close() error {
+	if j == nil {
+		return nil
+	}
+
+	return j.file.Close()
+}
@@ -0,0 +1,289 @@
+//+build unit
+
+package sku_import_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"feeder-service/internal/sku/infrastructure/io/sku_import"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx        context.Context
+	dir        string
+	repository *memory.SkuRepository
+	commandBus *bus.Bus
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.dir = s.T().TempDir()
+	s.repository = memory.NewSkuRepository(domain.NewHydrator())
+	s.commandBus = bus.New()
+	s.Require().NoError(s.commandBus.Register(create_sku.CommandName, create_sku.BusHandler(create_sku.NewCommandHandler(s.repository))))
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) writeFile(name, content string) string {
+	path := filepath.Join(s.dir, name)
+	s.Require().NoError(os.WriteFile(path, []byte(content), 0644))
+
+	return path
+}
+
+func (s *UnitSuite) readAll(source *sku_import.Source) []string {
+	var skus []string
+	for {
+		position, sku, err := source.Next()
+		if err == io.EOF {
+			return skus
+		}
+		s.Require().NoError(err)
+		skus = append(skus, fmt.Sprintf("%d:%s", position, sku))
+	}
+}
+
+func (s *UnitSuite) TestThePlainFilesHaveAnSkuPerLineAndTheBlankOnesAreSkipped() {
+	source, err := sku_import.Open(s.writeFile("skus.txt", "ABCD-1234\n\n  EFGH-5678 \nIJKL-9012"), sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+
+	s.Require().Equal([]string{"1:ABCD-1234", "3:EFGH-5678", "4:IJKL-9012"}, s.readAll(source))
+	s.Require().Equal(4, source.Position())
+}
+
+func (s *UnitSuite) TestTheCsvColumnIsFoundByHeaderOrByPosition() {
+	path := s.writeFile("skus.csv", "name,SKU\nshoe,ABCD-1234\nhat\n\"boot, tall\",EFGH-5678\n")
+
+	for _, column := range []string{"sku", "2"} {
+		source, err := sku_import.Open(path, sku_import.FormatCSV, column)
+		s.Require().NoError(err)
+		s.Require().Equal([]string{"1:ABCD-1234", "3:EFGH-5678"}, s.readAll(source), column)
+		s.Require().NoError(source.Close())
+	}
+
+	_, err := sku_import.Open(path, sku_import.FormatCSV, "price")
+	s.Require().ErrorIs(err, sku_import.ErrUnknownColumn)
+	_, err = sku_import.Open(path, "xml", "")
+	s.Require().ErrorIs(err, sku_import.ErrUnknownFormat)
+}
+
+func (s *UnitSuite) TestTheMalformedRecordsAreReturnedWithTheirPositionAndTheNextOnesAreRead() {
+	path := s.writeFile("skus.txt", "ABCD-1234\n"+strings.Repeat("A", 2*1024*1024)+"\nEFGH-5678\n")
+	source, err := sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+	_, _, err = source.Next()
+	s.Require().NoError(err)
+	position, _, err := source.Next()
+	s.Require().ErrorIs(err, sku_import.ErrMalformedRecord)
+	s.Require().Equal(2, position)
+	s.Require().Equal([]string{"3:EFGH-5678"}, s.readAll(source))
+
+	path = s.writeFile("skus.csv", "sku\nABCD-1234\nEF\"GH\nIJKL-9012\n")
+	csvSource, err := sku_import.Open(path, sku_import.FormatCSV, "sku")
+	s.Require().NoError(err)
+	defer csvSource.Close()
+	_, _, err = csvSource.Next()
+	s.Require().NoError(err)
+	position, _, err = csvSource.Next()
+	s.Require().ErrorIs(err, sku_import.ErrMalformedRecord)
+	s.Require().Equal(2, position)
+	s.Require().Equal([]string{"3:IJKL-9012"}, s.readAll(csvSource))
+}
+
+func (s *UnitSuite) TestTheGzippedFilesAreDetectedByTheirContent() {
+	path := filepath.Join(s.dir, "skus")
+	file, err := os.Create(path)
+	s.Require().NoError(err)
+	writer := gzip.NewWriter(file)
+	_, err = writer.Write([]byte("ABCD-1234\nEFGH-5678\n"))
+	s.Require().NoError(err)
+	s.Require().NoError(writer.Close())
+	s.Require().NoError(file.Close())
+
+	source, err := sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+	s.Require().Equal([]string{"1:ABCD-1234", "2:EFGH-5678"}, s.readAll(source))
+}
+
+func (s *UnitSuite) TestTheFormatIsTheOneOfTheExtension() {
+	s.Require().Equal(sku_import.FormatCSV, sku_import.FormatOf("skus.CSV"))
+	s.Require().Equal(sku_import.FormatCSV, sku_import.FormatOf("skus.csv.gz"))
+	s.Require().Equal(sku_import.FormatPlain, sku_import.FormatOf("skus.txt.gz"))
+	s.Require().Equal(sku_import.FormatPlain, sku_import.FormatOf("skus"))
+}
+
+func (s *UnitSuite) TestTheCheckpointIsSavedAndOnlyResumesTheSameFile() {
+	path := s.writeFile("skus.txt", "ABCD-1234\n")
+	checkpoint, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+	checkpoint.Position = 1
+	checkpoint.Report.RecordCreateResult("default", nil)
+	checkpointFileName := filepath.Join(s.dir, "skus.checkpoint")
+	s.Require().NoError(checkpoint.Save(checkpointFileName))
+
+	loaded, err := sku_import.LoadCheckpoint(checkpointFileName)
+	s.Require().NoError(err)
+	s.Require().Equal(1, loaded.Position)
+	s.Require().Equal(1, loaded.Report.CreatedSkus)
+	s.Require().Equal(1, loaded.Report.Tenants["default"].CreatedSkus)
+	current, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+	s.Require().NoError(current.Resumes(loaded))
+	s.Require().Equal(current.Id(), loaded.Id())
+
+	s.writeFile("skus.txt", "ABCD-1234\nEFGH-5678\n")
+	modified, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+	s.Require().ErrorIs(modified.Resumes(loaded), sku_import.ErrCheckpointMismatch)
+
+	missing, err := sku_import.LoadCheckpoint(filepath.Join(s.dir, "missing.checkpoint"))
+	s.Require().NoError(err)
+	s.Require().Nil(missing)
+}
+
+// TestTheImportCountsTheResultsInTheCategoriesOfTheServerReport pads a duplicate with zeros, as the server trims them.
+func (s *UnitSuite) TestTheImportCountsTheResultsInTheCategoriesOfTheServerReport() {
+	path := s.writeFile("skus.txt", "ABCD-1234\nEFGH-5678\n00ABCD-1234\ninvalid\nIJKL-9012\n")
+	source, err := sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+	checkpoint, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+	var saved []int
+
+	err = sku_import.New(s.commandBus, sku_import.WithBatchSize(2), sku_import.WithWorkers(1)).Import(s.ctx, source, checkpoint, func(c *sku_import.Checkpoint) error {
+		saved = append(saved, c.Position)
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Require().Equal([]int{2, 4, 5}, saved)
+	s.Require().Equal(3, checkpoint.Report.CreatedSkus)
+	s.Require().Equal(1, checkpoint.Report.DuplicatedSkus)
+	s.Require().Equal(1, checkpoint.Report.InvalidSkus)
+	s.Require().Equal(1, checkpoint.Report.InvalidReasons[reporting.InvalidReasonFormat])
+	s.Require().Equal(3, checkpoint.Report.Tenants["default"].CreatedSkus)
+}
+
+func (s *UnitSuite) TestTheMalformedRecordsAreCountedAsInvalidAndTheImportContinues() {
+	path := s.writeFile("skus.csv", "sku\nABCD-1234\nEF\"GH\nIJKL-9012\n")
+	source, err := sku_import.Open(path, sku_import.FormatCSV, "sku")
+	s.Require().NoError(err)
+	defer source.Close()
+	checkpoint, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+
+	err = sku_import.New(s.commandBus).Import(s.ctx, source, checkpoint, func(*sku_import.Checkpoint) error { return nil })
+	s.Require().NoError(err)
+	s.Require().Equal(3, checkpoint.Position)
+	s.Require().Equal(2, checkpoint.Report.CreatedSkus)
+	s.Require().Equal(1, checkpoint.Report.InvalidSkus)
+	s.Require().Equal(1, checkpoint.Report.InvalidReasons[reporting.InvalidReasonMalformed])
+}
+
+func (s *UnitSuite) TestAnInterruptedImportResumesFromItsCheckpoint() {
+	path := s.writeFile("skus.txt", "ABCD-1234\nEFGH-5678\nIJKL-9012\nMNOP-3456\n")
+	checkpointFileName := filepath.Join(s.dir, "skus.checkpoint")
+	save := func(c *sku_import.Checkpoint) error {
+		return c.Save(checkpointFileName)
+	}
+	importer := sku_import.New(s.commandBus, sku_import.WithBatchSize(2))
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	source, err := sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	checkpoint, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+	err = importer.Import(ctx, source, checkpoint, save)
+	s.Require().ErrorIs(err, sku_import.ErrInterrupted)
+	s.Require().NoError(source.Close())
+
+	source, err = sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+	checkpoint, err = sku_import.LoadCheckpoint(checkpointFileName)
+	s.Require().NoError(err)
+	s.Require().Equal(2, checkpoint.Position)
+	err = importer.Import(s.ctx, source, checkpoint, save)
+	s.Require().NoError(err)
+	s.Require().Equal(4, checkpoint.Position)
+	s.Require().Equal(4, checkpoint.Report.CreatedSkus)
+	s.Require().Zero(checkpoint.Report.DuplicatedSkus)
+}
+
+func (s *UnitSuite) TestTheBatchOfACrashedImportIsResumedWithTheOutcomesOfItsJournal() {
+	path := s.writeFile("skus.txt", "ABCD-1234\nEFGH-5678\nabc\nABCD-1234\n")
+	journalFileName := filepath.Join(s.dir, "skus.journal")
+	importer := sku_import.New(s.commandBus, sku_import.WithTenant("acme"), sku_import.WithBatchSize(4), sku_import.WithJournal(journalFileName))
+	errCrash := errors.New("crash")
+
+	source, err := sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	checkpoint, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+	err = importer.Import(s.ctx, source, checkpoint, func(*sku_import.Checkpoint) error { return errCrash })
+	s.Require().ErrorIs(err, errCrash)
+	s.Require().NoError(source.Close())
+
+	source, err = sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+	checkpoint, err = sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+	err = importer.Import(s.ctx, source, checkpoint, func(*sku_import.Checkpoint) error { return nil })
+	s.Require().NoError(err)
+	s.Require().Equal(2, checkpoint.Report.CreatedSkus)
+	s.Require().Equal(1, checkpoint.Report.DuplicatedSkus)
+	s.Require().Equal(1, checkpoint.Report.InvalidSkus)
+	s.Require().Zero(checkpoint.Report.ReplayedSkus)
+	s.Require().Equal(2, checkpoint.Report.Tenants["acme"].CreatedSkus)
+	s.Require().NoFileExists(journalFileName)
+	for _, sku := range []string{"ABCD-1234", "EFGH-5678"} {
+		skuId, err := domain.DefaultSkuIdFormat.NewTenantSkuId("acme", sku)
+		s.Require().NoError(err)
+		found, err := s.repository.Find(s.ctx, skuId)
+		s.Require().NoError(err)
+		s.Require().NotNil(found)
+	}
+}
+
+func (s *UnitSuite) TestTheJournalOfAnotherCheckpointIsIgnored() {
+	path := s.writeFile("skus.txt", "ABCD-1234\nEFGH-5678\n")
+	journalFileName := filepath.Join(s.dir, "skus.journal")
+	s.Require().NoError(os.WriteFile(journalFileName, []byte("0000000000000000 0\n1\t\n2\t\n"), 0644))
+	source, err := sku_import.Open(path, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+	checkpoint, err := sku_import.NewCheckpoint(path, time.Now())
+	s.Require().NoError(err)
+
+	err = sku_import.New(s.commandBus, sku_import.WithJournal(journalFileName)).Import(s.ctx, source, checkpoint, func(*sku_import.Checkpoint) error { return nil })
+	s.Require().NoError(err)
+	s.Require().Equal(2, checkpoint.Report.CreatedSkus)
+	skuId, err := domain.NewSkuId("ABCD-1234")
+	s.Require().NoError(err)
+	found, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().NotNil(found)
+}
@@ -0,0 +1,193 @@
+package sku_import
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/io/payload"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Formats of the imported files, the gzipped files are detected by their content whatever their format.
+const (
+	// FormatPlain has an sku per line.
+	FormatPlain = "plain"
+	// FormatCSV has a header and an sku column.
+	FormatCSV = "csv"
+)
+
+// DefaultColumn is the header of the sku column of the csv files.
+const DefaultColumn = "sku"
+
+var (
+	ErrUnknownFormat = errors.New("unknown file format")
+	ErrUnknownColumn = errors.New("unknown csv column")
+	// ErrMalformedRecord is returned for a line longer than maxLineLength or a csv record that can't be parsed, it's
+	// a malformed message so the reports count it as invalid like the socket server does.
+	ErrMalformedRecord = fmt.Errorf("%w: malformed record", payload.ErrMalformedMessage)
+)
+
+// maxLineLength is the longest line of a plain file, as the csv reader has no limit.
+const maxLineLength = 1024 * 1024
+
+// Source reads the skus of a file one after another without loading it into memory.
+type Source struct {
+	file *os.File
+	// next returns the sku of the next line or record, empty when it's blank.
+	next     func() (string, error)
+	position int
+}
+
+// FormatOf returns the format of the file by its extension, ignoring the .gz one: csv for the .csv files and plain
+// for the rest.
+func FormatOf(path string) string {
+	extension := strings.ToLower(filepath.Ext(strings.TrimSuffix(strings.ToLower(path), ".gz")))
+	if extension == ".csv" {
+		return FormatCSV
+	}
+
+	return FormatPlain
+}
+
+// Open opens the file with the format. The column of a csv file is the header of the sku column, or its
+// position starting from 1.
+func Open(path, format, column string) (*Source, error) {
+	if format != FormatPlain && format != FormatCSV {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := decompress(bufio.NewReader(file))
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	source := &Source{file: file}
+	if format == FormatPlain {
+		lineReader := bufio.NewReaderSize(reader, 64*1024)
+		source.next = func() (string, error) {
+			return readLine(lineReader)
+		}
+		return source, nil
+	}
+
+	source.next, err = csvColumn(reader, column)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// decompress returns the content of the gzipped files and the reader itself otherwise.
+func decompress(reader *bufio.Reader) (io.Reader, error) {
+	magic, err := reader.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return reader, nil
+	}
+
+	return gzip.NewReader(reader)
+}
+
+// readLine returns the next line of the reader. A line longer than maxLineLength is read until its end, so the next
+// call returns the following one, and it returns ErrMalformedRecord for it.
+func readLine(reader *bufio.Reader) (string, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if !tooLong && len(line)+len(chunk) > maxLineLength {
+			tooLong, line = true, nil
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+		if !isPrefix {
+			break
+		}
+	}
+	if tooLong {
+		return "", fmt.Errorf("%w: line longer than %d bytes", ErrMalformedRecord, maxLineLength)
+	}
+
+	return strings.TrimSpace(string(line)), nil
+}
+
+func csvColumn(reader io.Reader, column string) (func() (string, error), error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the csv header: %w", err)
+	}
+	index := -1
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			index = i
+		}
+	}
+	if number, err := strconv.Atoi(column); index < 0 && err == nil && number >= 1 && number <= len(header) {
+		index = number - 1
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, column)
+	}
+
+	return func() (string, error) {
+		record, err := csvReader.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return "", fmt.Errorf("%w: %s", ErrMalformedRecord, parseErr.Error())
+		}
+		if err != nil {
+			return "", err
+		}
+		if index >= len(record) {
+			return "", nil
+		}
+		return strings.TrimSpace(record[index]), nil
+	}, nil
+}
+
+// Next returns the next sku and its position, the number of the line (or csv record after the header) starting
+// from 1. The blank ones are skipped and it returns io.EOF at the end of the file. A malformed line or record
+// returns ErrMalformedRecord with its position, and the next call continues after it.
+func (s *Source) Next() (int, string, error) {
+	for {
+		sku, err := s.next()
+		if errors.Is(err, ErrMalformedRecord) {
+			s.position++
+			return s.position, "", err
+		}
+		if err != nil {
+			return s.position, "", err
+		}
+		s.position++
+		if sku != "" {
+			return s.position, sku, nil
+		}
+	}
+}
+
+// Position returns the position of the last line (or csv record) read.
+func (s *Source) Position() int {
+	return s.position
+}
+
+func (s *Source) Close() error {
+	return s.file.Close()
+}
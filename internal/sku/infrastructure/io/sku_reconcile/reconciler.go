@@ -0,0 +1,225 @@
+package sku_reconcile
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/payload"
+	"feeder-service/internal/sku/infrastructure/io/sku_import"
+	"io"
+	"os"
+)
+
+// Kinds of the differences between a supplier file and the repository.
+const (
+	// KindMissing is a valid sku of the file that is not in the repository.
+	KindMissing = "missing"
+	// KindExtra is an sku of the repository that is not in the file.
+	KindExtra = "extra"
+	// KindInvalid is an entry of the file that is not a valid sku.
+	KindInvalid = "invalid"
+)
+
+// DefaultChunkSize is the number of skus of the file sorted in memory at once.
+const DefaultChunkSize = 100000
+
+// maxLineLength is the longest line of a chunk file, the skus are validated before they're written.
+const maxLineLength = 64 * 1024
+
+type Difference struct {
+	Kind string
+	// Sku is the normalised value of the missing and extra skus, and the entry as it is in the file of the invalid ones.
+	Sku string
+	// Line is the line (or csv record) of the file of the missing and invalid skus, zero for the extra ones.
+	Line int
+	// Reason is why an invalid sku is invalid.
+	Reason string
+}
+
+// Summary counts the skus of both sides, the duplicates of the file are only compared once.
+type Summary struct {
+	// FileSkus counts the valid skus of the file, its duplicates included.
+	FileSkus       int `json:"file_skus"`
+	DuplicatedSkus int `json:"duplicated_skus"`
+	StoreSkus      int `json:"store_skus"`
+	MatchedSkus    int `json:"matched_skus"`
+	MissingSkus    int `json:"missing_skus"`
+	ExtraSkus      int `json:"extra_skus"`
+	InvalidSkus    int `json:"invalid_skus"`
+}
+
+// Reconciler compares the skus of a supplier file with the ones of a tenant in the repository, whatever the size of
+// both: the file is sorted in chunks on disk and merged with the skus of the repository, which are scanned in order.
+type Reconciler struct {
+	repository  domain.SkuRepository
+	tenant      domain.Tenant
+	skuIdFormat *domain.SkuIdFormat
+	chunkSize   int
+	tempDir     string
+}
+
+type Option func(*Reconciler)
+
+// WithTenant compares the file with the skus of the tenant instead of the ones of the default tenant.
+func WithTenant(tenant domain.Tenant) Option {
+	return func(r *Reconciler) {
+		r.tenant = tenant
+	}
+}
+
+// WithSkuIdFormat validates the entries of the file with the sku format of the tenant instead of the default one.
+func WithSkuIdFormat(skuIdFormat *domain.SkuIdFormat) Option {
+	return func(r *Reconciler) {
+		r.skuIdFormat = skuIdFormat
+	}
+}
+
+// WithChunkSize sets the number of skus of the file sorted in memory at once.
+func WithChunkSize(chunkSize int) Option {
+	return func(r *Reconciler) {
+		if chunkSize > 0 {
+			r.chunkSize = chunkSize
+		}
+	}
+}
+
+// WithTempDir sets the dir of the temporary files of the chunks, the default one of the system when it's empty.
+func WithTempDir(tempDir string) Option {
+	return func(r *Reconciler) {
+		r.tempDir = tempDir
+	}
+}
+
+func New(repository domain.SkuRepository, options ...Option) *Reconciler {
+	reconciler := &Reconciler{
+		repository:  repository,
+		tenant:      domain.DefaultTenant,
+		skuIdFormat: domain.DefaultSkuIdFormat,
+		chunkSize:   DefaultChunkSize,
+	}
+	for _, option := range options {
+		option(reconciler)
+	}
+
+	return reconciler
+}
+
+// Reconcile calls fn with every difference between the file and the active and inactive skus of the repository:
+// first the invalid entries of the file in the order they're read, then the missing and extra skus ordered by sku.
+// The entries of the file are normalised like the messages of the socket server, see payload.Normalise, and
+// validated with the sku format of the tenant. A deleted sku of the repository is missing when the file has it.
+func (r *Reconciler) Reconcile(ctx context.Context, source *sku_import.Source, fn func(Difference) error) (Summary, error) {
+	summary := Summary{}
+	dir, err := os.MkdirTemp(r.tempDir, "sku-reconcile-*")
+	if err != nil {
+		return summary, err
+	}
+	defer os.RemoveAll(dir)
+
+	fileSkus, err := r.sortFile(ctx, source, &sorter{dir: dir, chunkSize: r.chunkSize}, &summary, fn)
+	if err != nil {
+		return summary, err
+	}
+	defer fileSkus.close()
+
+	// the duplicates of the file are consecutive once it's sorted
+	var last string
+	next := func() (entry, bool, error) {
+		for {
+			e, ok, err := fileSkus.next()
+			if err != nil || !ok {
+				return e, ok, err
+			}
+			if last != "" && e.value == last {
+				summary.DuplicatedSkus++
+				continue
+			}
+			last = e.value
+			return e, true, nil
+		}
+	}
+	fileSku, ok, err := next()
+	if err != nil {
+		return summary, err
+	}
+
+	filter := domain.SkuFilter{Tenant: r.tenant, Statuses: []domain.Status{domain.StatusActive, domain.StatusInactive}}
+	err = r.repository.Scan(ctx, filter, func(sku *domain.Sku) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		summary.StoreSkus++
+		value := sku.Id().Value()
+		for ok && fileSku.value < value {
+			summary.MissingSkus++
+			err := fn(Difference{Kind: KindMissing, Sku: fileSku.value, Line: fileSku.line})
+			if err != nil {
+				return err
+			}
+			fileSku, ok, err = next()
+			if err != nil {
+				return err
+			}
+		}
+		if ok && fileSku.value == value {
+			summary.MatchedSkus++
+			var err error
+			fileSku, ok, err = next()
+			return err
+		}
+
+		summary.ExtraSkus++
+		return fn(Difference{Kind: KindExtra, Sku: value})
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	for ok {
+		summary.MissingSkus++
+		err = fn(Difference{Kind: KindMissing, Sku: fileSku.value, Line: fileSku.line})
+		if err != nil {
+			return summary, err
+		}
+		fileSku, ok, err = next()
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+// sortFile validates the entries of the file, calling fn with the invalid ones, and returns the valid ones sorted.
+func (r *Reconciler) sortFile(ctx context.Context, source *sku_import.Source, fileSorter *sorter, summary *Summary, fn func(Difference) error) (iterator, error) {
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		line, value, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return fileSorter.sorted()
+		}
+		if err != nil && !errors.Is(err, sku_import.ErrMalformedRecord) {
+			return nil, err
+		}
+
+		normalised := payload.Normalise(value)
+		if err == nil {
+			_, err = r.skuIdFormat.NewTenantSkuId(r.tenant, normalised)
+		}
+		if err != nil {
+			summary.InvalidSkus++
+			err = fn(Difference{Kind: KindInvalid, Sku: value, Line: line, Reason: err.Error()})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		summary.FileSkus++
+		err = fileSorter.add(entry{value: normalised, line: line})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,174 @@
+//+build unit
+
+package sku_reconcile_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/sku_import"
+	"feeder-service/internal/sku/infrastructure/io/sku_reconcile"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx        context.Context
+	dir        string
+	repository *memory.SkuRepository
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.dir = s.T().TempDir()
+	s.repository = memory.NewSkuRepository(domain.NewHydrator())
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) store(tenant domain.Tenant, values ...string) {
+	for _, value := range values {
+		skuId, err := domain.DefaultSkuIdFormat.NewTenantSkuId(tenant, value)
+		s.Require().NoError(err)
+		s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	}
+}
+
+func (s *UnitSuite) reconcile(content string, options ...sku_reconcile.Option) ([]sku_reconcile.Difference, sku_reconcile.Summary) {
+	fileName := filepath.Join(s.dir, "supplier.txt")
+	s.Require().NoError(os.WriteFile(fileName, []byte(content), 0644))
+	source, err := sku_import.Open(fileName, sku_import.FormatPlain, "")
+	s.Require().NoError(err)
+	defer source.Close()
+
+	var differences []sku_reconcile.Difference
+	summary, err := sku_reconcile.New(s.repository, append(options, sku_reconcile.WithTempDir(s.dir))...).Reconcile(s.ctx, source, func(difference sku_reconcile.Difference) error {
+		differences = append(differences, difference)
+		return nil
+	})
+	s.Require().NoError(err)
+
+	return differences, summary
+}
+
+func (s *UnitSuite) TestListTheMissingExtraAndInvalidSkus() {
+	s.store(domain.DefaultTenant, "ABCD-0001", "ABCD-0003", "ABCD-0005")
+	s.store("acme", "ABCD-0002")
+	deleted, err := domain.NewSkuId("ABCD-0004")
+	s.Require().NoError(err)
+	tombstone := domain.NewSku(deleted, domain.Attributes{}, time.Now(), "")
+	s.Require().NoError(s.repository.Save(s.ctx, tombstone))
+	s.Require().NoError(tombstone.Delete())
+	s.Require().NoError(s.repository.Update(s.ctx, tombstone))
+
+	differences, summary := s.reconcile("00ABCD-0004\nABCD-0001\nnot an sku\n\nABCD-0002\nABCD-0001\n ABCD-0006 \nabcd-0007\n")
+	s.Require().Equal([]sku_reconcile.Difference{
+		{Kind: sku_reconcile.KindInvalid, Sku: "not an sku", Line: 3, Reason: "invalid Sku provided: not an sku"},
+		{Kind: sku_reconcile.KindInvalid, Sku: "abcd-0007", Line: 8, Reason: "invalid Sku provided: abcd-0007"},
+		{Kind: sku_reconcile.KindMissing, Sku: "ABCD-0002", Line: 5},
+		{Kind: sku_reconcile.KindExtra, Sku: "ABCD-0003"},
+		{Kind: sku_reconcile.KindMissing, Sku: "ABCD-0004", Line: 1},
+		{Kind: sku_reconcile.KindExtra, Sku: "ABCD-0005"},
+		{Kind: sku_reconcile.KindMissing, Sku: "ABCD-0006", Line: 7},
+	}, differences)
+	s.Require().Equal(sku_reconcile.Summary{
+		FileSkus:       5,
+		DuplicatedSkus: 1,
+		StoreSkus:      3,
+		MatchedSkus:    1,
+		MissingSkus:    3,
+		ExtraSkus:      2,
+		InvalidSkus:    2,
+	}, summary)
+}
+
+func (s *UnitSuite) TestAMalformedLineIsInvalidAndTheNextOnesAreCompared() {
+	s.store(domain.DefaultTenant, "ABCD-0001")
+
+	differences, summary := s.reconcile(strings.Repeat("A", 2*1024*1024) + "\nABCD-0001\n")
+	s.Require().Len(differences, 1)
+	s.Require().Equal(sku_reconcile.KindInvalid, differences[0].Kind)
+	s.Require().Equal(1, differences[0].Line)
+	s.Require().Contains(differences[0].Reason, sku_import.ErrMalformedRecord.Error())
+	s.Require().Equal(1, summary.MatchedSkus)
+	s.Require().Equal(1, summary.InvalidSkus)
+}
+
+func (s *UnitSuite) TestCompareWithTheSkusOfTheTenant() {
+	s.store(domain.DefaultTenant, "ABCD-0001")
+	s.store("acme", "ABCD-0002")
+
+	differences, _ := s.reconcile("ABCD-0002\n", sku_reconcile.WithTenant("acme"))
+	s.Require().Empty(differences)
+}
+
+func (s *UnitSuite) TestValidateTheEntriesWithTheSkuFormatOfTheTenant() {
+	skuIdFormat, err := domain.NewSkuIdFormat("^[a-z]{3}-[0-9]{2}$")
+	s.Require().NoError(err)
+	skuId, err := skuIdFormat.NewTenantSkuId("acme", "abc-01")
+	s.Require().NoError(err)
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+
+	differences, summary := s.reconcile("abc-01\nABCD-0001\n", sku_reconcile.WithTenant("acme"), sku_reconcile.WithSkuIdFormat(skuIdFormat))
+	s.Require().Equal([]sku_reconcile.Difference{
+		{Kind: sku_reconcile.KindInvalid, Sku: "ABCD-0001", Line: 2, Reason: "invalid Sku provided: ABCD-0001"},
+	}, differences)
+	s.Require().Equal(1, summary.MatchedSkus)
+}
+
+func (s *UnitSuite) TestAFileLargerThanAChunkIsSortedOnDisk() {
+	random := rand.New(rand.NewSource(1))
+	var lines []string
+	fileSkus := map[string]bool{}
+	storeSkus := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		value := fmt.Sprintf("ABCD-%04d", random.Intn(2000))
+		lines = append(lines, value)
+		fileSkus[value] = true
+	}
+	for i := 0; i < 2000; i += 3 {
+		value := fmt.Sprintf("ABCD-%04d", i)
+		storeSkus[value] = true
+		s.store(domain.DefaultTenant, value)
+	}
+
+	differences, summary := s.reconcile(strings.Join(lines, "\n"), sku_reconcile.WithChunkSize(7))
+	var missing, extra []string
+	for _, difference := range differences {
+		if difference.Kind == sku_reconcile.KindMissing {
+			missing = append(missing, difference.Sku)
+		} else {
+			extra = append(extra, difference.Sku)
+		}
+	}
+	var expectedMissing, expectedExtra []string
+	for value := range fileSkus {
+		if !storeSkus[value] {
+			expectedMissing = append(expectedMissing, value)
+		}
+	}
+	for value := range storeSkus {
+		if !fileSkus[value] {
+			expectedExtra = append(expectedExtra, value)
+		}
+	}
+	sort.Strings(expectedMissing)
+	sort.Strings(expectedExtra)
+	s.Require().Equal(expectedMissing, missing)
+	s.Require().Equal(expectedExtra, extra)
+	s.Require().Equal(1000, summary.FileSkus)
+	s.Require().Equal(1000-len(fileSkus), summary.DuplicatedSkus)
+	entries, err := os.ReadDir(s.dir)
+	s.Require().NoError(err)
+	s.Require().Len(entries, 1, "the chunks are removed")
+}
@@ -0,0 +1,264 @@
+package sku_reconcile
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxMergedFiles limits the chunk files open at once, more of them are merged in several passes.
+const maxMergedFiles = 64
+
+// entry is a valid sku of the file and its line.
+type entry struct {
+	value string
+	line  int
+}
+
+func less(a, b entry) bool {
+	if a.value != b.value {
+		return a.value < b.value
+	}
+
+	return a.line < b.line
+}
+
+// iterator returns the entries one after another ordered by value, ok is false after the last one.
+type iterator interface {
+	next() (e entry, ok bool, err error)
+	close() error
+}
+
+// sorter sorts more entries than fit in memory: they're sorted in chunks of chunkSize entries, the chunks are
+// written to temporary files in the dir and merged once every entry is added.
+type sorter struct {
+	dir       string
+	chunkSize int
+	chunk     []entry
+	files     []string
+}
+
+func (s *sorter) add(e entry) error {
+	s.chunk = append(s.chunk, e)
+	if len(s.chunk) < s.chunkSize {
+		return nil
+	}
+
+	return s.flush()
+}
+
+// flush writes the chunk sorted to a new file.
+func (s *sorter) flush() error {
+	if len(s.chunk) == 0 {
+		return nil
+	}
+	sort.Slice(s.chunk, func(i, j int) bool {
+		return less(s.chunk[i], s.chunk[j])
+	})
+	fileName, err := s.writeFile(&sliceIterator{entries: s.chunk})
+	if err != nil {
+		return err
+	}
+	s.files = append(s.files, fileName)
+	s.chunk = s.chunk[:0]
+
+	return nil
+}
+
+func (s *sorter) writeFile(entries iterator) (string, error) {
+	file, err := os.CreateTemp(s.dir, "chunk-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	for {
+		e, ok, err := entries.next()
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			break
+		}
+		_, err = fmt.Fprintf(writer, "%s\t%d\n", e.value, e.line)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return file.Name(), writer.Flush()
+}
+
+// sorted returns the entries ordered by value, and by line the ones with the same value. When they all fit in a
+// chunk they're sorted in memory.
+func (s *sorter) sorted() (iterator, error) {
+	if len(s.files) == 0 {
+		sort.Slice(s.chunk, func(i, j int) bool {
+			return less(s.chunk[i], s.chunk[j])
+		})
+		return &sliceIterator{entries: s.chunk}, nil
+	}
+	err := s.flush()
+	if err != nil {
+		return nil, err
+	}
+
+	for len(s.files) > maxMergedFiles {
+		var merged []string
+		for start := 0; start < len(s.files); start += maxMergedFiles {
+			end := start + maxMergedFiles
+			if end > len(s.files) {
+				end = len(s.files)
+			}
+			fileName, err := s.mergeFiles(s.files[start:end])
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, fileName)
+		}
+		s.files = merged
+	}
+
+	return newMergeIterator(s.files)
+}
+
+// mergeFiles merges the sorted files into a new one and removes them.
+func (s *sorter) mergeFiles(fileNames []string) (string, error) {
+	entries, err := newMergeIterator(fileNames)
+	if err != nil {
+		return "", err
+	}
+	fileName, err := s.writeFile(entries)
+	closeErr := entries.close()
+	if err != nil {
+		return "", err
+	}
+	for _, merged := range fileNames {
+		_ = os.Remove(merged)
+	}
+
+	return fileName, closeErr
+}
+
+type sliceIterator struct {
+	entries []entry
+	index   int
+}
+
+func (i *sliceIterator) next() (entry, bool, error) {
+	if i.index >= len(i.entries) {
+		return entry{}, false, nil
+	}
+	i.index++
+
+	return i.entries[i.index-1], true, nil
+}
+
+func (i *sliceIterator) close() error {
+	return nil
+}
+
+// chunkReader reads the entries of a sorted file, current is the next one to return.
+type chunkReader struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	current entry
+}
+
+func (r *chunkReader) read() (bool, error) {
+	if !r.scanner.Scan() {
+		return false, r.scanner.Err()
+	}
+	text := r.scanner.Text()
+	separator := strings.LastIndexByte(text, '\t')
+	if separator < 0 {
+		return false, fmt.Errorf("malformed chunk line %q", text)
+	}
+	line, err := strconv.Atoi(text[separator+1:])
+	if err != nil {
+		return false, fmt.Errorf("malformed chunk line %q", text)
+	}
+	r.current = entry{value: text[:separator], line: line}
+
+	return true, nil
+}
+
+// mergeIterator merges the sorted files through a heap of their readers ordered by their current entry.
+type mergeIterator struct {
+	readers []*chunkReader
+	pending chunkHeap
+}
+
+func newMergeIterator(fileNames []string) (*mergeIterator, error) {
+	merge := &mergeIterator{}
+	for _, fileName := range fileNames {
+		file, err := os.Open(fileName)
+		if err != nil {
+			_ = merge.close()
+			return nil, err
+		}
+		reader := &chunkReader{file: file, scanner: bufio.NewScanner(file)}
+		reader.scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+		merge.readers = append(merge.readers, reader)
+		ok, err := reader.read()
+		if err != nil {
+			_ = merge.close()
+			return nil, err
+		}
+		if ok {
+			merge.pending = append(merge.pending, reader)
+		}
+	}
+	heap.Init(&merge.pending)
+
+	return merge, nil
+}
+
+func (m *mergeIterator) next() (entry, bool, error) {
+	if len(m.pending) == 0 {
+		return entry{}, false, nil
+	}
+	reader := m.pending[0]
+	e := reader.current
+	ok, err := reader.read()
+	if err != nil {
+		return entry{}, false, err
+	}
+	if ok {
+		heap.Fix(&m.pending, 0)
+	} else {
+		heap.Pop(&m.pending)
+	}
+
+	return e, true, nil
+}
+
+func (m *mergeIterator) close() error {
+	var err error
+	for _, reader := range m.readers {
+		closeErr := reader.file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+type chunkHeap []*chunkReader
+
+func (h chunkHeap) Len() int            { return len(h) }
+func (h chunkHeap) Less(i, j int) bool  { return less(h[i].current, h[j].current) }
+func (h chunkHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x interface{}) { *h = append(*h, x.(*chunkReader)) }
+func (h *chunkHeap) Pop() interface{} {
+	old := *h
+	reader := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return reader
+}
@@ -1,11 +1,16 @@
 package server
 
+import "sync"
+
 type ConnectionSlotStatus struct {
+	mutex sync.Mutex
 	maxSlots int
 	slotsInUse int
 }
 
 func (p *ConnectionSlotStatus) UseFreeSlot() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	if p.hasFreeSlot() {
 		p.slotsInUse++
 
@@ -20,13 +25,28 @@ func (p *ConnectionSlotStatus) hasFreeSlot() bool {
 }
 
 func (p *ConnectionSlotStatus) FreesASlot() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	if p.slotsInUse == 0 {
 		return
 	}
 	p.slotsInUse--
 }
 
+// SetMaxSlots changes the number of slots, the slots in use over the new maximum are kept until they're freed.
+func (p *ConnectionSlotStatus) SetMaxSlots(maxSlots int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.maxSlots = maxSlots
+}
+
+func (p *ConnectionSlotStatus) MaxSlots() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.maxSlots
+}
+
 func NewConnectionSlotStatus(maxSlots int) *ConnectionSlotStatus {
 	return &ConnectionSlotStatus{maxSlots: maxSlots, slotsInUse: 0}
 }
-
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
+	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
+)
+
+// recordConnectionError counts the errors that only affect the connection being read and returns false
+// for any other error, as those mean the server can't keep accepting connections.
+func recordConnectionError(r *reporting.Report, client string, err error) bool {
+	switch {
+	case errors.Is(err, sku_reader.ErrConnectionDenied):
+		r.DeniedConnections++
+	case errors.Is(err, sku_reader.ErrIdleTimeout):
+		r.IdleTimeouts++
+	case errors.Is(err, sku_reader.ErrReadTimeout):
+		r.ReadTimeouts++
+	case errors.Is(err, sku_reader.ErrMessageTooLong):
+		r.OversizedMessages++
+	case errors.Is(err, ratelimit.ErrRejected), errors.Is(err, ratelimit.ErrDropped):
+		recordThrottled(&r.Throttling, client, err)
+	case errors.Is(err, sku_reader.ErrSessionClosed):
+	default:
+		return false
+	}
+
+	return true
+}
+
+// recordThrottled counts a message of the client affected by the rate limiter, delayed when there's no error.
+func recordThrottled(r *reporting.ThrottlingReport, client string, err error) {
+	switch {
+	case errors.Is(err, ratelimit.ErrDropped):
+		r.Dropped++
+	case errors.Is(err, ratelimit.ErrRejected):
+		r.Rejected++
+	default:
+		r.Delayed++
+	}
+	if r.Clients == nil {
+		r.Clients = map[string]int{}
+	}
+	r.Clients[client]++
+}
@@ -3,96 +3,390 @@ package server
 import (
 	"context"
 	"errors"
-	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/application/bus"
 	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/payload"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
 	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"log"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
 
+var tracer = otel.Tracer("feeder-service/internal/sku/infrastructure/io/socket/tcp/server")
+
 type Server struct {
-	skuReader               sku_reader.SkuReader
-	createSkuCommandHandler create_sku.CommandHandlerInterface
-	logger                  *log.Logger
+	skuReader       sku_reader.SkuReader
+	commandBus      bus.Dispatcher
+	logger          *log.Logger
+	connectionSlots *ConnectionSlotStatus
+	gracePeriod     time.Duration
+	dryRun          bool
+	running         int32
+	draining        int32
+	report          reporting.Report
+	reportMutex     sync.Mutex
+	// tenantQuotas holds the maximum number of messages of each tenant handled in a run, see SetTenantQuotas.
+	tenantQuotas atomic.Value
+	// admitted counts the messages of each tenant handled in the current run, it's guarded by the reportMutex.
+	admitted map[string]int
 }
 
-type Report struct {
-	CreatedSkus int
-	DuplicatedSkus int
-	InvalidSkus int
+type Option func(*Server)
+
+// WithGracePeriod limits how long the shutdown waits for the messages in progress, when it's exceeded
+// the connections still open are closed. Without it the shutdown waits for all of them.
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(s *Server) {
+		s.gracePeriod = gracePeriod
+	}
 }
 
-func New(skuReader sku_reader.SkuReader, createSkuCommandHandler create_sku.CommandHandlerInterface, logger *log.Logger) *Server {
-	return &Server{skuReader: skuReader, createSkuCommandHandler: createSkuCommandHandler, logger: logger}
+// WithTenantQuotas limits the number of messages of each tenant handled in a run.
+func WithTenantQuotas(quotas map[string]int) Option {
+	return func(s *Server) {
+		s.SetTenantQuotas(quotas)
+	}
+}
+
+// WithDryRun marks the report and the created skus log as the ones of a dry run, whose command bus validates the
+// messages without storing the skus, so they're not taken for the skus of a real run.
+func WithDryRun() Option {
+	return func(s *Server) {
+		s.dryRun = true
+	}
 }
 
-func (s *Server) Run(ctx context.Context, maxConnections int, deadline time.Time) Report {
-	liveCondition := true
+// New returns a server that dispatches the command of every message through the command bus, the messages
+// whose command has no handler registered in the bus are discarded as invalid.
+func New(skuReader sku_reader.SkuReader, commandBus bus.Dispatcher, logger *log.Logger, options ...Option) *Server {
+	server := &Server{
+		skuReader:       skuReader,
+		commandBus:      commandBus,
+		logger:          logger,
+		connectionSlots: NewConnectionSlotStatus(0),
+	}
+	server.SetTenantQuotas(nil)
+	for _, option := range options {
+		option(server)
+	}
+
+	return server
+}
+
+func (s *Server) Run(ctx context.Context, maxConnections int, deadline time.Time) reporting.Report {
+	s.connectionSlots.SetMaxSlots(maxConnections)
+	atomic.StoreInt32(&s.running, 1)
 	if maxConnections <= 0 {
-		liveCondition = false
+		s.drain()
 	}
 
-	sigChannel := make(chan os.Signal)
-	signal.Notify(sigChannel, os.Interrupt, syscall.SIGTERM, syscall.SIGKILL)
+	done := make(chan struct{})
+	defer close(done)
+	sigChannel := make(chan os.Signal, 1)
+	signal.Notify(sigChannel, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChannel)
 	go func() {
 		select {
 		case <-sigChannel:
-			liveCondition = false
+			s.drain()
 		case <-ctx.Done():
-			liveCondition = false
+			s.drain()
+		case <-done:
 		}
 	}()
 
-	report := Report{}
-	connectionSlots := NewConnectionSlotStatus(maxConnections)
+	s.reportMutex.Lock()
+	s.report = reporting.Report{DryRun: s.dryRun}
+	s.admitted = map[string]int{}
+	s.reportMutex.Unlock()
+	report := &s.report
+	mutex := &s.reportMutex
+	connectionSlots := s.connectionSlots
 	var wg sync.WaitGroup
-	var mutex sync.Mutex
-	for liveCondition {
+	for !s.isDraining() {
 		if connectionSlots.UseFreeSlot() {
 			wg.Add(1)
 			go func() {
 				message, err := s.skuReader.Read(deadline)
 				if err != nil {
-					liveCondition = false
+					mutex.Lock()
+					defer mutex.Unlock()
+					if recordConnectionError(report, message.Client, err) {
+						connectionSlots.FreesASlot()
+					} else {
+						s.drain()
+					}
 					wg.Done()
 					return
 				}
-				if message == "terminate" {
-					liveCondition = false
+				if message.Value == "terminate" {
+					s.drain()
+					_ = message.Ack(AckTerminating)
 					wg.Done()
 					return
 				}
-				err = s.createSkuCommandHandler.Handle(ctx, create_sku.Command{Sku: message})
-				if err != nil {
-					if errors.Is(err, domain.ErrSkuAlreadyExists) {
-						mutex.Lock()
-						defer mutex.Unlock()
-						report.DuplicatedSkus++
-						connectionSlots.FreesASlot()
-						wg.Done()
-						return
-					}
+				mutex.Lock()
+				if !message.Resumed {
+					report.AcceptedConnections++
+				}
+				report.ReceivedMessages++
+				mutex.Unlock()
+				if message.Throttled > 0 {
 					mutex.Lock()
-					defer mutex.Unlock()
-					report.InvalidSkus++
-					connectionSlots.FreesASlot()
-					wg.Done()
-					return
+					recordThrottled(&report.Throttling, message.Client, nil)
+					mutex.Unlock()
+				}
+				messageCtx := withSpanContext(ctx, message.SpanContext)
+				skuPayload, tenant, err := s.parse(messageCtx, message.Value)
+				result := reporting.ResultNone
+				if err == nil {
+					result, err = s.handle(withIdempotencyKey(messageCtx, tenant, skuPayload.IdempotencyKey), skuPayload, message.Client)
 				}
 				mutex.Lock()
-				defer mutex.Unlock()
-				report.CreatedSkus++
-				s.logger.Println(message)
+				report.RecordResult(tenant, result, err)
+				if err == nil && result == reporting.ResultCreated {
+					s.logger.Println(s.loggedSku(tenant, skuPayload.Sku))
+				}
+				mutex.Unlock()
+				_ = message.Ack(acknowledgement(result, err))
 				connectionSlots.FreesASlot()
 				wg.Done()
 			}()
+		} else {
+			time.Sleep(freeSlotPollInterval)
+		}
+	}
+
+	return s.shutdown(&wg)
+}
+
+// freeSlotPollInterval is the pause between the checks for a free slot while every slot is in use, checking
+// them without pause takes a whole cpu away from the messages being handled.
+const freeSlotPollInterval = time.Millisecond
+
+// parse returns the payload of a message and its tenant, once the tenant is admitted, recording the span of both.
+func (s *Server) parse(ctx context.Context, message string) (payload.Payload, string, error) {
+	_, span := tracer.Start(ctx, "payload.Parse")
+	defer span.End()
+	skuPayload, err := payload.Parse(message)
+	tenant := ""
+	if err == nil {
+		span.SetAttributes(attribute.String("sku.op", skuPayload.Operation()))
+		tenant, err = s.admit(skuPayload.Tenant)
+		span.SetAttributes(attribute.String("sku.tenant", tenant))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return skuPayload, tenant, err
+}
+
+// withSpanContext makes the span of the read of a message the parent of the spans of its handling, the context is
+// left as it is when the read was not traced.
+func withSpanContext(ctx context.Context, spanContext trace.SpanContext) context.Context {
+	if !spanContext.IsValid() {
+		return ctx
+	}
+
+	return trace.ContextWithSpanContext(ctx, spanContext)
+}
+
+// SetTenantQuotas replaces the maximum number of messages of each tenant handled in a run, the next messages of
+// a tenant that reached its quota are discarded. The tenants without quota, or with a zero quota, have no limit.
+func (s *Server) SetTenantQuotas(quotas map[string]int) {
+	s.tenantQuotas.Store(quotas)
+}
+
+// admit returns the tenant of a message, or reporting.ErrQuotaExceeded when the tenant already reached its quota
+// in this run.
+func (s *Server) admit(tenantValue string) (string, error) {
+	tenant, err := domain.NewTenant(tenantValue)
+	if err != nil {
+		return "", err
+	}
+	quota := s.tenantQuotas.Load().(map[string]int)[tenant.String()]
+
+	s.reportMutex.Lock()
+	defer s.reportMutex.Unlock()
+	if quota > 0 && s.admitted[tenant.String()] >= quota {
+		return tenant.String(), fmt.Errorf("%w: %s already sent %d messages", reporting.ErrQuotaExceeded, tenant, quota)
+	}
+	s.admitted[tenant.String()]++
+
+	return tenant.String(), nil
+}
+
+// DryRunLogPrefix starts the lines of the created skus log of a dry run, the skus that would have been created.
+const DryRunLogPrefix = "dry-run "
+
+// loggedSku returns the sku as it's written in the created skus log, prefixed with its tenant unless it's the default one.
+func (s *Server) loggedSku(tenant, sku string) string {
+	if tenant != domain.DefaultTenant.String() {
+		sku = tenant + ":" + sku
+	}
+	if s.dryRun {
+		return DryRunLogPrefix + sku
+	}
+
+	return sku
+}
+
+// withIdempotencyKey scopes the idempotency key of a message to its tenant, so the tenants can't replay each other's
+// messages. The messages without key are left as they are.
+func withIdempotencyKey(ctx context.Context, tenant, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+
+	return bus.WithIdempotencyKey(ctx, tenant+"/"+key)
+}
+
+// AckTerminating is the acknowledgement of the terminate message.
+const AckTerminating = "terminating"
+
+// acknowledgement is the response sent to a session client: the outcome of its message, followed by the reason
+// when it's invalid, e.g. "created" or "invalid invalid_format".
+func acknowledgement(result reporting.Result, err error) string {
+	messageOutcome := reporting.Outcome(result, err)
+	if messageOutcome == reporting.OutcomeInvalid {
+		return messageOutcome + " " + reporting.InvalidReason(err)
+	}
+
+	return messageOutcome
+}
+
+// handle dispatches the command of the payload op and returns what it did to the sku.
+func (s *Server) handle(ctx context.Context, skuPayload payload.Payload, client string) (reporting.Result, error) {
+	op := skuPayload.Operation()
+	value, err := s.commandBus.Dispatch(ctx, skuPayload.Command(client))
+	if errors.Is(err, bus.ErrNoHandler) {
+		return reporting.ResultNone, fmt.Errorf("%w: %s", payload.ErrUnsupportedOperation, op)
+	}
+
+	switch op {
+	case payload.OpUpdate:
+		return reporting.ResultUpdated, err
+	case payload.OpUpsert:
+		if created, _ := value.(bool); created {
+			return reporting.ResultCreated, err
 		}
+		return reporting.ResultUpdated, err
+	case payload.OpDeactivate:
+		return reporting.ResultDeactivated, err
+	case payload.OpReactivate:
+		return reporting.ResultReactivated, err
+	case payload.OpDelete:
+		return reporting.ResultDeleted, err
+	default:
+		return reporting.ResultCreated, err
+	}
+}
+
+// shutdown stops accepting connections and waits for the messages in progress during the grace period.
+// After it the connections still open are closed and the commands in progress are abandoned, so their
+// result is not part of the returned report.
+func (s *Server) shutdown(inProgress *sync.WaitGroup) reporting.Report {
+	err := s.skuReader.Close()
+	if err != nil {
+		log.Printf("error closing sku reader: %v", err)
+	}
+
+	if waitWithTimeout(inProgress, s.gracePeriod) {
+		return s.Snapshot()
 	}
-	wg.Wait()
+	forceClosedConnections := s.skuReader.CloseConnections()
+	log.Printf("grace period of %s exceeded, %d connections force closed", s.gracePeriod, forceClosedConnections)
+
+	s.reportMutex.Lock()
+	defer s.reportMutex.Unlock()
+	s.report.GracePeriodExceeded = true
+	s.report.ForceClosedConnections = forceClosedConnections
+
+	return s.report.Copy()
+}
+
+// Snapshot returns the report of the current run so far, it can be called while the server is running.
+func (s *Server) Snapshot() reporting.Report {
+	s.reportMutex.Lock()
+	defer s.reportMutex.Unlock()
+
+	return s.report.Copy()
+}
+
+// waitWithTimeout returns false when the wait group is not done before the timeout, a zero timeout waits forever.
+func waitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	if timeout <= 0 {
+		wg.Wait()
+		return true
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-finished:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+var ErrInvalidMaxConnections = errors.New("max connections has to be greater than zero")
+
+// SetMaxConnections changes the number of concurrent connections of a running server,
+// the connections in progress over the new maximum are not interrupted.
+func (s *Server) SetMaxConnections(maxConnections int) error {
+	if maxConnections <= 0 {
+		return ErrInvalidMaxConnections
+	}
+	s.connectionSlots.SetMaxSlots(maxConnections)
+
+	return nil
+}
+
+func (s *Server) MaxConnections() int {
+	return s.connectionSlots.MaxSlots()
+}
+
+var (
+	ErrServerNotRunning = errors.New("server is not running")
+	ErrServerDraining   = errors.New("server is draining")
+)
+
+// CheckAcceptingConnections reports whether the server is running and has not
+// started its shutdown, so it can be used as a readiness check.
+func (s *Server) CheckAcceptingConnections(_ context.Context) error {
+	if atomic.LoadInt32(&s.running) == 0 {
+		return ErrServerNotRunning
+	}
+	if s.isDraining() {
+		return ErrServerDraining
+	}
+
+	return nil
+}
+
+func (s *Server) drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
 
-	return report
+func (s *Server) isDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
 }
@@ -4,13 +4,32 @@ package server_test
 
 import (
 	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
 	"feeder-service/internal/sku/application/command/create_sku"
+	"feeder-service/internal/sku/application/command/deactivate_sku"
+	deactivateMock "feeder-service/internal/sku/application/command/deactivate_sku/mock"
+	"feeder-service/internal/sku/application/command/delete_sku"
+	deleteMock "feeder-service/internal/sku/application/command/delete_sku/mock"
+	"feeder-service/internal/sku/application/command/reactivate_sku"
+	reactivateMock "feeder-service/internal/sku/application/command/reactivate_sku/mock"
+	"feeder-service/internal/sku/application/command/update_sku"
+	updateMock "feeder-service/internal/sku/application/command/update_sku/mock"
+	"feeder-service/internal/sku/application/command/upsert_sku"
+	upsertMock "feeder-service/internal/sku/application/command/upsert_sku/mock"
 	applicationMock "feeder-service/internal/sku/application/command/create_sku/mock"
 	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
+	"feeder-service/internal/sku/infrastructure/io/reporting"
 	"feeder-service/internal/sku/infrastructure/io/socket/tcp/server"
+	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
 	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader/mock"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"feeder-service/internal/sku/infrastructure/tracing"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 	"log"
 	"strconv"
 	"strings"
@@ -30,6 +49,7 @@ type UnitSuite struct {
 	ctx                         context.Context
 	skuReaderMock               *mock.MockSkuReader
 	createSkuCommandHandlerMock *applicationMock.MockCommandHandlerInterface
+	commandBus                  *bus.Bus
 	mockCtrl                    *gomock.Controller
 	logger                      *log.Logger
 	loggerBuffer				*strings.Builder
@@ -41,11 +61,14 @@ func (s *UnitSuite) SetupTest() {
 	s.ctx = context.Background()
 	s.mockCtrl = gomock.NewController(s.T())
 	s.skuReaderMock = mock.NewMockSkuReader(s.mockCtrl)
+	s.skuReaderMock.EXPECT().Close().AnyTimes().Return(nil)
 	s.createSkuCommandHandlerMock = applicationMock.NewMockCommandHandlerInterface(s.mockCtrl)
 	s.loggerBuffer = &strings.Builder{}
 	s.logger = log.New(s.loggerBuffer, "", log.Lmsgprefix)
 	s.deadline = time.Now().Add(10 * time.Second)
-	s.server = server.New(s.skuReaderMock, s.createSkuCommandHandlerMock, s.logger)
+	s.commandBus = bus.New()
+	s.Require().NoError(s.commandBus.Register(create_sku.CommandName, create_sku.BusHandler(s.createSkuCommandHandlerMock)))
+	s.server = server.New(s.skuReaderMock, s.commandBus, s.logger)
 }
 
 func (s *UnitSuite) TearDownTest() {
@@ -57,9 +80,9 @@ func TestSuite(t *testing.T) {
 }
 
 func (s *UnitSuite) TestSkuReaderReadIsCalledFiveTimesAndCreatedSkusAreLoggedAndReportIsReturned() {
-	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku, nil)
-	s.skuReaderMock.EXPECT().Read(s.deadline).Times(3).Return(anotherSku, nil)
-	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return("terminate", nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(3).Return(sku_reader.Message{Value: anotherSku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
 
 	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(nil).Times(1)
 	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: anotherSku}).Return(nil).Times(1)
@@ -74,9 +97,9 @@ func (s *UnitSuite) TestSkuReaderReadIsCalledFiveTimesAndCreatedSkusAreLoggedAnd
 }
 
 func (s *UnitSuite) TestDuplicatedSkusCanBeUpdatedInAConcurrentWayWithNoRaceConditions() {
-	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku, nil)
-	s.skuReaderMock.EXPECT().Read(s.deadline).Times(15000).Return(anotherSku, nil)
-	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return("terminate", nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(15000).Return(sku_reader.Message{Value: anotherSku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
 
 	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(nil).Times(1)
 	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: anotherSku}).Return(nil).Times(1)
@@ -91,11 +114,11 @@ func (s *UnitSuite) TestDuplicatedSkusCanBeUpdatedInAConcurrentWayWithNoRaceCond
 func (s *UnitSuite) TestCreatedSkusCanBeUpdatedInAConcurrentWayWithNoRaceConditions() {
 	for i := 0; i < 10000; i++ {
 		randomSku := "KASL-"+strconv.Itoa(i)
-		s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(randomSku, nil)
+		s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: randomSku}, nil)
 		s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: randomSku}).Return(nil).Times(1)
 	}
 
-	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return("terminate", nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
 
 	report := s.server.Run(s.ctx, 500, s.deadline)
 	s.Require().Equal(10000, report.CreatedSkus)
@@ -105,10 +128,10 @@ func (s *UnitSuite) TestCreatedSkusCanBeUpdatedInAConcurrentWayWithNoRaceConditi
 
 func (s *UnitSuite) TestInvalidSkusCanBeUpdatedInAConcurrentWayWithNoRaceConditions() {
 	invalidSku := "invalid-sku"
-	s.skuReaderMock.EXPECT().Read(s.deadline).Times(10000).Return(invalidSku, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(10000).Return(sku_reader.Message{Value: invalidSku}, nil)
 	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: invalidSku}).Return(domain.ErrInvalidSku).Times(10000)
 
-	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return("terminate", nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
 
 	report := s.server.Run(s.ctx, 500, s.deadline)
 	s.Require().Equal(0, report.CreatedSkus)
@@ -116,13 +139,197 @@ func (s *UnitSuite) TestInvalidSkusCanBeUpdatedInAConcurrentWayWithNoRaceConditi
 	s.Require().Equal(10000, report.InvalidSkus)
 }
 
+func (s *UnitSuite) TestJSONMessagesAreHandledWithTheirAttributes() {
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"sku": "` + sku + `", "brand": "Acme", "price": 10, "currency": "EUR"}`, Client: "10.0.0.1"}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"sku": `}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku, Brand: "Acme", Price: "10", Currency: "EUR", Source: "10.0.0.1"}).Return(nil).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(1, report.CreatedSkus)
+	s.Require().Equal(map[string]int{reporting.InvalidReasonMalformed: 1}, report.InvalidReasons)
+	s.Require().Equal(sku+"\n", s.loggerBuffer.String())
+}
+
+func (s *UnitSuite) TestUpdateAndUpsertMessagesAreHandledByTheirCommandHandlers() {
+	updateSkuCommandHandlerMock := updateMock.NewMockCommandHandlerInterface(s.mockCtrl)
+	upsertSkuCommandHandlerMock := upsertMock.NewMockCommandHandlerInterface(s.mockCtrl)
+	s.Require().NoError(s.commandBus.Register(update_sku.CommandName, update_sku.BusHandler(updateSkuCommandHandlerMock)))
+	s.Require().NoError(s.commandBus.Register(upsert_sku.CommandName, upsert_sku.BusHandler(upsertSkuCommandHandlerMock)))
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(3).Return(sku_reader.Message{Value: `{"op": "update", "sku": "` + sku + `", "brand": "Acme"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(3).Return(sku_reader.Message{Value: `{"op": "upsert", "sku": "` + anotherSku + `"}`, Client: "10.0.0.1"}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	updateCommand := update_sku.Command{Sku: sku, Brand: "Acme"}
+	updateSkuCommandHandlerMock.EXPECT().Handle(s.ctx, updateCommand).Return(nil).Times(1)
+	updateSkuCommandHandlerMock.EXPECT().Handle(s.ctx, updateCommand).Return(domain.ErrSkuUnchanged).Times(1)
+	updateSkuCommandHandlerMock.EXPECT().Handle(s.ctx, updateCommand).Return(domain.ErrSkuNotFound).Times(1)
+	upsertCommand := upsert_sku.Command{Sku: anotherSku, Source: "10.0.0.1"}
+	upsertSkuCommandHandlerMock.EXPECT().Handle(s.ctx, upsertCommand).Return(true, nil).Times(1)
+	upsertSkuCommandHandlerMock.EXPECT().Handle(s.ctx, upsertCommand).Return(false, nil).Times(1)
+	upsertSkuCommandHandlerMock.EXPECT().Handle(s.ctx, upsertCommand).Return(false, domain.ErrSkuUnchanged).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(1, report.CreatedSkus)
+	s.Require().Equal(2, report.UpdatedSkus)
+	s.Require().Equal(2, report.UnchangedSkus)
+	s.Require().Equal(1, report.NotFoundSkus)
+	s.Require().Equal(anotherSku+"\n", s.loggerBuffer.String())
+}
+
+func (s *UnitSuite) TestStatusMessagesAreHandledByTheirCommandHandlers() {
+	deactivateSkuCommandHandlerMock := deactivateMock.NewMockCommandHandlerInterface(s.mockCtrl)
+	reactivateSkuCommandHandlerMock := reactivateMock.NewMockCommandHandlerInterface(s.mockCtrl)
+	deleteSkuCommandHandlerMock := deleteMock.NewMockCommandHandlerInterface(s.mockCtrl)
+	s.Require().NoError(s.commandBus.Register(deactivate_sku.CommandName, deactivate_sku.BusHandler(deactivateSkuCommandHandlerMock)))
+	s.Require().NoError(s.commandBus.Register(reactivate_sku.CommandName, reactivate_sku.BusHandler(reactivateSkuCommandHandlerMock)))
+	s.Require().NoError(s.commandBus.Register(delete_sku.CommandName, delete_sku.BusHandler(deleteSkuCommandHandlerMock)))
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{Value: `{"op": "deactivate", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"op": "reactivate", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"op": "delete", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku, Client: "10.0.0.1"}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	deactivateSkuCommandHandlerMock.EXPECT().Handle(s.ctx, deactivate_sku.Command{Sku: sku}).Return(nil).Times(1)
+	deactivateSkuCommandHandlerMock.EXPECT().Handle(s.ctx, deactivate_sku.Command{Sku: sku}).Return(domain.ErrSkuUnchanged).Times(1)
+	reactivateSkuCommandHandlerMock.EXPECT().Handle(s.ctx, reactivate_sku.Command{Sku: sku}).Return(nil).Times(1)
+	deleteSkuCommandHandlerMock.EXPECT().Handle(s.ctx, delete_sku.Command{Sku: sku}).Return(nil).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku, Source: "10.0.0.1"}).Return(domain.ErrSkuDeleted).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(1, report.DeactivatedSkus)
+	s.Require().Equal(1, report.UnchangedSkus)
+	s.Require().Equal(1, report.ReactivatedSkus)
+	s.Require().Equal(1, report.DeletedSkus)
+	s.Require().Equal(1, report.TombstonedSkus)
+	s.Require().Equal(0, report.InvalidSkus)
+	s.Require().Empty(s.loggerBuffer.String())
+}
+
+func (s *UnitSuite) TestMessagesAreReportedByTenantAndDiscardedOverTheTenantQuota() {
+	s.server = server.New(s.skuReaderMock, s.commandBus, s.logger, server.WithTenantQuotas(map[string]int{"acme": 1}))
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{Value: `{"tenant": "acme", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"tenant": "Acme Inc", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku, Tenant: "acme"}).Return(nil).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(nil).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(2, report.CreatedSkus)
+	s.Require().Equal(2, report.InvalidSkus)
+	s.Require().Equal(map[string]int{reporting.InvalidReasonQuota: 1, reporting.InvalidReasonTenant: 1}, report.InvalidReasons)
+	s.Require().Equal(map[string]reporting.TenantReport{
+		"acme":    {SkuCounters: reporting.SkuCounters{CreatedSkus: 1, InvalidSkus: 1}, QuotaExceeded: 1},
+		"default": {SkuCounters: reporting.SkuCounters{CreatedSkus: 1}},
+	}, report.Tenants)
+	s.Require().Equal(1, report.Counters()["tenant_acme_quota_exceeded"])
+	s.Require().Equal("acme:"+sku+"\n"+sku+"\n", s.loggerBuffer.String())
+}
+
+func (s *UnitSuite) TestRetriesWithTheSameIdempotencyKeyAreReplayedInsteadOfCountedAsDuplicates() {
+	s.commandBus = bus.New(bus.Idempotency(memory.NewIdempotencyStore(), time.Minute, domain.OutcomeErrors...))
+	s.Require().NoError(s.commandBus.Register(create_sku.CommandName, create_sku.BusHandler(s.createSkuCommandHandlerMock)))
+	s.server = server.New(s.skuReaderMock, s.commandBus, s.logger)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{Value: `{"idempotency_key": "retry-1", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"idempotency_key": "retry-1", "tenant": "acme", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(gomock.Any(), create_sku.Command{Sku: sku}).Return(nil).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(gomock.Any(), create_sku.Command{Sku: sku, Tenant: "acme"}).Return(nil).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(domain.ErrSkuAlreadyExists).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(2, report.CreatedSkus)
+	s.Require().Equal(1, report.ReplayedSkus)
+	s.Require().Equal(1, report.DuplicatedSkus)
+	s.Require().Equal(0, report.InvalidSkus)
+	s.Require().Equal(1, report.Tenants["default"].ReplayedSkus)
+	s.Require().Equal(sku+"\n"+"acme:"+sku+"\n", s.loggerBuffer.String())
+}
+
+func (s *UnitSuite) TestTheSkusOfADryRunAreLoggedAsDryRunAndReported() {
+	s.server = server.New(s.skuReaderMock, s.commandBus, s.logger, server.WithDryRun())
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"tenant": "acme", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku, Tenant: "acme"}).Return(nil).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(nil).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().True(report.DryRun)
+	s.Require().Equal(2, report.CreatedSkus)
+	s.Require().Equal(server.DryRunLogPrefix+"acme:"+sku+"\n"+server.DryRunLogPrefix+sku+"\n", s.loggerBuffer.String())
+}
+
+func (s *UnitSuite) TestTheHandlingOfAMessageContinuesTheTraceOfItsRead() {
+	tracerProvider, exporter := tracing.NewInMemoryTracerProvider()
+	otel.SetTracerProvider(tracerProvider)
+	_, readSpan := tracerProvider.Tracer("test").Start(s.ctx, "sku_reader.Read")
+	readSpan.End()
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku, SpanContext: readSpan.SpanContext()}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(gomock.Any(), create_sku.Command{Sku: sku}).Times(1).DoAndReturn(func(ctx context.Context, command create_sku.Command) error {
+		s.Require().Equal(readSpan.SpanContext().TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+		return nil
+	})
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(1, report.CreatedSkus)
+	spans := exporter.GetSpans()
+	s.Require().Len(spans, 2)
+	s.Require().Equal("payload.Parse", spans[1].Name)
+	s.Require().Equal(readSpan.SpanContext().SpanID(), spans[1].Parent.SpanID())
+}
+
+func (s *UnitSuite) TestUpdateMessagesAreInvalidWithoutAnUpdateCommandHandlerInTheBus() {
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: `{"op": "update", "sku": "` + sku + `"}`}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(map[string]int{reporting.InvalidReasonUnsupported: 1}, report.InvalidReasons)
+}
+
+func (s *UnitSuite) TestInvalidSkusAreReportedByReason() {
+	invalidSku := "invalid-sku"
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: invalidSku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: invalidSku}).Return(domain.ErrInvalidSku).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(create_sku.ErrCreatingSku).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(errors.New("unexpected error")).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(3, report.InvalidSkus)
+	s.Require().Equal(3, report.AcceptedConnections)
+	s.Require().Equal(3, report.ReceivedMessages)
+	s.Require().Equal(map[string]int{
+		reporting.InvalidReasonFormat:      1,
+		reporting.InvalidReasonPersistence: 1,
+		reporting.InvalidReasonUnknown:     1,
+	}, report.InvalidReasons)
+	s.Require().Equal(1, report.Counters()["invalid_skus_"+reporting.InvalidReasonFormat])
+}
+
+func (s *UnitSuite) TestTheConnectionOfASessionIsCountedOnceAndItsMessagesSeparately() {
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{Value: anotherSku, Resumed: true}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Return(nil).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: anotherSku}).Return(nil).Times(1)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: anotherSku}).Return(domain.ErrSkuAlreadyExists).Times(1)
+
+	report := s.server.Run(s.ctx, 1, s.deadline)
+	s.Require().Equal(1, report.AcceptedConnections)
+	s.Require().Equal(3, report.ReceivedMessages)
+	s.Require().Equal(3, report.Counters()["received_messages"])
+}
+
 func (s *UnitSuite) TestSkuReaderReadIsNotCalledWhenMaxConnectionsIsZeroAndEmptyReportIsReturned() {
 	s.skuReaderMock.EXPECT().Read(s.deadline).Times(0)
 	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, gomock.Any()).Times(0)
 	s.requireEmptyReportAndNoSkusLogged(s.server.Run(s.ctx, 0, s.deadline))
 }
 
-func (s *UnitSuite) requireEmptyReportAndNoSkusLogged(report server.Report) {
+func (s *UnitSuite) requireEmptyReportAndNoSkusLogged(report reporting.Report) {
 	s.Require().Equal(0, report.CreatedSkus)
 	s.Require().Equal(0, report.DuplicatedSkus)
 	s.Require().Equal(0, report.InvalidSkus)
@@ -130,9 +337,9 @@ func (s *UnitSuite) requireEmptyReportAndNoSkusLogged(report server.Report) {
 }
 
 func (s *UnitSuite) TestServerFinishAndAnEmptyReportIsReturnedWhenContextIsDoneDueToCancel() {
-	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return("terminate", nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
 	ctx, cancelFunc := context.WithCancel(s.ctx)
-	var report server.Report
+	var report reporting.Report
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
@@ -145,12 +352,12 @@ func (s *UnitSuite) TestServerFinishAndAnEmptyReportIsReturnedWhenContextIsDoneD
 }
 
 func (s *UnitSuite) TestServerFinishAndTheSkuIsLoggedWhenContextIsDoneDueToTimeout() {
-	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: sku}, nil)
 	s.createSkuCommandHandlerMock.EXPECT().Handle(gomock.Any(), gomock.Any()).Times(1).Return(nil)
 	s.createSkuCommandHandlerMock.EXPECT().Handle(gomock.Any(), gomock.Any()).AnyTimes().Return(domain.ErrSkuAlreadyExists)
 	ctx, cancelFunc := context.WithTimeout(s.ctx, 0)
 	defer cancelFunc()
-	var report server.Report
+	var report reporting.Report
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
@@ -163,4 +370,188 @@ func (s *UnitSuite) TestServerFinishAndTheSkuIsLoggedWhenContextIsDoneDueToTimeo
 	s.Require().GreaterOrEqual(report.DuplicatedSkus, 0)
 	s.Require().Equal(0, report.InvalidSkus)
 	s.Require().Equal(sku+"\n", s.loggerBuffer.String())
-}
\ No newline at end of file
+}
+func (s *UnitSuite) TestServerIsNotAcceptingConnectionsBeforeRunning() {
+	err := s.server.CheckAcceptingConnections(s.ctx)
+	s.Require().ErrorIs(err, server.ErrServerNotRunning)
+}
+
+func (s *UnitSuite) TestServerIsDrainingAfterRunFinishes() {
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+
+	s.server.Run(s.ctx, maxConnections, s.deadline)
+
+	err := s.server.CheckAcceptingConnections(s.ctx)
+	s.Require().ErrorIs(err, server.ErrServerDraining)
+}
+
+func (s *UnitSuite) TestServerIsAcceptingConnectionsWhileRunning() {
+	reading := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().DoAndReturn(func(time.Time) (sku_reader.Message, error) {
+		once.Do(func() { close(reading) })
+		<-release
+		return sku_reader.Message{Value: "terminate"}, nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		s.server.Run(s.ctx, maxConnections, s.deadline)
+		wg.Done()
+	}()
+	<-reading
+
+	s.Require().NoError(s.server.CheckAcceptingConnections(s.ctx))
+	close(release)
+	wg.Wait()
+}
+
+func (s *UnitSuite) TestSnapshotReturnsTheReportWhileRunning() {
+	handled := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().DoAndReturn(func(time.Time) (sku_reader.Message, error) {
+		<-release
+		return sku_reader.Message{Value: "terminate"}, nil
+	})
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).Times(1).DoAndReturn(func(context.Context, create_sku.Command) error {
+		once.Do(func() { close(handled) })
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var report reporting.Report
+	go func() {
+		report = s.server.Run(s.ctx, maxConnections, s.deadline)
+		wg.Done()
+	}()
+	<-handled
+
+	s.Require().Eventually(func() bool {
+		return s.server.Snapshot().CreatedSkus == 1
+	}, time.Second, 10*time.Millisecond)
+	close(release)
+	wg.Wait()
+	s.Require().Equal(report, s.server.Snapshot())
+	s.Require().Equal(1, report.Counters()["created_skus"])
+}
+
+func (s *UnitSuite) TestThrottledMessagesAreReportedAndNotHandled() {
+	const (
+		client        = "10.0.0.1"
+		anotherClient = "10.0.0.2"
+	)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(3).Return(sku_reader.Message{Client: client}, ratelimit.ErrRejected)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{Client: anotherClient}, ratelimit.ErrDropped)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku, Client: client, Throttled: time.Second}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku, Source: client}).Return(nil).Times(1)
+
+	report := s.server.Run(s.ctx, maxConnections, s.deadline)
+	s.Require().Equal(1, report.CreatedSkus)
+	s.Require().Equal(3, report.Throttling.Rejected)
+	s.Require().Equal(2, report.Throttling.Dropped)
+	s.Require().Equal(1, report.Throttling.Delayed)
+	s.Require().Equal(map[string]int{client: 4, anotherClient: 2}, report.Throttling.Clients)
+}
+
+func (s *UnitSuite) TestDeniedConnectionsAreReportedAndNotHandled() {
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(4).Return(sku_reader.Message{Client: "10.0.0.1"}, sku_reader.ErrConnectionDenied)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, gomock.Any()).Times(0)
+
+	report := s.server.Run(s.ctx, maxConnections, s.deadline)
+	s.Require().Equal(4, report.DeniedConnections)
+	s.Require().Equal(0, report.InvalidSkus)
+}
+
+func (s *UnitSuite) TestSlowAndOversizedConnectionsAreReportedAndNotHandled() {
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(3).Return(sku_reader.Message{}, sku_reader.ErrIdleTimeout)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{}, sku_reader.ErrReadTimeout)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{}, sku_reader.ErrMessageTooLong)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, gomock.Any()).Times(0)
+
+	report := s.server.Run(s.ctx, maxConnections, s.deadline)
+	s.Require().Equal(3, report.IdleTimeouts)
+	s.Require().Equal(2, report.ReadTimeouts)
+	s.Require().Equal(1, report.OversizedMessages)
+}
+
+func (s *UnitSuite) TestClosedSessionsAreNotReportedAndTheServerKeepsRunning() {
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(2).Return(sku_reader.Message{Client: "10.0.0.1"}, sku_reader.ErrSessionClosed)
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: "KASL-3423"}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, gomock.Any()).Return(nil)
+
+	report := s.server.Run(s.ctx, maxConnections, s.deadline)
+	s.Require().Equal(1, report.CreatedSkus)
+	s.Require().Equal(0, report.InvalidSkus)
+}
+
+func (s *UnitSuite) TestMaxConnectionsCanBeChangedWhileRunning() {
+	reading := make(chan struct{}, 10)
+	release := make(chan struct{})
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().DoAndReturn(func(time.Time) (sku_reader.Message, error) {
+		reading <- struct{}{}
+		<-release
+		return sku_reader.Message{Value: "terminate"}, nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		s.server.Run(s.ctx, 1, s.deadline)
+		wg.Done()
+	}()
+	<-reading
+	s.Require().Equal(1, s.server.MaxConnections())
+
+	s.Require().NoError(s.server.SetMaxConnections(3))
+	<-reading
+	<-reading
+	s.Require().Equal(3, s.server.MaxConnections())
+	s.Require().ErrorIs(s.server.SetMaxConnections(0), server.ErrInvalidMaxConnections)
+
+	close(release)
+	wg.Wait()
+}
+
+func (s *UnitSuite) TestShutdownWaitsForTheMessagesInProgressDuringTheGracePeriod() {
+	s.server = server.New(s.skuReaderMock, s.commandBus, s.logger, server.WithGracePeriod(time.Second))
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.skuReaderMock.EXPECT().CloseConnections().Times(0)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).DoAndReturn(func(context.Context, create_sku.Command) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	report := s.server.Run(s.ctx, maxConnections, s.deadline)
+	s.Require().Equal(1, report.CreatedSkus)
+	s.Require().False(report.GracePeriodExceeded)
+	s.Require().Equal(0, report.ForceClosedConnections)
+}
+
+func (s *UnitSuite) TestShutdownForceClosesTheConnectionsWhenTheGracePeriodIsExceeded() {
+	s.server = server.New(s.skuReaderMock, s.commandBus, s.logger, server.WithGracePeriod(50*time.Millisecond))
+	s.skuReaderMock.EXPECT().Read(s.deadline).Times(1).Return(sku_reader.Message{Value: sku}, nil)
+	s.skuReaderMock.EXPECT().Read(s.deadline).AnyTimes().Return(sku_reader.Message{Value: "terminate"}, nil)
+	s.skuReaderMock.EXPECT().CloseConnections().Times(1).Return(1)
+	release := make(chan struct{})
+	defer close(release)
+	s.createSkuCommandHandlerMock.EXPECT().Handle(s.ctx, create_sku.Command{Sku: sku}).DoAndReturn(func(context.Context, create_sku.Command) error {
+		<-release
+		return nil
+	})
+
+	report := s.server.Run(s.ctx, maxConnections, s.deadline)
+	s.Require().Equal(0, report.CreatedSkus)
+	s.Require().True(report.GracePeriodExceeded)
+	s.Require().Equal(1, report.ForceClosedConnections)
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	sku_reader "feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
 	reflect "reflect"
 	time "time"
 
@@ -34,11 +35,39 @@ func (m *MockSkuReader) EXPECT() *MockSkuReaderMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockSkuReader) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockSkuReaderMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSkuReader)(nil).Close))
+}
+
+// CloseConnections mocks base method.
+func (m *MockSkuReader) CloseConnections() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseConnections")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// CloseConnections indicates an expected call of CloseConnections.
+func (mr *MockSkuReaderMockRecorder) CloseConnections() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseConnections", reflect.TypeOf((*MockSkuReader)(nil).CloseConnections))
+}
+
 // Read mocks base method.
-func (m *MockSkuReader) Read(arg0 time.Time) (string, error) {
+func (m *MockSkuReader) Read(arg0 time.Time) (sku_reader.Message, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Read", arg0)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(sku_reader.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2,56 +2,548 @@ package sku_reader
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"feeder-service/internal/sku/infrastructure/io/ipfilter"
+	"feeder-service/internal/sku/infrastructure/io/payload"
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
+
+var tracer = otel.Tracer("feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader")
+
 //go:generate mockgen -destination=mock/sku_reader_mockgen_mock.go -package=mock . SkuReader
 type SkuReader interface {
-	Read(deadline time.Time)(string, error)
+	Read(deadline time.Time)(Message, error)
+	// Close stops accepting connections, the reads waiting for one return ErrListenerClosed.
+	Close() error
+	// CloseConnections closes the accepted connections that are still being read and returns how many there were.
+	CloseConnections() int
+}
+
+// Message is a line received from a client. Client identifies who sent it and it's also filled when Read
+// returns an error after the connection was accepted. SpanContext is the span of the read, so the handling of
+// the message continues its trace, it's not valid when the tracing is disabled.
+type Message struct {
+	Value       string
+	Client      string
+	Throttled   time.Duration
+	SpanContext trace.SpanContext
+	// Resumed is true for the messages of a session after its first one, their connection is already counted.
+	Resumed bool
+	// session is the connection of the message when it was sent in a session, see Ack.
+	session *connection
+}
+
+// Ack sends the response line to the client of a session and hands its connection over to the next Read, that
+// reads the next message of the session. It does nothing when the message wasn't sent in a session, as its
+// connection is already closed.
+func (m Message) Ack(response string) error {
+	if m.session == nil {
+		return nil
+	}
+
+	return m.session.ack(response)
+}
+
+// SessionHeader is the optional first line of a connection that opens a session: every message sent through it
+// is acknowledged with a response line and the connection is kept open for the next message, until the client
+// closes it. Without it the connection is closed once its message is read.
+const SessionHeader = "session"
+
+// ackTimeout limits how long the response of a message waits for a client that doesn't read it.
+const ackTimeout = 10 * time.Second
+
+// TraceParentHeader is the optional first line of a connection that carries the W3C trace context of the client,
+// e.g. "traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". The message is the next line.
+const TraceParentHeader = "traceparent:"
+
+// maxTraceParentHeaderLength is the length of the header line with a version 00 trace parent and its line break.
+const maxTraceParentHeaderLength = len(TraceParentHeader) + 1 + 55 + 2
+
+// ReadLimits protects the server from clients that hold a connection without sending a complete message.
+// IdleTimeout is the maximum time without receiving any byte, ReadTimeout is the maximum time to receive the
+// whole message since the connection was accepted and MaxMessageLength is the maximum size in bytes of a message.
+// A zero value disables the limit.
+type ReadLimits struct {
+	IdleTimeout      time.Duration
+	ReadTimeout      time.Duration
+	MaxMessageLength int
 }
 
 type SkuReaderImpl struct {
-	listener net.Listener
+	listener        net.Listener
+	readLimits      ReadLimits
+	rateLimiter     *ratelimit.Limiter
+	ipFilter        *ipfilter.Filter
+	closed          int32
+
+	openConnectionsMutex sync.Mutex
+	openConnections      map[net.Conn]struct{}
+
+	connections        chan net.Conn
+	sessions           chan *connection
+	done               chan struct{}
+	lifecycleMutex     sync.Mutex
+	acceptLoopStarted  bool
+	acceptLoopFinished sync.WaitGroup
+	acceptErrMutex     sync.Mutex
+	acceptErr          error
+}
+
+type Option func(*SkuReaderImpl)
+
+// WithRateLimiter throttles the accepted connections of each client before their message is read.
+func WithRateLimiter(rateLimiter *ratelimit.Limiter) Option {
+	return func(h *SkuReaderImpl) {
+		h.rateLimiter = rateLimiter
+	}
+}
+
+// WithIPFilter closes the accepted connections whose remote ip is not allowed by the filter.
+func WithIPFilter(ipFilter *ipfilter.Filter) Option {
+	return func(h *SkuReaderImpl) {
+		h.ipFilter = ipFilter
+	}
 }
 
-func New(listener net.Listener) (*SkuReaderImpl, error) {
-	return &SkuReaderImpl{listener: listener}, nil
+func WithReadLimits(readLimits ReadLimits) Option {
+	return func(h *SkuReaderImpl) {
+		h.readLimits = readLimits
+	}
 }
 
-func (h *SkuReaderImpl) Read(deadline time.Time)(string, error) {
-	conn, err := h.connect(deadline)
+func New(listener net.Listener, options ...Option) (*SkuReaderImpl, error) {
+	skuReader := &SkuReaderImpl{
+		listener:    listener,
+		connections:     make(chan net.Conn),
+		sessions:        make(chan *connection),
+		openConnections: map[net.Conn]struct{}{},
+		done:        make(chan struct{}),
+	}
+	for _, option := range options {
+		option(skuReader)
+	}
+
+	return skuReader, nil
+}
+
+func (h *SkuReaderImpl) Read(deadline time.Time) (message Message, err error) {
+	c, err := h.connect(deadline)
 	if err != nil {
-		return "", err
+		return Message{}, err
+	}
+	resumed := c.session
+	if !resumed {
+		h.trackConnection(c.conn)
+	}
+	defer func() {
+		if message.session == nil {
+			h.untrackConnection(c.conn)
+		}
+	}()
+	acceptedAt := time.Now()
+	traceParent := ""
+	defer func() {
+		message.SpanContext = traceRead(acceptedAt, traceParent, message, err)
+	}()
+
+	message = Message{Client: c.client, Resumed: resumed}
+	if !resumed && !h.allowed(message.Client) {
+		return message, fmt.Errorf("%w: %s", ErrConnectionDenied, message.Client)
+	}
+
+	message.Throttled, err = h.throttle(message.Client, deadline)
+	if err != nil {
+		if errors.Is(err, ratelimit.ErrDropped) {
+			_, _, _ = h.readMessage(c)
+		}
+		return message, err
 	}
-	defer conn.Close()
 
-	line, _, err := bufio.NewReader(conn).ReadLine()
+	var line string
+	traceParent, line, err = h.readMessage(c)
 	if err != nil {
-		return "", err
+		return message, err
+	}
+	message.Value = payload.Normalise(line)
+	if c.session {
+		message.session = c
 	}
-	return strings.TrimLeft(string(line), "0"), nil
+
+	return message, nil
 }
 
-func (h *SkuReaderImpl) connect(deadline time.Time) (net.Conn, error) {
-	c := make(chan net.Conn)
-	e := make(chan error)
-	go func() {
-		conn, err := h.listener.Accept()
-		if err != nil {
-			e <- err
-		} else {
-			c <- conn
+// traceRead records the span of a read since its connection was accepted, child of the span of the client when
+// the connection sent its trace parent header, and returns its span context.
+func traceRead(acceptedAt time.Time, traceParent string, message Message, err error) trace.SpanContext {
+	ctx := context.Background()
+	if traceParent != "" {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": traceParent})
+	}
+	_, span := tracer.Start(ctx, "sku_reader.Read",
+		trace.WithTimestamp(acceptedAt),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("net.peer.ip", message.Client)),
+	)
+	defer span.End()
+	if message.Throttled > 0 {
+		span.SetAttributes(attribute.Int64("throttled_ms", message.Throttled.Milliseconds()))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return span.SpanContext()
+}
+
+var (
+	ErrIdleTimeout    = errors.New("idle timeout reading message")
+	ErrReadTimeout    = errors.New("read timeout reading message")
+	ErrMessageTooLong = errors.New("message too long")
+	// ErrSessionClosed is returned when the client closes its session instead of sending the next message.
+	ErrSessionClosed = errors.New("session closed by the client")
+)
+
+// connection is an accepted connection with its own buffered reader, so the messages of a session are read
+// through the same buffer.
+type connection struct {
+	conn       net.Conn
+	client     string
+	connReader *limitedConnReader
+	reader     *bufio.Reader
+	skuReader  *SkuReaderImpl
+	// session is true once the connection sent the SessionHeader.
+	session bool
+}
+
+func (h *SkuReaderImpl) newConnection(conn net.Conn) *connection {
+	connReader := &limitedConnReader{conn: conn, idleTimeout: h.readLimits.IdleTimeout}
+
+	return &connection{
+		conn:       conn,
+		client:     clientIdentity(conn),
+		connReader: connReader,
+		reader:     bufio.NewReader(connReader),
+		skuReader:  h,
+	}
+}
+
+// readMessage returns the trace parent header of the message, empty when it wasn't sent, and the message.
+// The first message of a connection may be preceded by the session header too.
+func (h *SkuReaderImpl) readMessage(c *connection) (string, string, error) {
+	c.connReader.readDeadline = time.Time{}
+	if h.readLimits.ReadTimeout > 0 {
+		c.connReader.readDeadline = time.Now().Add(h.readLimits.ReadTimeout)
+	}
+	// the headers are shorter than a trace parent header, so no line of a valid message is longer than this
+	maxLineLength := 0
+	if h.readLimits.MaxMessageLength > 0 {
+		maxLineLength = h.readLimits.MaxMessageLength + maxTraceParentHeaderLength
+	}
+
+	resumed := c.session
+	line, err := c.readLine(maxLineLength)
+	if resumed && errors.Is(err, io.EOF) {
+		return "", "", ErrSessionClosed
+	}
+	if err == nil && !resumed && line == SessionHeader {
+		c.session = true
+		line, err = c.readLine(maxLineLength)
+	}
+	traceParent := ""
+	if err == nil && strings.HasPrefix(line, TraceParentHeader) {
+		traceParent = strings.TrimSpace(strings.TrimPrefix(line, TraceParentHeader))
+		line, err = c.readLine(maxLineLength)
+	}
+	if err == nil && h.readLimits.MaxMessageLength > 0 && len(line) > h.readLimits.MaxMessageLength {
+		err = ErrMessageTooLong
+	}
+	if errors.Is(err, ErrMessageTooLong) {
+		return traceParent, "", fmt.Errorf("%w: more than %d bytes", ErrMessageTooLong, h.readLimits.MaxMessageLength)
+	}
+	if err != nil {
+		return traceParent, "", err
+	}
+
+	return traceParent, line, nil
+}
+
+// readLine reads up to the next line break and returns ErrMessageTooLong as soon as the line is longer than
+// maxLength, without reading the rest of it. A zero maxLength doesn't limit the line.
+func (c *connection) readLine(maxLength int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := c.reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if maxLength > 0 && len(strings.TrimRight(string(line), "\r\n")) > maxLength {
+			return "", ErrMessageTooLong
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+			return "", c.connReader.translate(err)
 		}
-	}()
 
+		return strings.TrimRight(string(line), "\r\n"), nil
+	}
+}
+
+func (c *connection) ack(response string) error {
+	err := c.conn.SetWriteDeadline(time.Now().Add(ackTimeout))
+	if err == nil {
+		_, err = io.WriteString(c.conn, response+"\n")
+	}
+	if err != nil {
+		c.skuReader.untrackConnection(c.conn)
+		return err
+	}
+	go c.skuReader.resume(c)
+
+	return nil
+}
+
+// resume hands the connection of a session over to the next Read, or closes it when the reader is closed first.
+func (h *SkuReaderImpl) resume(c *connection) {
 	select {
-	case conn := <-c:
-		return conn, nil
-	case err := <- e:
+	case h.sessions <- c:
+	case <-h.done:
+		h.untrackConnection(c.conn)
+	}
+}
+
+// limitedConnReader sets before every read the closest deadline between the idle and the read timeouts.
+type limitedConnReader struct {
+	conn         net.Conn
+	idleTimeout  time.Duration
+	readDeadline time.Time
+	idleDeadline time.Time
+}
+
+func (r *limitedConnReader) Read(p []byte) (int, error) {
+	deadline := r.readDeadline
+	if r.idleTimeout > 0 {
+		r.idleDeadline = time.Now().Add(r.idleTimeout)
+		if deadline.IsZero() || r.idleDeadline.Before(deadline) {
+			deadline = r.idleDeadline
+		}
+	}
+	err := r.conn.SetReadDeadline(deadline)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.conn.Read(p)
+}
+
+func (r *limitedConnReader) translate(err error) error {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return err
+	}
+	if r.readDeadlineReached() {
+		return fmt.Errorf("%w: %s", ErrReadTimeout, err.Error())
+	}
+
+	return fmt.Errorf("%w: %s", ErrIdleTimeout, err.Error())
+}
+
+func (r *limitedConnReader) readDeadlineReached() bool {
+	if r.idleDeadline.IsZero() {
+		return true
+	}
+
+	return !r.readDeadline.IsZero() && !r.readDeadline.After(r.idleDeadline)
+}
+
+var ErrConnectionDenied = errors.New("connection denied by ip filter")
+
+func (h *SkuReaderImpl) allowed(client string) bool {
+	if h.ipFilter == nil {
+		return true
+	}
+
+	return h.ipFilter.Allowed(net.ParseIP(client))
+}
+
+func (h *SkuReaderImpl) throttle(client string, deadline time.Time) (time.Duration, error) {
+	if h.rateLimiter == nil {
+		return 0, nil
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	return h.rateLimiter.Wait(ctx, client)
+}
+
+func clientIdentity(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+
+	return host
+}
+
+// OpenConnections returns the number of accepted connections that are still being read.
+func (h *SkuReaderImpl) OpenConnections() int {
+	h.openConnectionsMutex.Lock()
+	defer h.openConnectionsMutex.Unlock()
+
+	return len(h.openConnections)
+}
+
+func (h *SkuReaderImpl) CloseConnections() int {
+	h.openConnectionsMutex.Lock()
+	defer h.openConnectionsMutex.Unlock()
+	for conn := range h.openConnections {
+		_ = conn.Close()
+	}
+
+	return len(h.openConnections)
+}
+
+func (h *SkuReaderImpl) trackConnection(conn net.Conn) {
+	h.openConnectionsMutex.Lock()
+	defer h.openConnectionsMutex.Unlock()
+	h.openConnections[conn] = struct{}{}
+}
+
+func (h *SkuReaderImpl) untrackConnection(conn net.Conn) {
+	h.openConnectionsMutex.Lock()
+	defer h.openConnectionsMutex.Unlock()
+	delete(h.openConnections, conn)
+	_ = conn.Close()
+}
+
+var ErrListenerClosed = errors.New("listener is closed")
+
+// CheckListening reports whether the listener is still bound and accepting connections.
+func (h *SkuReaderImpl) CheckListening(_ context.Context) error {
+	if h.isClosed() {
+		return ErrListenerClosed
+	}
+
+	return nil
+}
+
+// Close stops the accept loop and closes the listener, the connection waiting to be read (if any) is closed too.
+// The connections already accepted are not interrupted, see CloseConnections.
+func (h *SkuReaderImpl) Close() error {
+	h.lifecycleMutex.Lock()
+	if !atomic.CompareAndSwapInt32(&h.closed, 0, 1) {
+		h.lifecycleMutex.Unlock()
+		return nil
+	}
+	close(h.done)
+	h.lifecycleMutex.Unlock()
+
+	err := h.listener.Close()
+	h.acceptLoopFinished.Wait()
+
+	return err
+}
+
+var ErrDeadlineExceeded = errors.New("deadline exceeded waiting to connect")
+
+func (h *SkuReaderImpl) connect(deadline time.Time) (*connection, error) {
+	err := h.ensureAcceptLoop()
+	if err != nil {
 		return nil, err
-	case <-time.After(deadline.Sub(time.Now())):
-		return nil, errors.New("deadline exceeded waiting to connect")
 	}
-}
\ No newline at end of file
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case conn, ok := <-h.connections:
+		if !ok {
+			return nil, h.acceptError()
+		}
+		return h.newConnection(conn), nil
+	case c := <-h.sessions:
+		return c, nil
+	case <-timer.C:
+		return nil, ErrDeadlineExceeded
+	}
+}
+
+// ensureAcceptLoop starts the accept loop with the first read, so a reader that is never read doesn't accept connections.
+func (h *SkuReaderImpl) ensureAcceptLoop() error {
+	h.lifecycleMutex.Lock()
+	defer h.lifecycleMutex.Unlock()
+	if h.acceptLoopStarted {
+		return nil
+	}
+	if h.isClosed() {
+		return ErrListenerClosed
+	}
+	h.acceptLoopStarted = true
+	h.acceptLoopFinished.Add(1)
+	go h.acceptLoop()
+
+	return nil
+}
+
+// acceptLoop is the only goroutine calling Accept, it hands over every accepted connection to the next Read
+// until the listener fails or the reader is closed. Then it closes the connections channel so the
+// pending and future reads return the accept error.
+func (h *SkuReaderImpl) acceptLoop() {
+	defer h.acceptLoopFinished.Done()
+	defer close(h.connections)
+	for {
+		conn, err := h.listener.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Temporary() && !h.isClosed() {
+				time.Sleep(acceptRetryDelay)
+				continue
+			}
+			h.setAcceptError(err)
+			return
+		}
+
+		select {
+		case h.connections <- conn:
+		case <-h.done:
+			_ = conn.Close()
+			h.setAcceptError(ErrListenerClosed)
+			return
+		}
+	}
+}
+
+const acceptRetryDelay = 10 * time.Millisecond
+
+func (h *SkuReaderImpl) isClosed() bool {
+	return atomic.LoadInt32(&h.closed) == 1
+}
+
+func (h *SkuReaderImpl) setAcceptError(err error) {
+	if errors.Is(err, net.ErrClosed) {
+		err = ErrListenerClosed
+		atomic.StoreInt32(&h.closed, 1)
+	}
+	h.acceptErrMutex.Lock()
+	defer h.acceptErrMutex.Unlock()
+	h.acceptErr = err
+}
+
+func (h *SkuReaderImpl) acceptError() error {
+	h.acceptErrMutex.Lock()
+	defer h.acceptErrMutex.Unlock()
+
+	return h.acceptErr
+}
@@ -3,9 +3,17 @@
 package sku_reader_test
 
 import (
+	"bufio"
+	"context"
+	"feeder-service/internal/sku/infrastructure/io/ipfilter"
+	"feeder-service/internal/sku/infrastructure/io/ratelimit"
 	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
+	"feeder-service/internal/sku/infrastructure/tracing"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"io"
 	"net"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -32,7 +40,7 @@ func (s *IntegrationSuite) SetupTest() {
 }
 
 func (s *IntegrationSuite) TearDownTest() {
-	err := s.listener.Close()
+	err := s.skuReader.Close()
 	s.Require().NoError(err)
 }
 
@@ -51,7 +59,7 @@ func (s *IntegrationSuite) TestRead() {
 			readErrorChan <- readError
 			return
 		}
-		readMessageChan <- readMessage
+		readMessageChan <- readMessage.Value
 	}()
 
 	s.sendMessageFromAClient("000"+expectedMessage)
@@ -76,7 +84,7 @@ func (s *IntegrationSuite) TestReadDeadlineExceed() {
 			readErrorChan <- readError
 			return
 		}
-		readMessageChan <- readMessage
+		readMessageChan <- readMessage.Value
 	}()
 
 	testFinishDeadline := s.deadline.Add(1 * time.Second)
@@ -90,6 +98,262 @@ func (s *IntegrationSuite) TestReadDeadlineExceed() {
 	}
 }
 
+func (s *IntegrationSuite) TestReadReturnsTheClientIp() {
+	readMessageChan := make(chan sku_reader.Message)
+	go func() {
+		readMessage, _ := s.skuReader.Read(s.deadline)
+		readMessageChan <- readMessage
+	}()
+
+	s.sendMessageFromAClient("KASL-3423")
+
+	s.Require().Equal(sku_reader.Message{Value: "KASL-3423", Client: "127.0.0.1"}, <-readMessageChan)
+}
+
+func (s *IntegrationSuite) TestReadRejectsConnectionsOverTheRateLimit() {
+	s.useRateLimit(ratelimit.PolicyReject)
+
+	s.Require().NoError(s.readSentMessage("KASL-3423"))
+	s.Require().ErrorIs(s.readSentMessage("KASL-3424"), ratelimit.ErrRejected)
+}
+
+func (s *IntegrationSuite) TestReadDropsMessagesOverTheRateLimit() {
+	s.useRateLimit(ratelimit.PolicyDrop)
+
+	s.Require().NoError(s.readSentMessage("KASL-3423"))
+	s.Require().ErrorIs(s.readSentMessage("KASL-3424"), ratelimit.ErrDropped)
+}
+
+func (s *IntegrationSuite) TestReadClosesDeniedConnections() {
+	ipFilter, err := ipfilter.New(ipfilter.Rules{Deny: []string{"127.0.0.0/8"}})
+	s.Require().NoError(err)
+	s.skuReader, err = sku_reader.New(s.listener, sku_reader.WithIPFilter(ipFilter))
+	s.Require().NoError(err)
+
+	s.Require().ErrorIs(s.readSentMessage("KASL-3423"), sku_reader.ErrConnectionDenied)
+
+	s.Require().NoError(ipFilter.Reload(ipfilter.Rules{Allow: []string{"127.0.0.1"}}))
+	s.Require().NoError(s.readSentMessage("KASL-3423"))
+}
+
+func (s *IntegrationSuite) TestReadFailsWithIdleTimeoutWhenTheClientSendsNothing() {
+	s.useReadLimits(sku_reader.ReadLimits{IdleTimeout: 50 * time.Millisecond, ReadTimeout: time.Second})
+
+	conn := s.dial()
+	defer conn.Close()
+
+	s.Require().ErrorIs(<-s.readInBackground(), sku_reader.ErrIdleTimeout)
+}
+
+func (s *IntegrationSuite) TestReadFailsWithReadTimeoutWhenTheClientNeverEndsTheMessage() {
+	s.useReadLimits(sku_reader.ReadLimits{IdleTimeout: 100 * time.Millisecond, ReadTimeout: 300 * time.Millisecond})
+
+	conn := s.dial()
+	defer conn.Close()
+	readErrorChan := s.readInBackground()
+	for i := 0; i < 10; i++ {
+		_, err := conn.Write([]byte("K"))
+		if err != nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	s.Require().ErrorIs(<-readErrorChan, sku_reader.ErrReadTimeout)
+}
+
+func (s *IntegrationSuite) TestReadFailsWhenTheMessageIsTooLong() {
+	s.useReadLimits(sku_reader.ReadLimits{MaxMessageLength: 9})
+
+	s.Require().ErrorIs(s.readSentMessage("KASL-34230\n"), sku_reader.ErrMessageTooLong)
+	s.Require().NoError(s.readSentMessage("KASL-3423\r\n"))
+}
+
+func (s *IntegrationSuite) TestSessionsAreReadMessageByMessageUntilTheClientClosesThem() {
+	conn := s.dial()
+	defer conn.Close()
+	_, err := conn.Write([]byte(sku_reader.SessionHeader + "\nKASL-3423\nKASL-3424\n"))
+	s.Require().NoError(err)
+	responses := bufio.NewReader(conn)
+
+	for i, expected := range []string{"KASL-3423", "KASL-3424"} {
+		message, err := s.skuReader.Read(s.deadline)
+		s.Require().NoError(err)
+		s.Require().Equal(expected, message.Value)
+		s.Require().Equal(i > 0, message.Resumed)
+		s.Require().NoError(message.Ack("created"))
+		response, err := responses.ReadString('\n')
+		s.Require().NoError(err)
+		s.Require().Equal("created\n", response)
+	}
+	s.Require().NoError(conn.Close())
+
+	_, err = s.skuReader.Read(s.deadline)
+	s.Require().ErrorIs(err, sku_reader.ErrSessionClosed)
+	s.Require().Equal(0, s.skuReader.OpenConnections())
+}
+
+func (s *IntegrationSuite) TestMessagesOutsideASessionAreNotAcknowledged() {
+	messageChan := make(chan sku_reader.Message, 1)
+	go func() {
+		message, _ := s.skuReader.Read(s.deadline)
+		messageChan <- message
+	}()
+	conn := s.dial()
+	defer conn.Close()
+	_, err := conn.Write([]byte("KASL-3423\n"))
+	s.Require().NoError(err)
+
+	s.Require().NoError((<-messageChan).Ack("created"))
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	s.Require().ErrorIs(err, io.EOF)
+}
+
+func (s *IntegrationSuite) TestTheTraceParentHeaderIsTheParentOfTheReadSpan() {
+	tracerProvider, exporter := tracing.NewInMemoryTracerProvider()
+	otel.SetTracerProvider(tracerProvider)
+	s.useReadLimits(sku_reader.ReadLimits{MaxMessageLength: 9})
+	messageChan := make(chan sku_reader.Message, 1)
+	go func() {
+		message, _ := s.skuReader.Read(s.deadline)
+		messageChan <- message
+	}()
+	s.sendMessageFromAClient(sku_reader.TraceParentHeader + " 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01\nKASL-3423\n")
+
+	message := <-messageChan
+	s.Require().Equal("KASL-3423", message.Value)
+	s.Require().Equal("4bf92f3577b34da6a3ce929d0e0e4736", message.SpanContext.TraceID().String())
+	spans := exporter.GetSpans()
+	s.Require().Len(spans, 1)
+	s.Require().Equal("sku_reader.Read", spans[0].Name)
+	s.Require().Equal("00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	s.Require().True(spans[0].Parent.IsRemote())
+}
+
+func (s *IntegrationSuite) useReadLimits(readLimits sku_reader.ReadLimits) {
+	skuReader, err := sku_reader.New(s.listener, sku_reader.WithReadLimits(readLimits))
+	s.Require().NoError(err)
+	s.skuReader = skuReader
+}
+
+func (s *IntegrationSuite) readInBackground() chan error {
+	readErrorChan := make(chan error, 1)
+	go func() {
+		_, readError := s.skuReader.Read(s.deadline)
+		readErrorChan <- readError
+	}()
+
+	return readErrorChan
+}
+
+func (s *IntegrationSuite) dial() net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	s.Require().NoError(err)
+
+	return conn
+}
+
+func (s *IntegrationSuite) useRateLimit(policy ratelimit.Policy) {
+	skuReader, err := sku_reader.New(s.listener, sku_reader.WithRateLimiter(ratelimit.New(ratelimit.Config{
+		Default: ratelimit.Limit{RatePerSecond: 0.001, Burst: 1, Policy: policy},
+	})))
+	s.Require().NoError(err)
+	s.skuReader = skuReader
+}
+
+func (s *IntegrationSuite) readSentMessage(messageToSend string) error {
+	readErrorChan := s.readInBackground()
+	s.sendMessageFromAClient(messageToSend)
+
+	return <-readErrorChan
+}
+
+func (s *IntegrationSuite) TestReadsThatExceedTheDeadlineDoNotLeakGoroutines() {
+	goroutinesBefore := runtime.NumGoroutine()
+
+	for i := 0; i < 50; i++ {
+		_, err := s.skuReader.Read(time.Now().Add(time.Millisecond))
+		s.Require().ErrorIs(err, sku_reader.ErrDeadlineExceeded)
+	}
+
+	// only the accept loop is still running
+	s.requireGoroutinesEventually(goroutinesBefore + 1)
+}
+
+func (s *IntegrationSuite) TestConnectionAcceptedAfterADeadlineIsReadByTheNextRead() {
+	_, err := s.skuReader.Read(time.Now().Add(time.Millisecond))
+	s.Require().ErrorIs(err, sku_reader.ErrDeadlineExceeded)
+
+	s.sendMessageFromAClient("KASL-3423")
+
+	message, err := s.skuReader.Read(s.deadline)
+	s.Require().NoError(err)
+	s.Require().Equal("KASL-3423", message.Value)
+}
+
+func (s *IntegrationSuite) TestCloseStopsTheAcceptLoop() {
+	goroutinesBefore := runtime.NumGoroutine()
+	_, err := s.skuReader.Read(time.Now().Add(time.Millisecond))
+	s.Require().ErrorIs(err, sku_reader.ErrDeadlineExceeded)
+
+	s.Require().NoError(s.skuReader.Close())
+
+	s.requireGoroutinesEventually(goroutinesBefore)
+	_, err = s.skuReader.Read(s.deadline)
+	s.Require().ErrorIs(err, sku_reader.ErrListenerClosed)
+}
+
+func (s *IntegrationSuite) TestCloseClosesTheConnectionWaitingToBeRead() {
+	_, err := s.skuReader.Read(time.Now().Add(time.Millisecond))
+	s.Require().ErrorIs(err, sku_reader.ErrDeadlineExceeded)
+	conn := s.dial()
+	defer conn.Close()
+
+	s.Require().NoError(s.skuReader.Close())
+
+	s.Require().NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	s.Require().ErrorIs(err, io.EOF)
+}
+
+// requireGoroutinesEventually polls by itself because Eventually runs the condition in another goroutine.
+func (s *IntegrationSuite) requireGoroutinesEventually(expected int) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if runtime.NumGoroutine() <= expected {
+			return
+		}
+	}
+	s.Require().LessOrEqual(runtime.NumGoroutine(), expected, "goroutines leaked")
+}
+
+func (s *IntegrationSuite) TestOpenConnectionsCountsConnectionsBeingRead() {
+	readFinished := make(chan struct{})
+	go func() {
+		_, _ = s.skuReader.Read(s.deadline)
+		close(readFinished)
+	}()
+
+	conn, err := net.Dial("tcp", addr)
+	s.Require().NoError(err)
+	s.Require().Eventually(func() bool {
+		return s.skuReader.OpenConnections() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = conn.Write([]byte("KASL-3423\n"))
+	s.Require().NoError(err)
+	<-readFinished
+	s.Require().NoError(conn.Close())
+	s.Require().Equal(0, s.skuReader.OpenConnections())
+}
+
+func (s *IntegrationSuite) TestCheckListeningFailsOnceClosed() {
+	s.Require().NoError(s.skuReader.CheckListening(context.Background()))
+
+	s.Require().NoError(s.skuReader.Close())
+
+	s.Require().ErrorIs(s.skuReader.CheckListening(context.Background()), sku_reader.ErrListenerClosed)
+}
+
 func (s *IntegrationSuite) sendMessageFromAClient(messageToSend string) {
 	conn, err := net.Dial("tcp", addr)
 	s.Require().NoError(err)
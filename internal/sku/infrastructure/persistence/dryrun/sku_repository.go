@@ -0,0 +1,81 @@
+package dryrun
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"sync"
+)
+
+// SkuRepository validates the changes of a run against a repository without writing to it: the skus are only read
+// from the wrapped repository, with Find, and every change is applied to an in-memory copy of the skus it touches.
+// The copy behaves like the wrapped repository, so a second message of a would be created sku is a duplicate and the
+// updates see the attributes of the previous ones, but it grows with every sku of the run and is lost at the end.
+type SkuRepository struct {
+	repository domain.SkuRepository
+	changes    *memory.SkuRepository
+	// mutex keeps two messages of the same sku from copying it twice, the second copy would undo the first changes.
+	mutex sync.Mutex
+}
+
+func NewSkuRepository(repository domain.SkuRepository, hydrator *domain.Hydrator) *SkuRepository {
+	return &SkuRepository{repository: repository, changes: memory.NewSkuRepository(hydrator)}
+}
+
+func (r *SkuRepository) Find(ctx context.Context, id *domain.SkuId, statuses ...domain.Status) (*domain.Sku, error) {
+	err := r.copy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.changes.Find(ctx, id, statuses...)
+}
+
+// Save returns the same errors as the wrapped repository would, a new sku is kept in memory.
+func (r *SkuRepository) Save(ctx context.Context, sku *domain.Sku) error {
+	err := r.copy(ctx, sku.Id())
+	if err != nil {
+		return err
+	}
+
+	return r.changes.Save(ctx, sku)
+}
+
+func (r *SkuRepository) Update(ctx context.Context, sku *domain.Sku) error {
+	err := r.copy(ctx, sku.Id())
+	if err != nil {
+		return err
+	}
+
+	return r.changes.Update(ctx, sku)
+}
+
+// Scan reads the wrapped repository, the changes of the run are left out.
+func (r *SkuRepository) Scan(ctx context.Context, filter domain.SkuFilter, fn func(*domain.Sku) error) error {
+	return r.repository.Scan(ctx, filter, fn)
+}
+
+func (r *SkuRepository) Restore(ctx context.Context, sku *domain.Sku) error {
+	return r.changes.Restore(ctx, sku)
+}
+
+// copy brings the stored sku of the id, if any, to the in-memory copy the first time the id is seen.
+func (r *SkuRepository) copy(ctx context.Context, id *domain.SkuId) error {
+	copied, err := r.changes.Find(ctx, id)
+	if err != nil || copied != nil {
+		return err
+	}
+	stored, err := r.repository.Find(ctx, id)
+	if err != nil || stored == nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	copied, err = r.changes.Find(ctx, id)
+	if err != nil || copied != nil {
+		return err
+	}
+
+	return r.changes.Restore(ctx, stored)
+}
@@ -0,0 +1,89 @@
+//+build unit
+
+package dryrun_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/domain/mock"
+	"feeder-service/internal/sku/infrastructure/persistence/dryrun"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type SkuRepositoryUnitSuite struct {
+	suite.Suite
+	ctx            context.Context
+	repositoryMock *mock.MockSkuRepository
+	mockCtrl       *gomock.Controller
+	repository     *dryrun.SkuRepository
+}
+
+func (s *SkuRepositoryUnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repositoryMock = mock.NewMockSkuRepository(s.mockCtrl)
+	s.repository = dryrun.NewSkuRepository(s.repositoryMock, domain.NewHydrator())
+}
+
+func (s *SkuRepositoryUnitSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func TestSkuRepositoryUnitSuite(t *testing.T) {
+	suite.Run(t, new(SkuRepositoryUnitSuite))
+}
+
+func (s *SkuRepositoryUnitSuite) TestSaveOfANewSkuOnlyFindsIt() {
+	skuId := s.skuId("DRYR-0001")
+	s.repositoryMock.EXPECT().Find(s.ctx, skuId).Return(nil, nil)
+
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	err := s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), ""))
+	s.Require().ErrorIs(err, domain.ErrSkuAlreadyExists)
+
+	sku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().True(skuId.Equal(sku.Id()))
+}
+
+func (s *SkuRepositoryUnitSuite) TestSaveOfAStoredSkuReturnTheErrorOfTheRepository() {
+	duplicated := s.skuId("DRYR-0002")
+	s.repositoryMock.EXPECT().Find(s.ctx, duplicated).Return(domain.NewSku(duplicated, domain.Attributes{}, time.Now(), ""), nil)
+	deleted := s.skuId("DRYR-0003")
+	tombstone := domain.NewSku(deleted, domain.Attributes{}, time.Now(), "")
+	s.Require().NoError(tombstone.Delete())
+	s.repositoryMock.EXPECT().Find(s.ctx, deleted).Return(tombstone, nil)
+
+	err := s.repository.Save(s.ctx, domain.NewSku(duplicated, domain.Attributes{}, time.Now(), ""))
+	s.Require().ErrorIs(err, domain.ErrSkuAlreadyExists)
+	err = s.repository.Save(s.ctx, domain.NewSku(deleted, domain.Attributes{}, time.Now(), ""))
+	s.Require().ErrorIs(err, domain.ErrSkuDeleted)
+}
+
+func (s *SkuRepositoryUnitSuite) TestUpdateChangesACopyOfTheStoredSku() {
+	skuId := s.skuId("DRYR-0004")
+	s.repositoryMock.EXPECT().Find(s.ctx, skuId).Return(domain.NewSku(skuId, domain.Attributes{}, time.Now(), ""), nil)
+	unknown := s.skuId("DRYR-0005")
+	s.repositoryMock.EXPECT().Find(s.ctx, unknown).Return(nil, nil)
+
+	sku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().NoError(sku.Deactivate())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+
+	sku, err = s.repository.Find(s.ctx, skuId, domain.StatusInactive)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusInactive, sku.Status())
+	err = s.repository.Update(s.ctx, domain.NewSku(unknown, domain.Attributes{}, time.Now(), ""))
+	s.Require().ErrorIs(err, domain.ErrSkuNotFound)
+}
+
+func (s *SkuRepositoryUnitSuite) skuId(value string) *domain.SkuId {
+	skuId, err := domain.NewSkuId(value)
+	s.Require().NoError(err)
+
+	return skuId
+}
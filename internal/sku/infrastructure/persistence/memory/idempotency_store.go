@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"feeder-service/internal/sku/application/bus"
+	"sync"
+	"time"
+)
+
+type idempotencyEntry struct {
+	// outcome is nil while the key is reserved.
+	outcome   *bus.Outcome
+	expiresAt time.Time
+}
+
+// IdempotencyStore keeps the outcomes of the idempotency keys in memory, so they're lost on restart. The expired keys
+// are removed while reserving new ones, at most once every sweep interval.
+type IdempotencyStore struct {
+	mutex         sync.Mutex
+	entries       map[string]idempotencyEntry
+	sweepInterval time.Duration
+	sweptAt       time.Time
+}
+
+const defaultSweepInterval = time.Minute
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{
+		entries:       map[string]idempotencyEntry{},
+		sweepInterval: defaultSweepInterval,
+	}
+}
+
+func (s *IdempotencyStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, *bus.Outcome, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	entry, ok := s.entries[key]
+	if ok && now.Before(entry.expiresAt) {
+		return false, entry.outcome, nil
+	}
+	if now.Sub(s.sweptAt) >= s.sweepInterval {
+		s.sweep(now)
+	}
+	s.entries[key] = idempotencyEntry{expiresAt: now.Add(ttl)}
+
+	return true, nil, nil
+}
+
+func (s *IdempotencyStore) Complete(_ context.Context, key string, outcome bus.Outcome, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[key] = idempotencyEntry{outcome: &outcome, expiresAt: time.Now().Add(ttl)}
+
+	return nil
+}
+
+// Release removes the key unless it was already completed.
+func (s *IdempotencyStore) Release(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if entry, ok := s.entries[key]; ok && entry.outcome == nil {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+func (s *IdempotencyStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.sweptAt = now
+}
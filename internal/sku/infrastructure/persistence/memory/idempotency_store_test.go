@@ -0,0 +1,72 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/application/bus"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx   context.Context
+	store *memory.IdempotencyStore
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = memory.NewIdempotencyStore()
+}
+
+func TestUnitSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestAKeyIsOnlyReservedOnce() {
+	reserved, outcome, err := s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+	s.Require().Nil(outcome)
+
+	reserved, outcome, err = s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Nil(outcome)
+}
+
+func (s *UnitSuite) TestTheOutcomeIsReturnedByTheReservationsOfItsKey() {
+	_, _, err := s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.Complete(s.ctx, "key-1", bus.Outcome{Result: true}, time.Minute))
+	s.Require().NoError(s.store.Release(s.ctx, "key-1"))
+
+	reserved, outcome, err := s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Equal(&bus.Outcome{Result: true}, outcome)
+}
+
+func (s *UnitSuite) TestAReleasedKeyIsReservedAgain() {
+	_, _, err := s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.Release(s.ctx, "key-1"))
+
+	reserved, _, err := s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+}
+
+func (s *UnitSuite) TestAKeyIsReservedAgainAfterItsTTL() {
+	_, _, err := s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.Complete(s.ctx, "key-1", bus.Outcome{}, 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	reserved, _, err := s.store.Reserve(s.ctx, "key-1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	"sort"
+	"sync"
+)
+
+// MigrationStore keeps the records of the applied migrations in memory, so they're lost on restart.
+type MigrationStore struct {
+	mutex   sync.Mutex
+	records map[int]migration.Record
+}
+
+func NewMigrationStore() *MigrationStore {
+	return &MigrationStore{records: map[int]migration.Record{}}
+}
+
+func (s *MigrationStore) Applied(_ context.Context) ([]migration.Record, error) {
+	s.mutex.Lock()
+	records := make([]migration.Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+
+	return records, nil
+}
+
+func (s *MigrationStore) Save(_ context.Context, record migration.Record) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[record.Version] = record
+
+	return nil
+}
@@ -0,0 +1,41 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type MigrationStoreUnitSuite struct {
+	suite.Suite
+	ctx   context.Context
+	store *memory.MigrationStore
+}
+
+func (s *MigrationStoreUnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = memory.NewMigrationStore()
+}
+
+func TestMigrationStoreUnitSuite(t *testing.T) {
+	suite.Run(t, new(MigrationStoreUnitSuite))
+}
+
+func (s *MigrationStoreUnitSuite) TestTheRecordsAreReturnedByVersionAndReplacedBySaveAgain() {
+	appliedAt := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	s.Require().NoError(s.store.Save(s.ctx, migration.Record{Version: 2, Description: "second", AppliedAt: appliedAt}))
+	s.Require().NoError(s.store.Save(s.ctx, migration.Record{Version: 1, Description: "first", AppliedAt: appliedAt}))
+	s.Require().NoError(s.store.Save(s.ctx, migration.Record{Version: 2, Description: "second", AppliedAt: appliedAt.Add(time.Hour)}))
+
+	records, err := s.store.Applied(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Equal([]migration.Record{
+		{Version: 1, Description: "first", AppliedAt: appliedAt},
+		{Version: 2, Description: "second", AppliedAt: appliedAt.Add(time.Hour)},
+	}, records)
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SkuRepository keeps the skus in memory with the same behaviour of the mongo one, so they're lost on restart.
+// It's meant for the tests and the tools that run the application without mongodb.
+type SkuRepository struct {
+	mutex    sync.RWMutex
+	skus     map[string]domain.SkuDTO
+	hydrator *domain.Hydrator
+}
+
+func NewSkuRepository(hydrator *domain.Hydrator) *SkuRepository {
+	return &SkuRepository{skus: map[string]domain.SkuDTO{}, hydrator: hydrator}
+}
+
+func (r *SkuRepository) Find(_ context.Context, id *domain.SkuId, statuses ...domain.Status) (*domain.Sku, error) {
+	r.mutex.RLock()
+	skuDTO, ok := r.skus[id.Key()]
+	r.mutex.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+
+	copied := copyDTO(skuDTO)
+	sku := r.hydrator.Hydrate(&copied)
+	if len(statuses) == 0 {
+		return sku, nil
+	}
+	for _, status := range statuses {
+		if sku.Status() == status {
+			return sku, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Save stores a new sku as it is and records the sighting of an existing one, returning domain.ErrSkuAlreadyExists.
+func (r *SkuRepository) Save(_ context.Context, sku *domain.Sku) error {
+	skuDTO := r.hydrator.Dehydrate(sku)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stored, ok := r.skus[skuDTO.ID]
+	if !ok {
+		r.skus[skuDTO.ID] = copyDTO(*skuDTO)
+		return nil
+	}
+	if stored.Status == domain.StatusDeleted.String() {
+		return fmt.Errorf("%w: %s", domain.ErrSkuDeleted, skuDTO.ID)
+	}
+
+	if skuDTO.LastSeen.After(stored.LastSeen) {
+		stored.LastSeen = skuDTO.LastSeen
+	}
+	stored.SeenCount += skuDTO.SeenCount
+	stored.Sources = addSources(stored.Sources, skuDTO.Sources)
+	r.skus[skuDTO.ID] = stored
+
+	return fmt.Errorf("%w: %s", domain.ErrSkuAlreadyExists, skuDTO.ID)
+}
+
+// Update sets the attributes and status of an existing sku when its stored version is still the one of the sku,
+// the sightings are left as they are.
+func (r *SkuRepository) Update(_ context.Context, sku *domain.Sku) error {
+	skuDTO := r.hydrator.Dehydrate(sku)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stored, ok := r.skus[skuDTO.ID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrSkuNotFound, skuDTO.ID)
+	}
+	if stored.Version != skuDTO.Version {
+		return fmt.Errorf("%w: %s is not version %d anymore", domain.ErrConcurrentModification, skuDTO.ID, skuDTO.Version)
+	}
+
+	// like in mongo, the unknown attributes of the sku don't remove the stored ones
+	updated := copyDTO(*skuDTO)
+	if updated.Name != "" {
+		stored.Name = updated.Name
+	}
+	if updated.Brand != "" {
+		stored.Brand = updated.Brand
+	}
+	if updated.Category != "" {
+		stored.Category = updated.Category
+	}
+	if updated.Price != nil {
+		stored.Price = updated.Price
+	}
+	if updated.Stock != nil {
+		stored.Stock = updated.Stock
+	}
+	stored.Status = updated.Status
+	stored.Version++
+	r.skus[skuDTO.ID] = stored
+
+	return nil
+}
+
+// Scan copies the selected skus while holding the lock, so it sees them as they were when it started.
+func (r *SkuRepository) Scan(_ context.Context, filter domain.SkuFilter, fn func(*domain.Sku) error) error {
+	var skus []*domain.Sku
+	r.mutex.RLock()
+	for _, skuDTO := range r.skus {
+		copied := copyDTO(skuDTO)
+		sku := r.hydrator.Hydrate(&copied)
+		if filter.Matches(sku) {
+			skus = append(skus, sku)
+		}
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(skus, func(i, j int) bool {
+		return skus[i].Id().Key() < skus[j].Id().Key()
+	})
+	for _, sku := range skus {
+		err := fn(sku)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Restore replaces the stored sku with the sku as it is.
+func (r *SkuRepository) Restore(_ context.Context, sku *domain.Sku) error {
+	skuDTO := r.hydrator.Dehydrate(sku)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.skus[skuDTO.ID] = copyDTO(*skuDTO)
+
+	return nil
+}
+
+// copyDTO returns a copy that doesn't share the price, the stock or the sources with the dto.
+func copyDTO(skuDTO domain.SkuDTO) domain.SkuDTO {
+	if skuDTO.Price != nil {
+		price := *skuDTO.Price
+		skuDTO.Price = &price
+	}
+	if skuDTO.Stock != nil {
+		stock := *skuDTO.Stock
+		skuDTO.Stock = &stock
+	}
+	if skuDTO.Sources != nil {
+		skuDTO.Sources = append([]string(nil), skuDTO.Sources...)
+	}
+
+	return skuDTO
+}
+
+func addSources(sources []string, newSources []string) []string {
+	for _, newSource := range newSources {
+		known := false
+		for _, source := range sources {
+			known = known || source == newSource
+		}
+		if !known {
+			sources = append(sources, newSource)
+		}
+	}
+
+	return sources
+}
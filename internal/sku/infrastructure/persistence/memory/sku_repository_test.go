@@ -0,0 +1,188 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type SkuRepositoryUnitSuite struct {
+	suite.Suite
+	ctx        context.Context
+	repository *memory.SkuRepository
+}
+
+func (s *SkuRepositoryUnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repository = memory.NewSkuRepository(domain.NewHydrator())
+}
+
+func TestSkuRepositoryUnitSuite(t *testing.T) {
+	suite.Run(t, new(SkuRepositoryUnitSuite))
+}
+
+func (s *SkuRepositoryUnitSuite) TestSaveAndFind() {
+	skuId := s.skuId("KASL-3423")
+	stock := 5
+	attributes, err := domain.NewAttributes("Running shoes", "Acme", "", nil, &stock)
+	s.Require().NoError(err)
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, attributes, time.Now(), "10.0.0.1")))
+
+	sku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().True(skuId.Equal(sku.Id()))
+	s.Require().Equal(attributes, sku.Attributes())
+
+	sku, err = s.repository.Find(s.ctx, s.skuId("KASL-3424"))
+	s.Require().NoError(err)
+	s.Require().Nil(sku)
+}
+
+func (s *SkuRepositoryUnitSuite) TestSaveADuplicateRecordsTheSighting() {
+	skuId := s.skuId("DUPL-0001")
+	firstSeen := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	lastSeen := firstSeen.Add(time.Hour)
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, firstSeen, "10.0.0.1")))
+
+	err := s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, lastSeen, "10.0.0.2"))
+	s.Require().ErrorIs(err, domain.ErrSkuAlreadyExists)
+	err = s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, firstSeen.Add(time.Minute), "10.0.0.1"))
+	s.Require().ErrorIs(err, domain.ErrSkuAlreadyExists)
+
+	sku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(3, sku.SeenCount())
+	s.Require().True(firstSeen.Equal(sku.FirstSeen()))
+	s.Require().True(lastSeen.Equal(sku.LastSeen()))
+	s.Require().Equal([]string{"10.0.0.1", "10.0.0.2"}, sku.Sources())
+}
+
+func (s *SkuRepositoryUnitSuite) TestUpdateOfAStaleVersionReturnErrConcurrentModification() {
+	skuId := s.skuId("VERS-0001")
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	sku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	staleSku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+
+	s.Require().NoError(sku.Deactivate())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+	s.Require().NoError(staleSku.Delete())
+	s.Require().ErrorIs(s.repository.Update(s.ctx, staleSku), domain.ErrConcurrentModification)
+
+	sku, err = s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusInactive, sku.Status())
+	s.Require().Equal(1, sku.Version())
+	sku, err = s.repository.Find(s.ctx, skuId, domain.StatusActive)
+	s.Require().NoError(err)
+	s.Require().Nil(sku)
+}
+
+func (s *SkuRepositoryUnitSuite) TestUpdateKeepsTheStoredAttributesThatAreNotInTheSku() {
+	skuId := s.skuId("UPDT-0001")
+	attributes, err := domain.NewAttributes("Running shoes", "Acme", "", nil, nil)
+	s.Require().NoError(err)
+	sku := domain.NewSku(skuId, attributes, time.Now(), "")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+
+	newAttributes, err := domain.NewAttributes("", "Acme Sports", "", nil, nil)
+	s.Require().NoError(err)
+	s.Require().NoError(sku.Update(newAttributes))
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+
+	sku, err = s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal("Running shoes", sku.Attributes().Name())
+	s.Require().Equal("Acme Sports", sku.Attributes().Brand())
+	s.Require().ErrorIs(s.repository.Update(s.ctx, domain.NewSku(s.skuId("UPDT-0002"), attributes, time.Now(), "")), domain.ErrSkuNotFound)
+}
+
+func (s *SkuRepositoryUnitSuite) TestSaveOfADeletedSkuReturnErrSkuDeleted() {
+	skuId := s.skuId("TOMB-0001")
+	sku := domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+	s.Require().NoError(sku.Delete())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+
+	err := s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), ""))
+	s.Require().ErrorIs(err, domain.ErrSkuDeleted)
+}
+
+func (s *SkuRepositoryUnitSuite) TestScanReturnsTheSkusSelectedByTheFilterOrderedByKey() {
+	for _, value := range []string{"SCNB-0001", "SCNA-0002", "SCNA-0001", "OTHR-0001"} {
+		s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(s.skuId(value), domain.Attributes{}, time.Now(), "")))
+	}
+	acmeSkuId, err := domain.DefaultSkuIdFormat.NewTenantSkuId("acme", "SCNA-0003")
+	s.Require().NoError(err)
+	acmeSku := domain.NewSku(acmeSkuId, domain.Attributes{}, time.Now(), "")
+	s.Require().NoError(s.repository.Save(s.ctx, acmeSku))
+	s.Require().NoError(acmeSku.Deactivate())
+	s.Require().NoError(s.repository.Update(s.ctx, acmeSku))
+
+	s.Require().Equal([]string{"SCNA-0001", "SCNA-0002", "SCNB-0001", "acme:SCNA-0003"}, s.scan(domain.SkuFilter{Prefixes: []string{"SCNA", "SCNB"}}))
+	s.Require().Equal([]string{"SCNA-0001", "SCNA-0002"}, s.scan(domain.SkuFilter{Tenant: domain.DefaultTenant, Prefixes: []string{"SCNA"}}))
+	s.Require().Equal([]string{"acme:SCNA-0003"}, s.scan(domain.SkuFilter{Tenant: "acme"}))
+	s.Require().Equal([]string{"acme:SCNA-0003"}, s.scan(domain.SkuFilter{Statuses: []domain.Status{domain.StatusInactive}}))
+}
+
+func (s *SkuRepositoryUnitSuite) TestScanSeesTheSkusAsTheyWereWhenItStarted() {
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(s.skuId("SCAN-0001"), domain.Attributes{}, time.Now(), "")))
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(s.skuId("SCAN-0002"), domain.Attributes{}, time.Now(), "")))
+
+	var statuses []domain.Status
+	err := s.repository.Scan(s.ctx, domain.SkuFilter{}, func(sku *domain.Sku) error {
+		statuses = append(statuses, sku.Status())
+		other, err := s.repository.Find(s.ctx, s.skuId("SCAN-0002"))
+		s.Require().NoError(err)
+		if other.Status() == domain.StatusActive {
+			s.Require().NoError(other.Deactivate())
+			s.Require().NoError(s.repository.Update(s.ctx, other))
+		}
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Require().Equal([]domain.Status{domain.StatusActive, domain.StatusActive}, statuses)
+}
+
+func (s *SkuRepositoryUnitSuite) TestRestoreStoresTheSkuAsItIs() {
+	skuId := s.skuId("RSTR-0001")
+	sku := domain.NewSku(skuId, domain.Attributes{}, time.Now(), "10.0.0.1")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+	s.Require().NoError(sku.Delete())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+	tombstone, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.repository.Restore(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	s.Require().NoError(s.repository.Restore(s.ctx, tombstone))
+
+	sku, err = s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusDeleted, sku.Status())
+	s.Require().Equal(1, sku.Version())
+	s.Require().Equal([]string{"10.0.0.1"}, sku.Sources())
+}
+
+func (s *SkuRepositoryUnitSuite) scan(filter domain.SkuFilter) []string {
+	var keys []string
+	err := s.repository.Scan(s.ctx, filter, func(sku *domain.Sku) error {
+		keys = append(keys, sku.Id().Key())
+		return nil
+	})
+	s.Require().NoError(err)
+
+	return keys
+}
+
+func (s *SkuRepositoryUnitSuite) skuId(value string) *domain.SkuId {
+	skuId, err := domain.NewSkuId(value)
+	s.Require().NoError(err)
+
+	return skuId
+}
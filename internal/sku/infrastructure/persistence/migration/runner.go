@@ -0,0 +1,115 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration changes the persistence of the skus, like its indexes, its documents or its schema, from the previous
+// version to its own. Up can run again when the runner stopped before recording it, so it has to leave the
+// persistence as it is when it was already applied.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+}
+
+// Record is a migration applied to the persistence.
+type Record struct {
+	Version     int
+	Description string
+	AppliedAt   time.Time
+}
+
+// Store keeps the records of the applied migrations.
+type Store interface {
+	// Applied returns the records ordered by version.
+	Applied(ctx context.Context) ([]Record, error)
+	// Save records a migration, replacing its previous record if any.
+	Save(ctx context.Context, record Record) error
+}
+
+var (
+	ErrInvalidMigrations = errors.New("invalid migrations")
+	ErrMigrationFailed   = errors.New("migration failed")
+)
+
+// Runner applies the migrations that are not recorded in the store yet, in version order. The instances that
+// start at once can apply the same migrations, as they're safe to apply twice.
+type Runner struct {
+	store      Store
+	migrations []Migration
+}
+
+// NewRunner returns ErrInvalidMigrations when a version is not positive or is repeated.
+func NewRunner(store Store, migrations []Migration) (*Runner, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("%w: version %d is not positive", ErrInvalidMigrations, migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("%w: version %d is repeated", ErrInvalidMigrations, migration.Version)
+		}
+	}
+
+	return &Runner{store: store, migrations: sorted}, nil
+}
+
+// Applied returns the records of the applied migrations, the ones of newer versions unknown to the runner included.
+func (r *Runner) Applied(ctx context.Context) ([]Record, error) {
+	return r.store.Applied(ctx)
+}
+
+// Pending returns the migrations that are not applied yet ordered by version, a migration older than the last
+// applied one is pending too when it's not recorded.
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	records, err := r.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := map[int]bool{}
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range r.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Run applies the pending migrations and returns their records. It stops at the first one that fails, returning
+// ErrMigrationFailed, the previous ones stay applied.
+func (r *Runner) Run(ctx context.Context) ([]Record, error) {
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, migration := range pending {
+		err = migration.Up(ctx)
+		if err != nil {
+			return records, fmt.Errorf("%w: %d %s: %s", ErrMigrationFailed, migration.Version, migration.Description, err.Error())
+		}
+		record := Record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+		err = r.store.Save(ctx, record)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,99 @@
+//+build unit
+
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/persistence/memory"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx     context.Context
+	store   *memory.MigrationStore
+	applied []int
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = memory.NewMigrationStore()
+	s.applied = nil
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) migration(version int, err error) migration.Migration {
+	return migration.Migration{
+		Version:     version,
+		Description: "migration",
+		Up: func(context.Context) error {
+			if err == nil {
+				s.applied = append(s.applied, version)
+			}
+			return err
+		},
+	}
+}
+
+func (s *UnitSuite) TestRunAppliesThePendingMigrationsInVersionOrderOnce() {
+	runner, err := migration.NewRunner(s.store, []migration.Migration{s.migration(2, nil), s.migration(1, nil)})
+	s.Require().NoError(err)
+
+	records, err := runner.Run(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(records, 2)
+	s.Require().Equal([]int{1, 2}, s.applied)
+	applied, err := runner.Applied(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(records, applied)
+
+	records, err = runner.Run(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(records)
+	s.Require().Equal([]int{1, 2}, s.applied)
+}
+
+func (s *UnitSuite) TestAFailedMigrationStopsTheRunAndIsPendingAfterwards() {
+	failure := errors.New("index build failed")
+	runner, err := migration.NewRunner(s.store, []migration.Migration{s.migration(1, nil), s.migration(2, failure), s.migration(3, nil)})
+	s.Require().NoError(err)
+
+	records, err := runner.Run(s.ctx)
+	s.Require().ErrorIs(err, migration.ErrMigrationFailed)
+	s.Require().Contains(err.Error(), failure.Error())
+	s.Require().Len(records, 1)
+	s.Require().Equal([]int{1}, s.applied)
+
+	pending, err := runner.Pending(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 2)
+	s.Require().Equal(2, pending[0].Version)
+}
+
+func (s *UnitSuite) TestAnOlderMigrationThatIsNotRecordedIsPending() {
+	runner, err := migration.NewRunner(s.store, []migration.Migration{s.migration(2, nil)})
+	s.Require().NoError(err)
+	_, err = runner.Run(s.ctx)
+	s.Require().NoError(err)
+
+	runner, err = migration.NewRunner(s.store, []migration.Migration{s.migration(1, nil), s.migration(2, nil)})
+	s.Require().NoError(err)
+	pending, err := runner.Pending(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 1)
+	s.Require().Equal(1, pending[0].Version)
+}
+
+func (s *UnitSuite) TestRepeatedOrNotPositiveVersionsAreRejected() {
+	_, err := migration.NewRunner(s.store, []migration.Migration{s.migration(1, nil), s.migration(1, nil)})
+	s.Require().ErrorIs(err, migration.ErrInvalidMigrations)
+
+	_, err = migration.NewRunner(s.store, []migration.Migration{s.migration(0, nil)})
+	s.Require().ErrorIs(err, migration.ErrInvalidMigrations)
+}
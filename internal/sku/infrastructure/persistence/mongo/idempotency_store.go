@@ -0,0 +1,121 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const idempotencyCollectionName = "idempotency_key"
+
+// IdempotencyStore keeps the outcomes of the idempotency keys in mongodb, so they survive restarts and are shared by
+// every instance. The expired keys are removed by the TTL index of the collection.
+type IdempotencyStore struct {
+	collection *mongo.Collection
+}
+
+type idempotencyKeyDTO struct {
+	Key string `bson:"_id"`
+	// Outcome is nil while the key is reserved.
+	Outcome   *idempotencyOutcomeDTO `bson:"outcome"`
+	ExpiresAt time.Time              `bson:"expires_at"`
+}
+
+type idempotencyOutcomeDTO struct {
+	Result  interface{} `bson:"result"`
+	Err     string      `bson:"error,omitempty"`
+	Message string      `bson:"message,omitempty"`
+}
+
+// NewIdempotencyStore creates the TTL index of the collection when it's missing, the migrations don't create it.
+func NewIdempotencyStore(ctx context.Context, db *mongo.Database) (*IdempotencyStore, error) {
+	if db == nil {
+		return nil, ErrMongoDBNil
+	}
+	store := &IdempotencyStore{collection: db.Collection(idempotencyCollectionName)}
+	err := store.EnsureIndexes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSave, err.Error())
+	}
+
+	return store, nil
+}
+
+// EnsureIndexes creates the TTL index that removes the expired keys. Mongodb removes them about once a minute,
+// so Reserve replaces the expired keys that were not removed yet.
+func (s *IdempotencyStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
+// Reserve inserts the key, or replaces it when it's expired but not removed yet. When the key is stored and not
+// expired the filter doesn't match it and the insert of the upsert fails with a duplicate key, so the key is only
+// reserved once.
+func (s *IdempotencyStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, *bus.Outcome, error) {
+	now := time.Now()
+	_, err := s.collection.ReplaceOne(ctx,
+		bson.M{"_id": key, "expires_at": bson.M{"$lte": now}},
+		idempotencyKeyDTO{Key: key, ExpiresAt: now.Add(ttl)},
+		options.Replace().SetUpsert(true),
+	)
+	if err == nil {
+		return true, nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, nil, fmt.Errorf("%w: %s", ErrSave, err.Error())
+	}
+
+	var idempotencyKey idempotencyKeyDTO
+	err = s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&idempotencyKey)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// the key was removed since, it's reserved by the next attempt
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("%w: %s", ErrFind, err.Error())
+	}
+	if idempotencyKey.Outcome == nil {
+		return false, nil, nil
+	}
+
+	return false, &bus.Outcome{
+		Result:  idempotencyKey.Outcome.Result,
+		Err:     idempotencyKey.Outcome.Err,
+		Message: idempotencyKey.Outcome.Message,
+	}, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, outcome bus.Outcome, ttl time.Duration) error {
+	idempotencyKey := idempotencyKeyDTO{
+		Key:       key,
+		Outcome:   &idempotencyOutcomeDTO{Result: outcome.Result, Err: outcome.Err, Message: outcome.Message},
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": key}, idempotencyKey, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrSave, err.Error())
+	}
+
+	return nil
+}
+
+var ErrDelete = fmt.Errorf("error during delete execution")
+
+// Release removes the key unless it was already completed.
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "outcome": nil})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDelete, err.Error())
+	}
+
+	return nil
+}
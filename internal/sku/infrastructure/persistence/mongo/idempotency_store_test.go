@@ -0,0 +1,110 @@
+//+build integration
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/application/bus"
+	mongo2 "feeder-service/internal/sku/infrastructure/persistence/mongo"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"testing"
+	"time"
+)
+
+type IdempotencyStoreIntegrationSuite struct {
+	suite.Suite
+	ctx   context.Context
+	db    *mongo.Database
+	store *mongo2.IdempotencyStore
+}
+
+func (s *IdempotencyStoreIntegrationSuite) SetupSuite() {
+	s.ctx = context.Background()
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	s.Require().NoError(err)
+	err = mongoClient.Connect(s.ctx)
+	s.Require().NoError(err)
+	s.db = mongoClient.Database("idempotency_integration_test")
+	s.store, err = mongo2.NewIdempotencyStore(s.ctx, s.db)
+	s.Require().NoError(err)
+}
+
+func (s *IdempotencyStoreIntegrationSuite) TearDownSuite() {
+	err := s.db.Drop(s.ctx)
+	s.Require().NoError(err)
+}
+
+func TestIdempotencyStoreIntegration(t *testing.T) {
+	suite.Run(t, new(IdempotencyStoreIntegrationSuite))
+}
+
+func (s *IdempotencyStoreIntegrationSuite) TestReturnErrMongoDbNil() {
+	_, err := mongo2.NewIdempotencyStore(s.ctx, nil)
+	s.Require().True(errors.Is(err, mongo2.ErrMongoDBNil))
+}
+
+func (s *IdempotencyStoreIntegrationSuite) TestTheStoreCreatesTheTTLIndexOfTheKeys() {
+	var indexes []bson.M
+	cursor, err := s.db.Collection("idempotency_key").Indexes().List(s.ctx)
+	s.Require().NoError(err)
+	s.Require().NoError(cursor.All(s.ctx, &indexes))
+
+	expiring := 0
+	for _, index := range indexes {
+		if _, ok := index["expireAfterSeconds"]; ok {
+			expiring++
+		}
+	}
+	s.Require().Equal(1, expiring)
+}
+
+func (s *IdempotencyStoreIntegrationSuite) TestAKeyIsOnlyReservedOnceUntilItsTTL() {
+	reserved, outcome, err := s.store.Reserve(s.ctx, "reserved", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+	s.Require().Nil(outcome)
+	reserved, outcome, err = s.store.Reserve(s.ctx, "reserved", time.Minute)
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Nil(outcome)
+
+	_, _, err = s.store.Reserve(s.ctx, "expired", -time.Second)
+	s.Require().NoError(err)
+	reserved, _, err = s.store.Reserve(s.ctx, "expired", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+}
+
+func (s *IdempotencyStoreIntegrationSuite) TestTheOutcomeIsReturnedByTheReservationsOfItsKey() {
+	_, _, err := s.store.Reserve(s.ctx, "created", time.Minute)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.Complete(s.ctx, "created", bus.Outcome{Result: true}, time.Minute))
+	_, _, err = s.store.Reserve(s.ctx, "duplicated", time.Minute)
+	s.Require().NoError(err)
+	duplicated := bus.Outcome{Err: "sku already exists", Message: "sku already exists: ABCD-1234"}
+	s.Require().NoError(s.store.Complete(s.ctx, "duplicated", duplicated, time.Minute))
+	s.Require().NoError(s.store.Release(s.ctx, "duplicated"))
+
+	reserved, outcome, err := s.store.Reserve(s.ctx, "created", time.Minute)
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Equal(&bus.Outcome{Result: true}, outcome)
+	reserved, outcome, err = s.store.Reserve(s.ctx, "duplicated", time.Minute)
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Equal(&duplicated, outcome)
+}
+
+func (s *IdempotencyStoreIntegrationSuite) TestAReleasedKeyIsReservedAgain() {
+	_, _, err := s.store.Reserve(s.ctx, "released", time.Minute)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.Release(s.ctx, "released"))
+
+	reserved, _, err := s.store.Reserve(s.ctx, "released", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+}
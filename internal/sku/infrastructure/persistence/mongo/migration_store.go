@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"context"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const migrationCollectionName = "migration"
+
+// MigrationStore keeps the records of the applied migrations in mongodb, one document by version.
+type MigrationStore struct {
+	collection *mongo.Collection
+}
+
+type migrationDTO struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+func NewMigrationStore(db *mongo.Database) (*MigrationStore, error) {
+	if db == nil {
+		return nil, ErrMongoDBNil
+	}
+	return &MigrationStore{collection: db.Collection(migrationCollectionName)}, nil
+}
+
+func (s *MigrationStore) Applied(ctx context.Context) ([]migration.Record, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFind, err.Error())
+	}
+	var migrationDTOs []migrationDTO
+	err = cursor.All(ctx, &migrationDTOs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFind, err.Error())
+	}
+
+	records := make([]migration.Record, 0, len(migrationDTOs))
+	for _, migrationDTO := range migrationDTOs {
+		records = append(records, migration.Record{
+			Version:     migrationDTO.Version,
+			Description: migrationDTO.Description,
+			AppliedAt:   migrationDTO.AppliedAt,
+		})
+	}
+
+	return records, nil
+}
+
+func (s *MigrationStore) Save(ctx context.Context, record migration.Record) error {
+	migrationDTO := migrationDTO{Version: record.Version, Description: record.Description, AppliedAt: record.AppliedAt}
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": record.Version}, migrationDTO, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrSave, err.Error())
+	}
+
+	return nil
+}
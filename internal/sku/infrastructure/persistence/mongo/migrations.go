@@ -0,0 +1,123 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/domain"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// namespaceExistsCode is the code of the error of mongodb when a collection is created twice.
+const namespaceExistsCode = 48
+
+// Migrations returns the migrations of the collections of the skus, a new one is appended with the next version
+// and the applied ones are never changed.
+func Migrations(db *mongo.Database) ([]migration.Migration, error) {
+	if db == nil {
+		return nil, ErrMongoDBNil
+	}
+	skus := db.Collection(collectionName)
+
+	return []migration.Migration{
+		{
+			Version:     1,
+			Description: "idempotency key expiry index",
+			// NewIdempotencyStore creates the index when it's missing, so the keys expire before the migrations are
+			// applied. The version is kept as it's recorded as applied in the collections already migrated.
+			Up: func(context.Context) error {
+				return nil
+			},
+		},
+		{
+			Version:     2,
+			Description: "sku tenant, status and sighting indexes",
+			Up: func(ctx context.Context) error {
+				_, err := skus.Indexes().CreateMany(ctx, skuIndexes())
+				return err
+			},
+		},
+		{
+			Version:     3,
+			Description: "sku status backfill",
+			Up: func(ctx context.Context) error {
+				// the skus stored before the status was introduced are active, see domain.Hydrator
+				_, err := skus.UpdateMany(ctx,
+					bson.M{"status": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"status": domain.StatusActive.String()}},
+				)
+				return err
+			},
+		},
+		{
+			Version:     4,
+			Description: "sku schema validator",
+			Up: func(ctx context.Context) error {
+				return setValidator(ctx, db, collectionName, skuSchema())
+			},
+		},
+	}, nil
+}
+
+// skuIndexes returns the indexes of the scans of the skus. The prefixes of the default tenant are served by the
+// _id index and the ones of the rest of the tenants by the tenant one.
+func skuIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("tenant_id")},
+		{Keys: bson.D{{Key: "status", Value: 1}}, Options: options.Index().SetName("status")},
+		{Keys: bson.D{{Key: "first_seen", Value: 1}}, Options: options.Index().SetName("first_seen")},
+		{Keys: bson.D{{Key: "last_seen", Value: 1}}, Options: options.Index().SetName("last_seen")},
+	}
+}
+
+// skuSchema returns the validator of the documents of the skus, see domain.SkuDTO. The status is not required as
+// the instances that are not migrated yet can still read the skus without it.
+func skuSchema() bson.M {
+	integer := bson.M{"bsonType": bson.A{"int", "long"}}
+	text := bson.M{"bsonType": "string"}
+	date := bson.M{"bsonType": "date"}
+
+	return bson.M{"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "version", "first_seen", "last_seen", "seen_count"},
+		"properties": bson.M{
+			"_id":      text,
+			"tenant":   text,
+			"name":     text,
+			"brand":    text,
+			"category": text,
+			"price": bson.M{
+				"bsonType":   "object",
+				"required":   bson.A{"amount", "currency"},
+				"properties": bson.M{"amount": integer, "currency": text},
+			},
+			"stock": integer,
+			"status": bson.M{"enum": bson.A{
+				domain.StatusActive.String(), domain.StatusInactive.String(), domain.StatusDeleted.String(),
+			}},
+			"version":    integer,
+			"first_seen": date,
+			"last_seen":  date,
+			"seen_count": integer,
+			"sources":    bson.M{"bsonType": "array", "items": text},
+		},
+	}}
+}
+
+// setValidator creates the collection with the validator, or sets the validator of the existing collection. The
+// validation level is moderate, so the documents stored before that don't match it can still be updated.
+func setValidator(ctx context.Context, db *mongo.Database, name string, validator bson.M) error {
+	err := db.CreateCollection(ctx, name, options.CreateCollection().SetValidator(validator).SetValidationLevel("moderate"))
+	var commandErr mongo.CommandError
+	if err == nil || !errors.As(err, &commandErr) || commandErr.Code != namespaceExistsCode {
+		return err
+	}
+
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: name},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+}
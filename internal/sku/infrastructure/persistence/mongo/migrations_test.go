@@ -0,0 +1,116 @@
+//+build integration
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/persistence/migration"
+	mongo2 "feeder-service/internal/sku/infrastructure/persistence/mongo"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"testing"
+	"time"
+)
+
+type MigrationsIntegrationSuite struct {
+	suite.Suite
+	ctx context.Context
+	db  *mongo.Database
+}
+
+func (s *MigrationsIntegrationSuite) SetupTest() {
+	s.ctx = context.Background()
+	mongoClient, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	s.Require().NoError(err)
+	err = mongoClient.Connect(s.ctx)
+	s.Require().NoError(err)
+	s.db = mongoClient.Database("migrations_integration_test")
+}
+
+func (s *MigrationsIntegrationSuite) TearDownTest() {
+	err := s.db.Drop(s.ctx)
+	s.Require().NoError(err)
+}
+
+func TestMigrationsIntegration(t *testing.T) {
+	suite.Run(t, new(MigrationsIntegrationSuite))
+}
+
+func (s *MigrationsIntegrationSuite) runner() *migration.Runner {
+	store, err := mongo2.NewMigrationStore(s.db)
+	s.Require().NoError(err)
+	migrations, err := mongo2.Migrations(s.db)
+	s.Require().NoError(err)
+	runner, err := migration.NewRunner(store, migrations)
+	s.Require().NoError(err)
+
+	return runner
+}
+
+func (s *MigrationsIntegrationSuite) TestReturnErrMongoDbNil() {
+	_, err := mongo2.Migrations(nil)
+	s.Require().True(errors.Is(err, mongo2.ErrMongoDBNil))
+	_, err = mongo2.NewMigrationStore(nil)
+	s.Require().True(errors.Is(err, mongo2.ErrMongoDBNil))
+}
+
+func (s *MigrationsIntegrationSuite) TestTheMigrationsAreAppliedOnceAndRecorded() {
+	_, err := s.db.Collection("sku").InsertOne(s.ctx, bson.M{
+		"_id": "LEGC-0001", "version": 0, "first_seen": time.Now(), "last_seen": time.Now(), "seen_count": 1,
+	})
+	s.Require().NoError(err)
+
+	records, err := s.runner().Run(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(records, 4)
+	records, err = s.runner().Run(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(records)
+	applied, err := s.runner().Applied(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(applied, 4)
+
+	var legacy bson.M
+	s.Require().NoError(s.db.Collection("sku").FindOne(s.ctx, bson.M{"_id": "LEGC-0001"}).Decode(&legacy))
+	s.Require().Equal("active", legacy["status"])
+	s.Require().ElementsMatch([]string{"_id_", "tenant_id", "status", "first_seen", "last_seen"}, s.indexNames("sku"))
+}
+
+func (s *MigrationsIntegrationSuite) TestTheSchemaValidatorRejectsTheInvalidSkus() {
+	_, err := s.runner().Run(s.ctx)
+	s.Require().NoError(err)
+
+	_, err = s.db.Collection("sku").InsertOne(s.ctx, bson.M{
+		"_id": "INVL-0001", "status": "archived", "version": 0, "first_seen": time.Now(), "last_seen": time.Now(), "seen_count": 1,
+	})
+	var writeErr mongo.WriteException
+	s.Require().True(errors.As(err, &writeErr))
+	_, err = s.db.Collection("sku").InsertOne(s.ctx, bson.M{"_id": "INVL-0002"})
+	s.Require().True(errors.As(err, &writeErr))
+}
+
+func (s *MigrationsIntegrationSuite) TestTheMigrationsCanBeAppliedAgain() {
+	migrations, err := mongo2.Migrations(s.db)
+	s.Require().NoError(err)
+	for i := 0; i < 2; i++ {
+		for _, migration := range migrations {
+			s.Require().NoError(migration.Up(s.ctx), migration.Description)
+		}
+	}
+}
+
+func (s *MigrationsIntegrationSuite) indexNames(collectionName string) []string {
+	cursor, err := s.db.Collection(collectionName).Indexes().List(s.ctx)
+	s.Require().NoError(err)
+	var indexes []bson.M
+	s.Require().NoError(cursor.All(s.ctx, &indexes))
+	var names []string
+	for _, index := range indexes {
+		names = append(names, index["name"].(string))
+	}
+
+	return names
+}
@@ -6,11 +6,20 @@ import (
 	"feeder-service/internal/sku/domain"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"regexp"
 )
 
 const collectionName = "sku"
 
+var tracer = otel.Tracer("feeder-service/internal/sku/infrastructure/persistence/mongo")
+
 type SkuRepository struct {
 	collection *mongo.Collection
 	hydrator   *domain.Hydrator
@@ -26,10 +35,16 @@ func NewSkuRepository(db *mongo.Database, hydrator *domain.Hydrator) (*SkuReposi
 
 var ErrFind = fmt.Errorf("error during find execution")
 
-func (r *SkuRepository) Find(ctx context.Context, id *domain.SkuId) (*domain.Sku, error) {
+func (r *SkuRepository) Find(ctx context.Context, id *domain.SkuId, statuses ...domain.Status) (sku *domain.Sku, err error) {
+	ctx, span := startSpan(ctx, "mongo.SkuRepository.Find", id.Key())
+	defer func() { endSpan(span, err) }()
 	var skuDTO *domain.SkuDTO
 
-	result := r.collection.FindOne(ctx, bson.M{"_id": id.Value()})
+	filter := bson.M{"_id": id.Key()}
+	if len(statuses) > 0 {
+		filter["$or"] = statusFilter(statuses)
+	}
+	result := r.collection.FindOne(ctx, filter)
 	if result.Err() != nil {
 		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
 			return nil, nil
@@ -37,7 +52,7 @@ func (r *SkuRepository) Find(ctx context.Context, id *domain.SkuId) (*domain.Sku
 		return nil, result.Err()
 	}
 
-	err := result.Decode(&skuDTO)
+	err = result.Decode(&skuDTO)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrFind, err.Error())
 	}
@@ -46,15 +61,264 @@ func (r *SkuRepository) Find(ctx context.Context, id *domain.SkuId) (*domain.Sku
 
 var ErrSave = fmt.Errorf("error during save execution")
 
-func (r *SkuRepository) Save(ctx context.Context, sku *domain.Sku) error {
+// Save upserts the sku: a new one is inserted as it is and an existing one gets its last seen time, seen count
+// and sources updated with the sku sighting, returning domain.ErrSkuAlreadyExists.
+func (r *SkuRepository) Save(ctx context.Context, sku *domain.Sku) (err error) {
 	skuDTO := r.hydrator.Dehydrate(sku)
-	_, err := r.collection.InsertOne(ctx, skuDTO)
+	ctx, span := startSpan(ctx, "mongo.SkuRepository.Save", skuDTO.ID)
+	defer func() { endSpan(span, err) }()
+	update := bson.M{
+		"$setOnInsert": insertOnlyFields(skuDTO),
+		"$max":         bson.M{"last_seen": skuDTO.LastSeen},
+		"$inc":         bson.M{"seen_count": skuDTO.SeenCount},
+	}
+	if len(skuDTO.Sources) > 0 {
+		update["$addToSet"] = bson.M{"sources": bson.M{"$each": skuDTO.Sources}}
+	}
+
+	result, err := r.upsert(ctx, skuDTO.ID, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %s", domain.ErrSkuDeleted, skuDTO.ID)
+	}
 	if err != nil {
-		if mongo.IsDuplicateKeyError(err) {
-			return fmt.Errorf("%w: %s", domain.ErrSkuAlreadyExists, err.Error())
-		}
 		return fmt.Errorf("%w: %s", ErrSave, err.Error())
 	}
+	if result.UpsertedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrSkuAlreadyExists, skuDTO.ID)
+	}
 
 	return nil
 }
+
+var ErrUpdate = fmt.Errorf("error during update execution")
+
+// Update sets the attributes and status of an existing sku when its stored version is still the one of the sku,
+// the sightings are left as they are.
+func (r *SkuRepository) Update(ctx context.Context, sku *domain.Sku) (err error) {
+	skuDTO := r.hydrator.Dehydrate(sku)
+	ctx, span := startSpan(ctx, "mongo.SkuRepository.Update", skuDTO.ID)
+	defer func() { endSpan(span, err) }()
+	fields := attributeFields(skuDTO)
+	fields["status"] = skuDTO.Status
+	fields["version"] = skuDTO.Version + 1
+	filter := bson.M{"_id": skuDTO.ID, "version": versionFilter(skuDTO.Version)}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": fields})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUpdate, err.Error())
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": skuDTO.ID})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUpdate, err.Error())
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrSkuNotFound, skuDTO.ID)
+	}
+
+	return fmt.Errorf("%w: %s is not version %d anymore", domain.ErrConcurrentModification, skuDTO.ID, skuDTO.Version)
+}
+
+var ErrScan = fmt.Errorf("error during scan execution")
+
+// Scan reads the skus ordered by id in a snapshot session, so the changes made while it's scanning are not seen.
+// The snapshot reads need a replica set or a sharded cluster, and a scan can't last longer than the snapshot
+// history of the server (5 minutes by default). On a standalone server the skus are read with a plain cursor,
+// so an sku changed while it's scanning may be seen with its new state.
+func (r *SkuRepository) Scan(ctx context.Context, filter domain.SkuFilter, fn func(*domain.Sku) error) (err error) {
+	ctx, span := startSpan(ctx, "mongo.SkuRepository.Scan", "")
+	defer func() { endSpan(span, err) }()
+
+	sessionOptions := options.Session()
+	if r.supportsSnapshots(ctx) {
+		sessionOptions.SetSnapshot(true)
+	}
+	session, err := r.collection.Database().Client().StartSession(sessionOptions)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrScan, err.Error())
+	}
+	defer session.EndSession(ctx)
+
+	return mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
+		cursor, err := r.collection.Find(sessionCtx, scanFilter(filter), options.Find().SetSort(bson.M{"_id": 1}))
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrScan, err.Error())
+		}
+		defer cursor.Close(sessionCtx)
+
+		for cursor.Next(sessionCtx) {
+			var skuDTO domain.SkuDTO
+			err = cursor.Decode(&skuDTO)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrScan, err.Error())
+			}
+			err = fn(r.hydrator.Hydrate(&skuDTO))
+			if err != nil {
+				return err
+			}
+		}
+		if cursor.Err() != nil {
+			return fmt.Errorf("%w: %s", ErrScan, cursor.Err().Error())
+		}
+
+		return nil
+	})
+}
+
+// supportsSnapshots returns whether the server is a replica set member or a mongos, the ones with snapshot reads.
+func (r *SkuRepository) supportsSnapshots(ctx context.Context) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := r.collection.Database().RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+
+	return err == nil && (hello.SetName != "" || hello.Msg == "isdbgrid")
+}
+
+var ErrRestore = fmt.Errorf("error during restore execution")
+
+// Restore replaces the stored sku, or inserts it, with the document of the sku as it is.
+func (r *SkuRepository) Restore(ctx context.Context, sku *domain.Sku) (err error) {
+	skuDTO := r.hydrator.Dehydrate(sku)
+	ctx, span := startSpan(ctx, "mongo.SkuRepository.Restore", skuDTO.ID)
+	defer func() { endSpan(span, err) }()
+	_, err = r.collection.ReplaceOne(ctx, bson.M{"_id": skuDTO.ID}, skuDTO, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRestore, err.Error())
+	}
+
+	return nil
+}
+
+// startSpan records the span of an operation over the sku with the id, child of the span of the context.
+func startSpan(ctx context.Context, name, id string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.mongodb.collection", collectionName),
+		attribute.String("sku.id", id),
+	))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// insertOnlyFields returns the fields that are only stored when the sku is created, the attributes of an
+// existing sku are not replaced by the ones of its duplicates.
+func insertOnlyFields(skuDTO *domain.SkuDTO) bson.M {
+	fields := attributeFields(skuDTO)
+	fields["first_seen"] = skuDTO.FirstSeen
+	if skuDTO.Tenant != "" {
+		fields["tenant"] = skuDTO.Tenant
+	}
+	fields["status"] = skuDTO.Status
+	fields["version"] = skuDTO.Version
+
+	return fields
+}
+
+// attributeFields returns the known attributes of the sku.
+func attributeFields(skuDTO *domain.SkuDTO) bson.M {
+	fields := bson.M{}
+	if skuDTO.Name != "" {
+		fields["name"] = skuDTO.Name
+	}
+	if skuDTO.Brand != "" {
+		fields["brand"] = skuDTO.Brand
+	}
+	if skuDTO.Category != "" {
+		fields["category"] = skuDTO.Category
+	}
+	if skuDTO.Price != nil {
+		fields["price"] = skuDTO.Price
+	}
+	if skuDTO.Stock != nil {
+		fields["stock"] = *skuDTO.Stock
+	}
+
+	return fields
+}
+
+// upsert retries once on a duplicate key error, as two concurrent upserts of a new sku can both try to insert it
+// and the one that loses has to update the sku inserted by the other. The tombstones of the deleted skus don't
+// match the filter, so their upserts always end with a duplicate key error.
+func (r *SkuRepository) upsert(ctx context.Context, id string, update bson.M) (*mongo.UpdateResult, error) {
+	filter := bson.M{"_id": id, "status": bson.M{"$ne": domain.StatusDeleted.String()}}
+	upsertOptions := options.Update().SetUpsert(true)
+	result, err := r.collection.UpdateOne(ctx, filter, update, upsertOptions)
+	if mongo.IsDuplicateKeyError(err) {
+		return r.collection.UpdateOne(ctx, filter, update, upsertOptions)
+	}
+
+	return result, err
+}
+
+// statusFilter matches the skus with any of the statuses, the ones stored without status are active.
+func statusFilter(statuses []domain.Status) bson.A {
+	values := bson.A{}
+	filter := bson.A{}
+	for _, status := range statuses {
+		values = append(values, status.String())
+		if status == domain.StatusActive {
+			filter = append(filter, bson.M{"status": bson.M{"$exists": false}})
+		}
+	}
+
+	return append(filter, bson.M{"status": bson.M{"$in": values}})
+}
+
+// scanFilter matches the skus selected by the filter.
+func scanFilter(filter domain.SkuFilter) bson.M {
+	conditions := bson.A{}
+	switch filter.Tenant {
+	case "":
+	case domain.DefaultTenant:
+		conditions = append(conditions, bson.M{"tenant": bson.M{"$exists": false}})
+	default:
+		conditions = append(conditions, bson.M{"tenant": filter.Tenant.String()})
+	}
+	if len(filter.Prefixes) > 0 {
+		prefixes := bson.A{}
+		for _, prefix := range filter.Prefixes {
+			prefixes = append(prefixes, bson.M{"_id": primitive.Regex{Pattern: keyPattern(filter.Tenant) + regexp.QuoteMeta(prefix)}})
+		}
+		conditions = append(conditions, bson.M{"$or": prefixes})
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, bson.M{"$or": statusFilter(filter.Statuses)})
+	}
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+
+	return bson.M{"$and": conditions}
+}
+
+// keyPattern matches the start of the keys of the tenant up to the sku value, the keys of the skus of the default
+// tenant are their value and the rest of them are prefixed with the tenant and a colon.
+func keyPattern(tenant domain.Tenant) string {
+	switch tenant {
+	case "":
+		return "^(?:[a-z0-9][a-z0-9_-]*:)?"
+	case domain.DefaultTenant:
+		return "^"
+	default:
+		return "^" + regexp.QuoteMeta(tenant.String()+":")
+	}
+}
+
+// versionFilter matches the skus with the version, the ones stored without version have version 0.
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return version
+}
@@ -10,7 +10,10 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const skuValue = "KASL-3423"
@@ -50,7 +53,7 @@ func (s *IntegrationSuite) TestSaveAndFind() {
 
 	skuId, err := domain.NewSkuId(skuValue)
 	s.Require().NoError(err)
-	sku := domain.NewSku(skuId)
+	sku := domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")
 
 	err = s.repository.Save(s.ctx, sku)
 	s.Require().NoError(err)
@@ -60,6 +63,260 @@ func (s *IntegrationSuite) TestSaveAndFind() {
 	s.Require().True(sku.Id().Equal(skuFromRepository.Id()))
 }
 
+func (s *IntegrationSuite) TestSaveAndFindTheAttributes() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("ATTR-0001")
+	s.Require().NoError(err)
+	price, err := domain.NewPrice("19.99", "EUR")
+	s.Require().NoError(err)
+	stock := 0
+	attributes, err := domain.NewAttributes("Running shoes", "Acme", "Footwear", price, &stock)
+	s.Require().NoError(err)
+
+	err = s.repository.Save(s.ctx, domain.NewSku(skuId, attributes, time.Now(), ""))
+	s.Require().NoError(err)
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(attributes, skuFromRepository.Attributes())
+}
+
+func (s *IntegrationSuite) TestUpdateTheAttributesOfAnExistingSku() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("UPDT-0001")
+	s.Require().NoError(err)
+	attributes, err := domain.NewAttributes("Running shoes", "Acme", "", nil, nil)
+	s.Require().NoError(err)
+	sku := domain.NewSku(skuId, attributes, time.Now(), "10.0.0.1")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+
+	newAttributes, err := domain.NewAttributes("", "Acme Sports", "", nil, nil)
+	s.Require().NoError(err)
+	s.Require().NoError(sku.Update(newAttributes))
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal("Running shoes", skuFromRepository.Attributes().Name())
+	s.Require().Equal("Acme Sports", skuFromRepository.Attributes().Brand())
+	s.Require().Equal(1, skuFromRepository.SeenCount())
+}
+
+func (s *IntegrationSuite) TestUpdateOfAStaleVersionReturnErrConcurrentModification() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("VERS-0001")
+	s.Require().NoError(err)
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	sku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	staleSku, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+
+	s.Require().NoError(sku.Deactivate())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+	s.Require().NoError(staleSku.Delete())
+	err = s.repository.Update(s.ctx, staleSku)
+	s.Require().ErrorIs(err, domain.ErrConcurrentModification)
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusInactive, skuFromRepository.Status())
+	s.Require().Equal(1, skuFromRepository.Version())
+}
+
+func (s *IntegrationSuite) TestUpdateReturnErrSkuNotFoundWhenTheSkuDoesNotExist() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("UPDT-0002")
+	s.Require().NoError(err)
+	err = s.repository.Update(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), ""))
+	s.Require().ErrorIs(err, domain.ErrSkuNotFound)
+}
+
+func (s *IntegrationSuite) TestSaveADuplicateRecordsTheSighting() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("DUPL-0001")
+	s.Require().NoError(err)
+	firstSeen := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	lastSeen := firstSeen.Add(time.Hour)
+
+	err = s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, firstSeen, "10.0.0.1"))
+	s.Require().NoError(err)
+	err = s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, lastSeen, "10.0.0.2"))
+	s.Require().ErrorIs(err, domain.ErrSkuAlreadyExists)
+	err = s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, firstSeen.Add(time.Minute), "10.0.0.1"))
+	s.Require().ErrorIs(err, domain.ErrSkuAlreadyExists)
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(3, skuFromRepository.SeenCount())
+	s.Require().True(firstSeen.Equal(skuFromRepository.FirstSeen()))
+	s.Require().True(lastSeen.Equal(skuFromRepository.LastSeen()))
+	s.Require().Equal([]string{"10.0.0.1", "10.0.0.2"}, skuFromRepository.Sources())
+}
+
+func (s *IntegrationSuite) TestConcurrentSavesOfTheSameSkuAreAllCounted() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("CONC-0001")
+	s.Require().NoError(err)
+	var wg sync.WaitGroup
+	var created int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")) == nil {
+				atomic.AddInt32(&created, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(20, skuFromRepository.SeenCount())
+	s.Require().Equal(int32(1), created)
+}
+
+func (s *IntegrationSuite) TestSaveOfADeletedSkuReturnErrSkuDeletedAndKeepsTheTombstone() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("TOMB-0001")
+	s.Require().NoError(err)
+	sku := domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+	s.Require().NoError(sku.Delete())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+
+	err = s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), ""))
+	s.Require().ErrorIs(err, domain.ErrSkuDeleted)
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusDeleted, skuFromRepository.Status())
+	s.Require().Equal(1, skuFromRepository.SeenCount())
+}
+
+func (s *IntegrationSuite) TestFindOnlyReturnsTheSkusWithTheGivenStatuses() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("STAT-0001")
+	s.Require().NoError(err)
+	sku := domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId, domain.StatusActive)
+	s.Require().NoError(err)
+	s.Require().NotNil(skuFromRepository)
+
+	s.Require().NoError(sku.Deactivate())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+	skuFromRepository, err = s.repository.Find(s.ctx, skuId, domain.StatusActive)
+	s.Require().NoError(err)
+	s.Require().Nil(skuFromRepository)
+	skuFromRepository, err = s.repository.Find(s.ctx, skuId, domain.StatusActive, domain.StatusInactive)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusInactive, skuFromRepository.Status())
+}
+
+func (s *IntegrationSuite) TestTheSameSkuOfDifferentTenantsAreDifferentSkus() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	defaultSkuId, err := domain.NewSkuId("TENA-0001")
+	s.Require().NoError(err)
+	acmeSkuId, err := domain.DefaultSkuIdFormat.NewTenantSkuId("acme", "TENA-0001")
+	s.Require().NoError(err)
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(defaultSkuId, domain.Attributes{}, time.Now(), "")))
+	s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(acmeSkuId, domain.Attributes{}, time.Now(), "")))
+
+	skuFromRepository, err := s.repository.Find(s.ctx, acmeSkuId)
+	s.Require().NoError(err)
+	s.Require().True(acmeSkuId.Equal(skuFromRepository.Id()))
+	s.Require().False(defaultSkuId.Equal(skuFromRepository.Id()))
+	s.Require().Equal(1, skuFromRepository.SeenCount())
+}
+
+func (s *IntegrationSuite) TestScanReturnsTheSkusSelectedByTheFilterOrderedByKey() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	for _, value := range []string{"SCNB-0001", "SCNA-0002", "SCNA-0001", "OTHR-0001"} {
+		skuId, err := domain.NewSkuId(value)
+		s.Require().NoError(err)
+		s.Require().NoError(s.repository.Save(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	}
+	acmeSkuId, err := domain.DefaultSkuIdFormat.NewTenantSkuId("acme", "SCNA-0003")
+	s.Require().NoError(err)
+	acmeSku := domain.NewSku(acmeSkuId, domain.Attributes{}, time.Now(), "")
+	s.Require().NoError(s.repository.Save(s.ctx, acmeSku))
+	s.Require().NoError(acmeSku.Deactivate())
+	s.Require().NoError(s.repository.Update(s.ctx, acmeSku))
+
+	s.Require().Equal([]string{"SCNA-0001", "SCNA-0002", "SCNB-0001", "acme:SCNA-0003"}, s.scan(domain.SkuFilter{Prefixes: []string{"SCNA", "SCNB"}}))
+	s.Require().Equal([]string{"SCNA-0001", "SCNA-0002"}, s.scan(domain.SkuFilter{Tenant: domain.DefaultTenant, Prefixes: []string{"SCNA"}}))
+	s.Require().Equal([]string{"acme:SCNA-0003"}, s.scan(domain.SkuFilter{Tenant: "acme", Prefixes: []string{"SCN"}}))
+	s.Require().Equal([]string{"acme:SCNA-0003"}, s.scan(domain.SkuFilter{Prefixes: []string{"SCN"}, Statuses: []domain.Status{domain.StatusInactive}}))
+}
+
+func (s *IntegrationSuite) TestRestoreStoresTheSkuAsItIs() {
+	s.initMongoDatabase()
+	err := s.initSkuRepository()
+	s.Require().NoError(err)
+
+	skuId, err := domain.NewSkuId("RSTR-0001")
+	s.Require().NoError(err)
+	sku := domain.NewSku(skuId, domain.Attributes{}, time.Now(), "10.0.0.1")
+	s.Require().NoError(s.repository.Save(s.ctx, sku))
+	s.Require().NoError(sku.Delete())
+	s.Require().NoError(s.repository.Update(s.ctx, sku))
+	tombstone, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.repository.Restore(s.ctx, domain.NewSku(skuId, domain.Attributes{}, time.Now(), "")))
+	s.Require().NoError(s.repository.Restore(s.ctx, tombstone))
+
+	skuFromRepository, err := s.repository.Find(s.ctx, skuId)
+	s.Require().NoError(err)
+	s.Require().Equal(domain.StatusDeleted, skuFromRepository.Status())
+	s.Require().Equal(1, skuFromRepository.Version())
+	s.Require().Equal([]string{"10.0.0.1"}, skuFromRepository.Sources())
+}
+
+func (s *IntegrationSuite) scan(filter domain.SkuFilter) []string {
+	var keys []string
+	err := s.repository.Scan(s.ctx, filter, func(sku *domain.Sku) error {
+		keys = append(keys, sku.Id().Key())
+		return nil
+	})
+	s.Require().NoError(err)
+
+	return keys
+}
+
 func (s *IntegrationSuite) initMongoDatabase() {
 	mongoClient, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
 	s.Require().NoError(err)
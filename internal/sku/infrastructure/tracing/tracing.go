@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const ServiceName = "feeder-service"
+
+const (
+	// ExporterNone doesn't record any span.
+	ExporterNone = "none"
+	// ExporterStdout writes the spans to the standard output as JSON.
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends the spans to an OTLP collector over HTTP, configured with the standard OTEL_EXPORTER_OTLP_*
+	// env vars (e.g. OTEL_EXPORTER_OTLP_ENDPOINT).
+	ExporterOTLP = "otlp"
+)
+
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+func CheckExporter(exporter string) error {
+	switch exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownExporter, exporter)
+	}
+}
+
+// NewTracerProvider returns a provider that exports the spans in batches with the exporter, it has to be shut down
+// to export the last spans. It fails for ExporterNone, as no provider is needed to record nothing.
+func NewTracerProvider(ctx context.Context, exporter string) (*sdktrace.TracerProvider, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownExporter, exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter), sdktrace.WithResource(serviceResource())), nil
+}
+
+// NewInMemoryTracerProvider returns a provider that keeps the spans in memory as soon as they end, so the tests
+// can check them in the returned exporter.
+func NewInMemoryTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	spanExporter := tracetest.NewInMemoryExporter()
+
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter), sdktrace.WithResource(serviceResource())), spanExporter
+}
+
+func serviceResource() *resource.Resource {
+	return resource.NewSchemaless(attribute.String("service.name", ServiceName))
+}
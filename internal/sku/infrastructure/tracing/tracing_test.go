@@ -0,0 +1,43 @@
+//+build unit
+
+package tracing_test
+
+import (
+	"context"
+	"feeder-service/internal/sku/infrastructure/tracing"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type UnitSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *UnitSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func TestUnitSuite(t *testing.T) {
+	suite.Run(t, new(UnitSuite))
+}
+
+func (s *UnitSuite) TestUnknownExportersAreRejected() {
+	s.Require().NoError(tracing.CheckExporter(tracing.ExporterOTLP))
+	s.Require().ErrorIs(tracing.CheckExporter("jaeger"), tracing.ErrUnknownExporter)
+
+	_, err := tracing.NewTracerProvider(s.ctx, tracing.ExporterNone)
+	s.Require().ErrorIs(err, tracing.ErrUnknownExporter)
+}
+
+func (s *UnitSuite) TestTheInMemoryTracerProviderKeepsTheEndedSpans() {
+	tracerProvider, exporter := tracing.NewInMemoryTracerProvider()
+	_, span := tracerProvider.Tracer("test").Start(s.ctx, "sku_reader.Read")
+	s.Require().Empty(exporter.GetSpans())
+	span.End()
+
+	spans := exporter.GetSpans()
+	s.Require().Len(spans, 1)
+	s.Require().Equal("sku_reader.Read", spans[0].Name)
+	s.Require().NoError(tracerProvider.Shutdown(s.ctx))
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Outcomes of the messages, as acknowledged by the server. They're the ones counted in its run report.
+const (
+	OutcomeCreated     = "created"
+	OutcomeDuplicated  = "duplicated"
+	OutcomeUpdated     = "updated"
+	OutcomeUnchanged   = "unchanged"
+	OutcomeNotFound    = "not_found"
+	OutcomeDeactivated = "deactivated"
+	OutcomeReactivated = "reactivated"
+	OutcomeDeleted     = "deleted"
+	OutcomeTombstoned  = "tombstoned"
+	OutcomeInvalid     = "invalid"
+	// OutcomeTerminating is the acknowledgement of the terminate message, the server stops after it.
+	OutcomeTerminating = "terminating"
+)
+
+// ReasonPersistence is the reason of the invalid messages that failed storing their sku, they're retried.
+const ReasonPersistence = "persistence_error"
+
+// Ack is the response of the server to a message of a session.
+type Ack struct {
+	Outcome string
+	// Reason is why the message is invalid, e.g. "invalid_format", it's empty for the other outcomes.
+	Reason string
+}
+
+var ErrMalformedAck = errors.New("malformed ack")
+
+var outcomes = map[string]struct{}{
+	OutcomeCreated: {}, OutcomeDuplicated: {}, OutcomeUpdated: {}, OutcomeUnchanged: {}, OutcomeNotFound: {},
+	OutcomeDeactivated: {}, OutcomeReactivated: {}, OutcomeDeleted: {}, OutcomeTombstoned: {},
+	OutcomeInvalid: {}, OutcomeTerminating: {},
+}
+
+// ParseAck parses a response line of the server: the outcome of the message, followed by the reason when it's
+// invalid, e.g. "created" or "invalid invalid_format".
+func ParseAck(line string) (Ack, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Ack{}, fmt.Errorf("%w: empty line", ErrMalformedAck)
+	}
+	if _, ok := outcomes[fields[0]]; !ok {
+		return Ack{}, fmt.Errorf("%w: unknown outcome %q", ErrMalformedAck, fields[0])
+	}
+	if fields[0] == OutcomeInvalid && len(fields) == 2 {
+		return Ack{Outcome: OutcomeInvalid, Reason: fields[1]}, nil
+	}
+	if fields[0] == OutcomeInvalid || len(fields) != 1 {
+		return Ack{}, fmt.Errorf("%w: %q", ErrMalformedAck, line)
+	}
+
+	return Ack{Outcome: fields[0]}, nil
+}
+
+func (a Ack) Invalid() bool {
+	return a.Outcome == OutcomeInvalid
+}
+
+func (a Ack) String() string {
+	if a.Invalid() {
+		return a.Outcome + " " + a.Reason
+	}
+
+	return a.Outcome
+}
@@ -0,0 +1,37 @@
+//+build unit
+
+package client_test
+
+import (
+	"feeder-service/pkg/client"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type AckUnitSuite struct {
+	suite.Suite
+}
+
+func TestAckUnitSuite(t *testing.T) {
+	suite.Run(t, new(AckUnitSuite))
+}
+
+func (s *AckUnitSuite) TestParseTheOutcomeAndTheReasonOfTheInvalidMessages() {
+	ack, err := client.ParseAck("created\n")
+	s.Require().NoError(err)
+	s.Require().Equal(client.Ack{Outcome: client.OutcomeCreated}, ack)
+	s.Require().False(ack.Invalid())
+
+	ack, err = client.ParseAck("invalid invalid_format\r\n")
+	s.Require().NoError(err)
+	s.Require().Equal(client.Ack{Outcome: client.OutcomeInvalid, Reason: "invalid_format"}, ack)
+	s.Require().True(ack.Invalid())
+	s.Require().Equal("invalid invalid_format", ack.String())
+}
+
+func (s *AckUnitSuite) TestReturnErrMalformedAckWhenTheLineIsNotAnAck() {
+	for _, line := range []string{"", "\n", "archived", "invalid", "created invalid_format", "invalid a b"} {
+		_, err := client.ParseAck(line)
+		s.Require().ErrorIs(err, client.ErrMalformedAck, line)
+	}
+}
@@ -0,0 +1,160 @@
+// Package client sends skus to the feeder service through its tcp socket, so the feeders don't have to speak
+// its protocol themselves. The messages are sent in sessions, every message is acknowledged by the server with
+// its outcome, and the sessions are pooled and reused between messages.
+package client
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	DefaultConnections  = 4
+	DefaultRetries      = 3
+	DefaultRetryBackoff = 100 * time.Millisecond
+	DefaultTimeout      = 10 * time.Second
+)
+
+// Client sends messages through a pool of sessions with the server, it's safe for concurrent use.
+type Client struct {
+	addr         string
+	retries      int
+	retryBackoff time.Duration
+	timeout      time.Duration
+	// slots has a token for every open session and idle holds the sessions that aren't sending a message.
+	slots chan struct{}
+	idle  chan *Session
+}
+
+type Option func(*Client)
+
+// WithConnections limits the number of sessions open at once, it's the number of messages sent concurrently.
+func WithConnections(connections int) Option {
+	return func(c *Client) {
+		if connections > 0 {
+			c.slots = make(chan struct{}, connections)
+			c.idle = make(chan *Session, connections)
+		}
+	}
+}
+
+// WithRetries sends again up to retries times the messages that fail or whose sku couldn't be stored, waiting
+// the backoff times the attempt before each retry. Zero retries sends every message once.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithTimeout limits the time to dial the server and to send every message and get its ack.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+func New(addr string, options ...Option) *Client {
+	client := &Client{
+		addr:         addr,
+		retries:      DefaultRetries,
+		retryBackoff: DefaultRetryBackoff,
+		timeout:      DefaultTimeout,
+	}
+	WithConnections(DefaultConnections)(client)
+	for _, option := range options {
+		option(client)
+	}
+
+	return client
+}
+
+// Send sends a message and returns its ack, retrying it when the session fails or its sku couldn't be stored.
+// A message retried after its ack was lost may be handled twice, the idempotency_key of the JSON messages
+// makes the server replay the first result instead. An invalid message returns ErrInvalidMessage without being
+// sent nor retried.
+func (c *Client) Send(ctx context.Context, message string) (Ack, error) {
+	ack, _, err := c.sendWithRetries(ctx, message)
+
+	return ack, err
+}
+
+// sendWithRetries returns the ack of the message and how many times it was retried.
+func (c *Client) sendWithRetries(ctx context.Context, message string) (Ack, int, error) {
+	err := validateMessage(message)
+	if err != nil {
+		return Ack{}, 0, err
+	}
+	for attempt := 0; ; attempt++ {
+		ack, err := c.send(ctx, message)
+		if err == nil && !(ack.Invalid() && ack.Reason == ReasonPersistence) {
+			return ack, attempt, nil
+		}
+		if attempt >= c.retries {
+			return ack, attempt, err
+		}
+
+		timer := time.NewTimer(c.retryBackoff * time.Duration(attempt+1))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ack, attempt, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, message string) (Ack, error) {
+	session, err := c.session(ctx)
+	if err != nil {
+		return Ack{}, err
+	}
+	ack, err := session.Send(ctx, message)
+	if err != nil {
+		c.discard(session)
+		return Ack{}, err
+	}
+	c.idle <- session
+
+	return ack, nil
+}
+
+// session returns an idle session, or a new one when there is none and the pool is not full.
+func (c *Client) session(ctx context.Context) (*Session, error) {
+	select {
+	case session := <-c.idle:
+		return session, nil
+	default:
+	}
+
+	select {
+	case session := <-c.idle:
+		return session, nil
+	case c.slots <- struct{}{}:
+		session, err := Dial(ctx, c.addr, c.timeout)
+		if err != nil {
+			<-c.slots
+			return nil, err
+		}
+		return session, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Client) discard(session *Session) {
+	_ = session.Close()
+	<-c.slots
+}
+
+// Close closes the sessions that aren't sending a message, it's meant to be called once every message is sent.
+func (c *Client) Close() error {
+	for {
+		select {
+		case session := <-c.idle:
+			c.discard(session)
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,167 @@
+//+build integration
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"feeder-service/internal/sku/infrastructure/io/socket/tcp/sku_reader"
+	"feeder-service/pkg/client"
+	"github.com/stretchr/testify/suite"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const addr = "localhost:4100"
+
+type ClientIntegrationSuite struct {
+	suite.Suite
+	ctx       context.Context
+	listener  *countingListener
+	skuReader *sku_reader.SkuReaderImpl
+	readers   sync.WaitGroup
+	mutex     sync.Mutex
+	received  map[string]int
+}
+
+// countingListener counts the accepted connections.
+type countingListener struct {
+	net.Listener
+	accepted int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt32(&l.accepted, 1)
+	}
+
+	return conn, err
+}
+
+func (s *ClientIntegrationSuite) SetupTest() {
+	s.ctx = context.Background()
+	listener, err := net.Listen("tcp", addr)
+	s.Require().NoError(err)
+	s.listener = &countingListener{Listener: listener}
+	s.skuReader, err = sku_reader.New(s.listener)
+	s.Require().NoError(err)
+	s.received = map[string]int{}
+	s.serve(4)
+}
+
+func (s *ClientIntegrationSuite) TearDownTest() {
+	s.Require().NoError(s.skuReader.Close())
+	s.readers.Wait()
+}
+
+func TestClientIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(ClientIntegrationSuite))
+}
+
+func (s *ClientIntegrationSuite) TestFeedSendsEveryLineAndCountsTheirAcks() {
+	feederClient := client.New(addr, client.WithConnections(2))
+	defer feederClient.Close()
+
+	summary, err := feederClient.Feed(s.ctx, strings.NewReader("KASL-3423\nKASL-3423\n\ninvalid\n{\"sku\": \"SLOS-4332\"}\n"))
+	s.Require().NoError(err)
+	s.Require().Equal(4, summary.Sent)
+	s.Require().Equal(2, summary.CreatedSkus)
+	s.Require().Equal(1, summary.DuplicatedSkus)
+	s.Require().Equal(1, summary.InvalidSkus)
+	s.Require().Equal(map[string]int{"invalid_format": 1}, summary.InvalidReasons)
+	s.Require().Equal(0, summary.Failed)
+	s.Require().LessOrEqual(atomic.LoadInt32(&s.listener.accepted), int32(2))
+}
+
+func (s *ClientIntegrationSuite) TestTheSessionsAreReusedBetweenMessages() {
+	feederClient := client.New(addr, client.WithConnections(1))
+	defer feederClient.Close()
+
+	for _, message := range []string{"KASL-3423", "KASL-3424", "KASL-3425"} {
+		ack, err := feederClient.Send(s.ctx, message)
+		s.Require().NoError(err)
+		s.Require().Equal(client.OutcomeCreated, ack.Outcome)
+	}
+	s.Require().Equal(int32(1), atomic.LoadInt32(&s.listener.accepted))
+}
+
+func (s *ClientIntegrationSuite) TestMessagesWhoseSkuCouldNotBeStoredAreRetried() {
+	feederClient := client.New(addr, client.WithRetries(2, time.Millisecond))
+	defer feederClient.Close()
+
+	summary, err := feederClient.Feed(s.ctx, strings.NewReader("FAIL-0001\n"))
+	s.Require().NoError(err)
+	s.Require().Equal(1, summary.CreatedSkus)
+	s.Require().Equal(1, summary.Retries)
+	s.Require().Equal(0, summary.InvalidSkus)
+}
+
+func (s *ClientIntegrationSuite) TestMessagesAreFailedWhenTheServerCantBeReachedAfterEveryRetry() {
+	feederClient := client.New("localhost:4101", client.WithRetries(1, time.Millisecond))
+	defer feederClient.Close()
+
+	summary, err := feederClient.Feed(s.ctx, strings.NewReader("KASL-3423\n"))
+	s.Require().NoError(err)
+	s.Require().Equal(1, summary.Sent)
+	s.Require().Equal(1, summary.Failed)
+	s.Require().Equal(1, summary.Retries)
+	s.Require().Len(summary.Errors, 1)
+}
+
+func (s *ClientIntegrationSuite) TestSendRejectsTheInvalidMessagesWithoutDiscardingTheSession() {
+	feederClient := client.New(addr, client.WithConnections(1))
+	defer feederClient.Close()
+
+	_, err := feederClient.Send(s.ctx, "KASL-3423")
+	s.Require().NoError(err)
+	for _, message := range []string{"KASL-3424\nKASL-3425", ""} {
+		_, err = feederClient.Send(s.ctx, message)
+		s.Require().ErrorIs(err, client.ErrInvalidMessage)
+	}
+	_, err = feederClient.Send(s.ctx, "KASL-3426")
+	s.Require().NoError(err)
+	s.Require().Equal(int32(1), atomic.LoadInt32(&s.listener.accepted))
+}
+
+// serve acks the messages like the server does: the first message of every sku is created and the next ones are
+// duplicated, the skus starting with FAIL can't be stored the first time.
+func (s *ClientIntegrationSuite) serve(concurrentReads int) {
+	for i := 0; i < concurrentReads; i++ {
+		s.readers.Add(1)
+		go func() {
+			defer s.readers.Done()
+			for {
+				message, err := s.skuReader.Read(time.Now().Add(time.Minute))
+				if errors.Is(err, sku_reader.ErrListenerClosed) {
+					return
+				}
+				if err == nil {
+					_ = message.Ack(s.ack(message.Value))
+				}
+			}
+		}()
+	}
+}
+
+func (s *ClientIntegrationSuite) ack(message string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sku := strings.TrimSuffix(strings.TrimPrefix(message, `{"sku": "`), `"}`)
+	if len(sku) != len("KASL-3423") {
+		return "invalid invalid_format"
+	}
+	s.received[sku]++
+	switch {
+	case strings.HasPrefix(sku, "FAIL") && s.received[sku] == 1:
+		return "invalid persistence_error"
+	case strings.HasPrefix(sku, "FAIL") && s.received[sku] == 2, s.received[sku] == 1:
+		return "created"
+	default:
+		return "duplicated"
+	}
+}
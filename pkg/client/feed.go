@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Summary counts the messages sent by their outcome, with the same names of the run report of the server,
+// and the ones that couldn't be sent.
+type Summary struct {
+	Sent            int            `json:"sent"`
+	CreatedSkus     int            `json:"created_skus"`
+	DuplicatedSkus  int            `json:"duplicated_skus"`
+	UpdatedSkus     int            `json:"updated_skus"`
+	UnchangedSkus   int            `json:"unchanged_skus"`
+	NotFoundSkus    int            `json:"not_found_skus"`
+	DeactivatedSkus int            `json:"deactivated_skus"`
+	ReactivatedSkus int            `json:"reactivated_skus"`
+	DeletedSkus     int            `json:"deleted_skus"`
+	TombstonedSkus  int            `json:"tombstoned_skus"`
+	InvalidSkus     int            `json:"invalid_skus"`
+	InvalidReasons  map[string]int `json:"invalid_reasons"`
+	// Failed counts the messages without ack after every retry, Errors holds the count of each error message.
+	Failed  int            `json:"failed"`
+	Errors  map[string]int `json:"errors"`
+	Retries int            `json:"retries"`
+	// Terminated is true when the server acknowledged a terminate message.
+	Terminated      bool    `json:"terminated"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+func newSummary() Summary {
+	return Summary{InvalidReasons: map[string]int{}, Errors: map[string]int{}}
+}
+
+func (s *Summary) record(ack Ack, retries int, err error) {
+	s.Sent++
+	s.Retries += retries
+	if err != nil {
+		s.Failed++
+		s.Errors[err.Error()]++
+		return
+	}
+
+	switch ack.Outcome {
+	case OutcomeCreated:
+		s.CreatedSkus++
+	case OutcomeDuplicated:
+		s.DuplicatedSkus++
+	case OutcomeUpdated:
+		s.UpdatedSkus++
+	case OutcomeUnchanged:
+		s.UnchangedSkus++
+	case OutcomeNotFound:
+		s.NotFoundSkus++
+	case OutcomeDeactivated:
+		s.DeactivatedSkus++
+	case OutcomeReactivated:
+		s.ReactivatedSkus++
+	case OutcomeDeleted:
+		s.DeletedSkus++
+	case OutcomeTombstoned:
+		s.TombstonedSkus++
+	case OutcomeInvalid:
+		s.InvalidSkus++
+		s.InvalidReasons[ack.Reason]++
+	case OutcomeTerminating:
+		s.Terminated = true
+	}
+}
+
+// maxMessageLength is the longest line Feed reads, longer ones fail with bufio.ErrTooLong.
+const maxMessageLength = 1024 * 1024
+
+// Feed sends every non empty line of the reader as a message, through as many sessions at once as connections
+// the client has, and returns the summary of their acks. It returns an error when the reader fails, with the
+// summary of the messages sent until then.
+func (c *Client) Feed(ctx context.Context, reader io.Reader) (Summary, error) {
+	startedAt := time.Now()
+	summary := newSummary()
+	var mutex sync.Mutex
+	messages := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(c.slots); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for message := range messages {
+				ack, retries, err := c.sendWithRetries(ctx, message)
+				mutex.Lock()
+				summary.record(ack, retries, err)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageLength)
+	for scanner.Scan() && ctx.Err() == nil {
+		message := strings.TrimSpace(scanner.Text())
+		if message != "" {
+			messages <- message
+		}
+	}
+	close(messages)
+	wg.Wait()
+	summary.DurationSeconds = time.Since(startedAt).Seconds()
+
+	return summary, scanner.Err()
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// sessionHeader is the first line of a connection that opens a session, so the server acknowledges every message
+// and keeps the connection open for the next one.
+const sessionHeader = "session"
+
+// Session is a connection to the server that sends messages one after another, waiting for the ack of each one.
+// It's not safe for concurrent use, see Client.
+type Session struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Dial opens a session with the server at addr. The timeout limits the dial and every message sent through the
+// session, besides the deadline of the context, a zero timeout doesn't limit them.
+func Dial(ctx context.Context, addr string, timeout time.Duration) (*Session, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{conn: conn, reader: bufio.NewReader(conn), timeout: timeout}
+	err = session.write(ctx, sessionHeader)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Send sends a message, a plain sku or a JSON message, and returns its ack. After an error the session can't be
+// used anymore, as the ack of the message may still arrive.
+func (s *Session) Send(ctx context.Context, message string) (Ack, error) {
+	err := validateMessage(message)
+	if err != nil {
+		return Ack{}, err
+	}
+	err = s.write(ctx, message)
+	if err != nil {
+		return Ack{}, err
+	}
+	line, err := s.reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return Ack{}, err
+	}
+
+	return ParseAck(line)
+}
+
+// validateMessage returns ErrInvalidMessage unless the message is a single non empty line.
+func validateMessage(message string) error {
+	if message == "" || strings.ContainsAny(message, "\r\n") {
+		return fmt.Errorf("%w: it must be a single non empty line", ErrInvalidMessage)
+	}
+
+	return nil
+}
+
+func (s *Session) write(ctx context.Context, line string) error {
+	err := s.conn.SetDeadline(s.deadline(ctx))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(s.conn, line+"\n")
+
+	return err
+}
+
+// deadline is the closest one between the deadline of the context and the timeout of the session.
+func (s *Session) deadline(ctx context.Context) time.Time {
+	deadline, ok := ctx.Deadline()
+	if s.timeout > 0 {
+		timeoutDeadline := time.Now().Add(s.timeout)
+		if !ok || timeoutDeadline.Before(deadline) {
+			return timeoutDeadline
+		}
+	}
+
+	return deadline
+}
+
+// Close ends the session, the server reads no more messages from it.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}